@@ -15,6 +15,14 @@
 //
 // Run with: go run ./cmd/demo
 //
+// For CI, go run ./cmd/demo -json prints only the outcome, as one object:
+//
+//   {"quorum_size":3,"chosen":"hello, paxos!",
+//    "learned":{"node-0":"hello, paxos!", ...},"pass":true}
+//
+// Nodes that learned nothing are missing from "learned". The exit status is
+// 1 when "pass" is false.
+//
 // =============================================================================
 // DEMO SCENARIO
 // =============================================================================
@@ -270,26 +278,62 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"time"
 
 	"quorum/internal/node"
+	"quorum/internal/paxos"
 	"quorum/internal/storage"
 	"quorum/internal/transport"
 )
 
+// demoResult is what -json prints: one object a CI job can check.
+type demoResult struct {
+	QuorumSize int               `json:"quorum_size"`
+	Chosen     string            `json:"chosen"`
+	Learned    map[string]string `json:"learned"`
+	Pass       bool              `json:"pass"`
+}
+
 func main() {
-	fmt.Println("=============================================================================")
-	fmt.Println("                    Single-Decree Paxos Demo")
-	fmt.Println("=============================================================================")
-	fmt.Println()
+	jsonOutput := flag.Bool("json", false, "print the result as one JSON object instead of the trace")
+	flag.Parse()
+	result, err := run(*jsonOutput, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *jsonOutput && !result.Pass {
+		os.Exit(1)
+	}
+}
+
+// run plays the demo, writing the trace to stdout, or only the result
+// object when jsonOutput is set.
+func run(jsonOutput bool, stdout io.Writer) (demoResult, error) {
+	out := stdout
+	if jsonOutput {
+		out = io.Discard
+	}
+
+	fmt.Fprintln(out, "=============================================================================")
+	fmt.Fprintln(out, "                    Single-Decree Paxos Demo")
+	fmt.Fprintln(out, "=============================================================================")
+	fmt.Fprintln(out)
 
 	numNodes := 5
 	quorumSize := (numNodes / 2) + 1 // Majority = 3
 
-	fmt.Printf("Starting Paxos cluster with %d nodes...\n", numNodes)
-	fmt.Printf("Quorum size: %d\n\n", quorumSize)
+	if err := paxos.ValidateQuorum(quorumSize, numNodes); err != nil {
+		return demoResult{}, fmt.Errorf("bad cluster config: %w", err)
+	}
+
+	fmt.Fprintf(out, "Starting Paxos cluster with %d nodes...\n", numNodes)
+	fmt.Fprintf(out, "Quorum size: %d\n\n", quorumSize)
 
 	network := transport.NewNetwork()
 
@@ -298,57 +342,75 @@ func main() {
 		id := fmt.Sprintf("node-%d", i)
 		s := storage.NewMemoryStorage()
 		trans := network.AddNode(id)
-		nodes[i] = node.NewNode(id, quorumSize, trans, s)
+		n, err := node.NewNode(id, quorumSize, trans, s)
+		if err != nil {
+			return demoResult{}, fmt.Errorf("create node: %w", err)
+		}
+		nodes[i] = n
 	}
 
 	for _, n := range nodes {
 		if err := n.Start(); err != nil {
-			log.Fatalf("Failed to start node: %v", err)
+			return demoResult{}, fmt.Errorf("start node: %w", err)
 		}
 	}
-	fmt.Println("All nodes started!")
-	fmt.Println()
+	fmt.Fprintln(out, "All nodes started!")
+	fmt.Fprintln(out)
 
 	value := []byte("hello, paxos!")
-	fmt.Printf("Node-0 proposing: \"%s\"\n\n", string(value))
+	fmt.Fprintf(out, "Node-0 proposing: \"%s\"\n\n", string(value))
 
 	chosenValue, err := nodes[0].Propose(value)
 	if err != nil {
-		log.Fatalf("Propose failed: %v", err)
+		return demoResult{}, fmt.Errorf("propose: %w", err)
 	}
 
-	fmt.Println()
-	fmt.Printf("✓ Chosen value: \"%s\"\n\n", string(chosenValue))
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "✓ Chosen value: \"%s\"\n\n", string(chosenValue))
 
 	time.Sleep(100 * time.Millisecond)
 
-	fmt.Println("Final state (what each node learned):")
-	fmt.Println("─────────────────────────────────────")
+	fmt.Fprintln(out, "Final state (what each node learned):")
+	fmt.Fprintln(out, "─────────────────────────────────────")
 
+	result := demoResult{
+		QuorumSize: quorumSize,
+		Chosen:     string(chosenValue),
+		Learned:    make(map[string]string),
+	}
 	allAgree := true
 	for i, n := range nodes {
 		v, ok := n.GetChosenValue()
 		if ok {
-			fmt.Printf("  Node-%d: learned \"%s\" ✓\n", i, string(v))
+			result.Learned[n.ID()] = string(v)
+			fmt.Fprintf(out, "  Node-%d: learned \"%s\" ✓\n", i, string(v))
 			if string(v) != string(chosenValue) {
 				allAgree = false
 			}
 		} else {
-			fmt.Printf("  Node-%d: learned nothing yet ✗\n", i)
+			fmt.Fprintf(out, "  Node-%d: learned nothing yet ✗\n", i)
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
 
 	if allAgree {
-		fmt.Println("✓ Consensus achieved! All nodes that learned agree on the value.")
+		fmt.Fprintln(out, "✓ Consensus achieved! All nodes that learned agree on the value.")
 	} else {
-		fmt.Println("✗ SAFETY VIOLATION: Nodes disagree on the chosen value!")
+		fmt.Fprintln(out, "✗ SAFETY VIOLATION: Nodes disagree on the chosen value!")
 	}
 
-	fmt.Println()
-	fmt.Println("Stopping all nodes...")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Stopping all nodes...")
 	for _, n := range nodes {
 		n.Stop()
 	}
-	fmt.Println("Demo complete!")
+	fmt.Fprintln(out, "Demo complete!")
+
+	result.Pass = allAgree
+	if jsonOutput {
+		if err := json.NewEncoder(stdout).Encode(result); err != nil {
+			return result, fmt.Errorf("encode result: %w", err)
+		}
+	}
+	return result, nil
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONOutputShowsAgreement(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := run(true, &out); err != nil {
+		t.Fatal(err)
+	}
+	var result demoResult
+	dec := json.NewDecoder(&out)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&result); err != nil {
+		t.Fatalf("output is not one result object: %v", err)
+	}
+	if dec.More() {
+		t.Fatal("output has more than the result object")
+	}
+	if !result.Pass || result.QuorumSize != 3 || result.Chosen == "" {
+		t.Fatalf("result = %+v", result)
+	}
+	if len(result.Learned) != 5 {
+		t.Fatalf("learned = %v, want all 5 nodes", result.Learned)
+	}
+	for id, v := range result.Learned {
+		if v != result.Chosen {
+			t.Fatalf("%s learned %q, chosen was %q", id, v, result.Chosen)
+		}
+	}
+}
+
+func TestTraceIsDefault(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := run(false, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Consensus achieved") || strings.HasPrefix(out.String(), "{") {
+		t.Fatalf("trace mode printed:\n%s", out.String())
+	}
+}
@@ -0,0 +1,206 @@
+// =============================================================================
+// QUORUMCTL - Operator CLI for a TCP Cluster
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// The demo builds its own cluster in memory. quorumctl talks to one that is
+// already running over TCPTransport:
+//
+//   quorumctl -peers node-0=10.0.0.1:7000,node-1=10.0.0.2:7000,node-2=10.0.0.3:7000 \
+//             propose "hello"
+//   chosen: "hello"
+//
+// Subcommands:
+//
+//   propose <value>   run Paxos for slot 0 and print the chosen value
+//   get               read slot 0 through a quorum (Proposer.Read)
+//   status            dial every member and report whether a quorum is up
+//   members           print the member list and quorum size
+//
+// =============================================================================
+// HOW IT TALKS TO THE CLUSTER
+// =============================================================================
+//
+// Nodes have no client protocol; they only speak Paxos. So quorumctl joins
+// as a proposer with no acceptor: it listens on -listen under -id, sends
+// Prepare/Accept to every peer, and waits for their replies like any other
+// proposer. Its proposal numbers carry its own id, so they never collide
+// with a node's.
+//
+// A TCP node replies over its own outbound connection, dialed from its peer
+// list. Every node must therefore list quorumctl's -id and -listen address
+// among its peers, like one more member that never votes.
+//
+// The member list comes from -peers: there is no message for asking a node
+// who its members are, so members prints what quorumctl was told.
+//
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"quorum/internal/node"
+	"quorum/internal/paxos"
+	"quorum/internal/transport"
+)
+
+const (
+	defaultID     = "quorumctl"
+	defaultListen = "127.0.0.1:7400"
+	dialTimeout   = time.Second
+	// Nodes still hold connections to a previous quorumctl run; the first
+	// reply on each is lost when the write fails, so resend promptly.
+	retransmitAfter = 200 * time.Millisecond
+)
+
+var errUsage = errors.New("usage: quorumctl [flags] propose <value> | get | status | members")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("quorumctl", flag.ContinueOnError)
+	id := fs.String("id", defaultID, "this client's node id, as listed in the nodes' peers")
+	listen := fs.String("listen", defaultListen, "address the nodes reply to")
+	peerList := fs.String("peers", "", "comma-separated id=host:port of every member")
+	timeout := fs.Duration("timeout", 5*time.Second, "give up after this long")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	peers, err := parsePeers(*peerList)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errUsage
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	switch cmd := fs.Arg(0); cmd {
+	case "propose":
+		if fs.NArg() != 2 {
+			return errUsage
+		}
+		return withProposer(*id, *listen, peers, func(p *paxos.Proposer) error {
+			chosen, err := p.ProposeDetailedContext(ctx, []byte(fs.Arg(1)))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "chosen: %q\n", chosen.Value)
+			if !chosen.OwnValueChosen {
+				fmt.Fprintln(out, "(an earlier value was already chosen)")
+			}
+			return nil
+		})
+	case "get":
+		return withProposer(*id, *listen, peers, func(p *paxos.Proposer) error {
+			value, ok, err := p.Read(ctx)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(out, "nothing chosen")
+				return nil
+			}
+			fmt.Fprintf(out, "chosen: %q\n", value)
+			return nil
+		})
+	case "status":
+		return status(out, peers)
+	case "members":
+		for _, member := range sortedIDs(peers) {
+			fmt.Fprintf(out, "%s\t%s\n", member, peers[member])
+		}
+		fmt.Fprintf(out, "quorum: %d of %d\n", quorumOf(peers), len(peers))
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q: %w", cmd, errUsage)
+	}
+}
+
+// parsePeers reads "id=addr,id=addr".
+func parsePeers(list string) (map[string]string, error) {
+	peers := make(map[string]string)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, addr, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || addr == "" {
+			return nil, fmt.Errorf("bad peer %q, want id=host:port", entry)
+		}
+		peers[id] = addr
+	}
+	if len(peers) == 0 {
+		return nil, errors.New("-peers is required")
+	}
+	return peers, nil
+}
+
+func quorumOf(peers map[string]string) int {
+	return len(peers)/2 + 1
+}
+
+func sortedIDs(peers map[string]string) []string {
+	ids := make([]string, 0, len(peers))
+	for id := range peers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func withProposer(id, listen string, peers map[string]string, fn func(*paxos.Proposer) error) error {
+	t, err := transport.NewTCPTransport(id, listen, peers, transport.GobCodec{})
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+	p, err := paxos.NewProposer(id, quorumOf(peers), node.ProposerTransport(t),
+		paxos.WithAcceptors(sortedIDs(peers)), paxos.WithRetransmit(retransmitAfter))
+	if err != nil {
+		return err
+	}
+	return fn(p)
+}
+
+// status only checks that each member accepts connections; a node that is
+// up but partitioned from the rest still shows as up.
+func status(out io.Writer, peers map[string]string) error {
+	up := 0
+	for _, id := range sortedIDs(peers) {
+		conn, err := net.DialTimeout("tcp", peers[id], dialTimeout)
+		if err != nil {
+			fmt.Fprintf(out, "%s\t%s\tdown (%v)\n", id, peers[id], err)
+			continue
+		}
+		conn.Close()
+		up++
+		fmt.Fprintf(out, "%s\t%s\tup\n", id, peers[id])
+	}
+	if up < quorumOf(peers) {
+		return fmt.Errorf("%d of %d members up, quorum needs %d", up, len(peers), quorumOf(peers))
+	}
+	fmt.Fprintf(out, "%d of %d members up, quorum %d: ok\n", up, len(peers), quorumOf(peers))
+	return nil
+}
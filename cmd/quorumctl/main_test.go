@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"quorum/internal/node"
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+// startCluster runs three TCP nodes in-process, each listing ctlAddr as the
+// quorumctl peer, and returns the -peers flag that reaches them.
+func startCluster(t *testing.T, ctlAddr string) string {
+	t.Helper()
+	ts := make([]*transport.TCPTransport, 3)
+	var peers []string
+	for i := range ts {
+		tr, err := transport.NewTCPTransport(fmt.Sprintf("n%d", i), "127.0.0.1:0", nil, transport.GobCodec{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts[i] = tr
+		peers = append(peers, fmt.Sprintf("n%d=%s", i, tr.Addr()))
+	}
+	for i, tr := range ts {
+		for j, peer := range ts {
+			if i != j {
+				tr.AddPeer(fmt.Sprintf("n%d", j), peer.Addr().String())
+			}
+		}
+		tr.AddPeer(defaultID, ctlAddr)
+		n, err := node.NewNode(fmt.Sprintf("n%d", i), 2, tr, storage.NewMemoryStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Start(); err != nil {
+			t.Fatal(err)
+		}
+		tr := tr
+		t.Cleanup(func() {
+			n.Stop()
+			tr.Close()
+		})
+	}
+	return strings.Join(peers, ",")
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestProposeThenGet(t *testing.T) {
+	ctlAddr := freeAddr(t)
+	peers := startCluster(t, ctlAddr)
+	ctl := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := run(append([]string{"-listen", ctlAddr, "-peers", peers}, args...), &out); err != nil {
+			t.Fatalf("quorumctl %v: %v\n%s", args, err, out.String())
+		}
+		return out.String()
+	}
+
+	if got := ctl("get"); got != "nothing chosen\n" {
+		t.Fatalf("get before propose printed %q", got)
+	}
+	if got := ctl("propose", "hello"); got != "chosen: \"hello\"\n" {
+		t.Fatalf("propose printed %q", got)
+	}
+	if got := ctl("get"); got != "chosen: \"hello\"\n" {
+		t.Fatalf("get printed %q", got)
+	}
+	if got := ctl("propose", "other"); !strings.Contains(got, "chosen: \"hello\"") || !strings.Contains(got, "already chosen") {
+		t.Fatalf("second propose printed %q, want the earlier value", got)
+	}
+	if got := ctl("status"); !strings.Contains(got, "3 of 3 members up") {
+		t.Fatalf("status printed %q", got)
+	}
+	if got := ctl("members"); !strings.HasSuffix(got, "quorum: 2 of 3\n") {
+		t.Fatalf("members printed %q", got)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"-peers", "n0=127.0.0.1:1"},
+		{"-peers", "n0=127.0.0.1:1", "propose"},
+		{"-peers", "n0=127.0.0.1:1", "frobnicate"},
+	} {
+		if err := run(args, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "usage") {
+			t.Errorf("run(%v) = %v, want a usage error", args, err)
+		}
+	}
+	if err := run([]string{"get"}, &bytes.Buffer{}); err == nil {
+		t.Error("run without -peers succeeded")
+	}
+}
@@ -0,0 +1,202 @@
+// =============================================================================
+// CLUSTER - Wiring N Nodes Over One In-Memory Network
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Every test and demo starts the same way: make a Network, add N transports,
+// build N nodes with a majority quorum, start them all, and remember to stop
+// them afterwards. Cluster does that once:
+//
+//   c, err := cluster.New(5)
+//   defer c.Stop()
+//
+//   chosen, err := c.Propose([]byte("hello"))
+//   agreed, err := c.WaitConsensus(time.Second)
+//
+// Node IDs are "node-0" .. "node-N-1", matching cmd/demo.
+//
+// =============================================================================
+// OPTIONS
+// =============================================================================
+//
+// By default every node gets its own MemoryStorage and default proposer
+// settings. Use WithStorage to supply durable storage per node and
+// WithProposerOptions to pass paxos.ProposerOption values to every node.
+// WithRecording wraps every node's transport in a RecordingTransport
+// sharing one Journal, which ToDOT draws from (see dot.go).
+//
+// =============================================================================
+
+package cluster
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"quorum/internal/node"
+	"quorum/internal/paxos"
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+var (
+	ErrNoConsensus = errors.New("nodes did not agree before the deadline")
+	ErrNoNodes     = errors.New("a cluster needs at least one node")
+)
+
+const consensusPollInterval = 10 * time.Millisecond
+
+type config struct {
+	newStorage      func(id string) storage.Storage
+	proposerOptions []paxos.ProposerOption
+	record          bool
+}
+
+type NodeOption func(*config)
+
+// WithStorage sets the storage each node is built with.
+func WithStorage(newStorage func(id string) storage.Storage) NodeOption {
+	return func(c *config) {
+		c.newStorage = newStorage
+	}
+}
+
+func WithProposerOptions(opts ...paxos.ProposerOption) NodeOption {
+	return func(c *config) {
+		c.proposerOptions = append(c.proposerOptions, opts...)
+	}
+}
+
+// WithRecording records every node's traffic in one Journal.
+func WithRecording() NodeOption {
+	return func(c *config) {
+		c.record = true
+	}
+}
+
+type Cluster struct {
+	network *transport.Network
+	nodes   []*node.Node
+	journal *transport.Journal
+}
+
+// New builds and starts an n-node cluster with a majority quorum. If any
+// node fails to start, the ones already running are stopped.
+func New(n int, opts ...NodeOption) (*Cluster, error) {
+	if n < 1 {
+		return nil, ErrNoNodes
+	}
+	cfg := config{
+		newStorage: func(string) storage.Storage {
+			return storage.NewMemoryStorage()
+		},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	quorumSize := n/2 + 1
+	if err := paxos.ValidateQuorum(quorumSize, n); err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{network: transport.NewNetwork()}
+	if cfg.record {
+		c.journal = transport.NewJournal()
+	}
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("node-%d", i)
+	}
+	for _, id := range ids {
+		var t transport.Transport = c.network.AddNode(id)
+		if c.journal != nil {
+			t = transport.NewRecordingTransport(id, t, c.journal)
+		}
+		nd, err := node.NewNode(id, quorumSize, t, cfg.newStorage(id), cfg.proposerOptions...)
+		if err != nil {
+			return nil, err
+		}
+		if err := nd.SetMembers(ids); err != nil {
+			return nil, err
+		}
+		c.nodes = append(c.nodes, nd)
+	}
+	for i, nd := range c.nodes {
+		if err := nd.Start(); err != nil {
+			for _, started := range c.nodes[:i] {
+				started.Stop()
+			}
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Propose proposes value through node-0.
+func (c *Cluster) Propose(value []byte) ([]byte, error) {
+	return c.nodes[0].Propose(value)
+}
+
+func (c *Cluster) Nodes() []*node.Node {
+	return c.nodes
+}
+
+func (c *Cluster) Network() *transport.Network {
+	return c.network
+}
+
+// Journal is the shared journal under WithRecording, or nil.
+func (c *Cluster) Journal() *transport.Journal {
+	return c.journal
+}
+
+// WaitConsensus waits until every node has learned the same chosen value
+// and returns it, or fails with ErrNoConsensus after timeout.
+func (c *Cluster) WaitConsensus(timeout time.Duration) ([]byte, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if value, ok := c.agreed(); ok {
+			return value, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrNoConsensus
+		}
+		time.Sleep(consensusPollInterval)
+	}
+}
+
+func (c *Cluster) agreed() ([]byte, bool) {
+	var first []byte
+	for i, nd := range c.nodes {
+		value, ok := nd.GetChosenValue()
+		if !ok {
+			return nil, false
+		}
+		if i == 0 {
+			first = value
+		} else if !bytes.Equal(value, first) {
+			return nil, false
+		}
+	}
+	return first, true
+}
+
+// Stop stops every node, closes the network, and returns the first error
+// encountered.
+func (c *Cluster) Stop() error {
+	var first error
+	for _, nd := range c.nodes {
+		if err := nd.Stop(); err != nil && first == nil {
+			first = err
+		}
+	}
+	if err := c.network.Close(); err != nil && first == nil {
+		first = err
+	}
+	return first
+}
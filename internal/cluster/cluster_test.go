@@ -0,0 +1,39 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClusterAgrees(t *testing.T) {
+	c, err := New(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	if _, err := c.Propose([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	v, err := c.WaitConsensus(2 * time.Second)
+	if err != nil || string(v) != "x" {
+		t.Fatalf("WaitConsensus = %q, %v, want x", v, err)
+	}
+}
+
+func TestWaitConsensusTimesOut(t *testing.T) {
+	c, err := New(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	if _, err := c.WaitConsensus(30 * time.Millisecond); !errors.Is(err, ErrNoConsensus) {
+		t.Fatalf("err = %v with nothing proposed, want ErrNoConsensus", err)
+	}
+}
+
+func TestNewRejectsEmptyCluster(t *testing.T) {
+	if _, err := New(0); !errors.Is(err, ErrNoNodes) {
+		t.Fatalf("New(0): err = %v, want ErrNoNodes", err)
+	}
+}
@@ -0,0 +1,107 @@
+// =============================================================================
+// DOT - Drawing the Cluster's State with Graphviz
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// ToDOT renders a snapshot of the cluster as a Graphviz graph, one box per
+// node showing its slot 0 state:
+//
+//   c, _ := cluster.New(3, cluster.WithRecording())
+//   c.Propose([]byte("hello"))
+//   os.WriteFile("cluster.dot", []byte(c.ToDOT()), 0o644)
+//   // dot -Tpng cluster.dot -o cluster.png
+//
+//   ┌───────────────────────────┐
+//   │ node-0 (leader)           │
+//   │ promised (round=1, ...)   │
+//   │ accepted (round=1, ...)   │
+//   │ chosen "hello"            │
+//   └───────────────────────────┘
+//
+// The leader is drawn bold, and nodes that haven't learned a value yet are
+// drawn dashed.
+//
+// =============================================================================
+// MESSAGE EDGES
+// =============================================================================
+//
+// Under WithRecording the graph also has an edge for each of the last
+// dotMaxEdges messages received, from sender to receiver, labelled with
+// the message type. That is usually the tail of the most recent proposal:
+// Prepare and Promise, then Accept and Accepted, then Learn. Without
+// recording there are no edges.
+//
+// =============================================================================
+
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	"quorum/internal/transport"
+)
+
+const dotMaxEdges = 32
+
+// ToDOT returns the cluster's current state as a Graphviz digraph. See the
+// banner above.
+func (c *Cluster) ToDOT() string {
+	var b strings.Builder
+	b.WriteString("digraph cluster {\n")
+	b.WriteString("  node [shape=box, fontname=monospace];\n")
+	for _, nd := range c.nodes {
+		st := nd.Describe()
+		title := st.ID
+		if st.Leader {
+			title += " (leader)"
+		}
+		lines := []string{
+			title,
+			"promised " + st.Promised.String(),
+			"accepted " + st.Accepted.String(),
+		}
+		style := "dashed"
+		if st.HasChosen {
+			lines = append(lines, fmt.Sprintf("chosen %q", st.Chosen))
+			style = "solid"
+		}
+		if st.Leader {
+			style += ",bold"
+		}
+		fmt.Fprintf(&b, "  %q [label=%q, style=%q];\n", st.ID, strings.Join(lines, "\n"), style)
+	}
+	for _, e := range c.lastReceived(dotMaxEdges) {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", e.Message.GetFrom(), e.Node, shortType(e.Type))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (c *Cluster) lastReceived(limit int) []transport.RecordedEvent {
+	if c.journal == nil {
+		return nil
+	}
+	var received []transport.RecordedEvent
+	for _, e := range c.journal.Events() {
+		if e.Kind == transport.EventReceive && e.Message != nil {
+			received = append(received, e)
+		}
+	}
+	if len(received) > limit {
+		received = received[len(received)-limit:]
+	}
+	return received
+}
+
+// shortType turns "paxos.Promise" into "Promise".
+func shortType(t string) string {
+	t = strings.TrimPrefix(t, "*")
+	if i := strings.LastIndex(t, "."); i >= 0 {
+		return t[i+1:]
+	}
+	return t
+}
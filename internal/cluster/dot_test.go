@@ -0,0 +1,61 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToDOTShowsEveryNodeAndItsValue(t *testing.T) {
+	c, err := New(3, WithRecording())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	if _, err := c.Propose([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.WaitConsensus(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	dot := c.ToDOT()
+	if !strings.HasPrefix(dot, "digraph cluster {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Fatalf("not a digraph:\n%s", dot)
+	}
+	lines := strings.Split(dot, "\n")
+	for _, nd := range c.Nodes() {
+		box := fmt.Sprintf("  %q [label=", nd.ID())
+		found := false
+		for _, line := range lines {
+			if strings.HasPrefix(line, box) {
+				found = true
+				if !strings.Contains(line, `chosen \"hello\"`) {
+					t.Fatalf("%s does not show its chosen value: %s", nd.ID(), line)
+				}
+			}
+		}
+		if !found {
+			t.Fatalf("no box for %s in:\n%s", nd.ID(), dot)
+		}
+	}
+	if !strings.Contains(dot, `[label="Accepted"]`) {
+		t.Fatalf("recording is on but no Accepted edge:\n%s", dot)
+	}
+}
+
+func TestToDOTWithoutRecordingHasNoEdges(t *testing.T) {
+	c, err := New(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	dot := c.ToDOT()
+	if strings.Contains(dot, "->") {
+		t.Fatalf("edges without recording:\n%s", dot)
+	}
+	if !strings.Contains(dot, `style="dashed"`) {
+		t.Fatalf("nodes with nothing chosen are not dashed:\n%s", dot)
+	}
+}
@@ -0,0 +1,56 @@
+// =============================================================================
+// DURABLE APPLY - Applying Each Slot Once Across Restarts
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// SetApplyFunc replays the whole log into fn every time the node starts.
+// That is what an in-memory state machine needs, since it starts empty. A
+// state machine that keeps its own state on disk needs the opposite: after
+// a restart it must not see the slots it already applied.
+//
+// SetDurableApplyFunc records the last applied slot in the node's Storage
+// (SaveApplied) after each call to fn, and resumes after it:
+//
+//   run 1:  slots 0-4 chosen    fn(0) .. fn(4)      applied = 4
+//   crash, restart over the same storage
+//   run 2:  replay 0-4, 5 chosen fn(5)              applied = 5
+//
+// Slots still arrive strictly in order, with NoOp slots skipped, exactly as
+// SetApplyFunc delivers them.
+//
+// =============================================================================
+// THE WINDOW
+// =============================================================================
+//
+// The index is saved right after fn returns, so a crash between the two
+// applies that one slot again on restart. A state machine that can't
+// tolerate that should store the slot with its own state and ignore
+// anything at or below it. If SaveApplied fails the error is logged and
+// delivery carries on; the slot will be applied again after a restart.
+//
+// =============================================================================
+
+package node
+
+import "log"
+
+// SetDurableApplyFunc registers fn like SetApplyFunc, but persists the last
+// slot applied and, on a node rebuilt over the same storage, starts after
+// it. See the banner above.
+func (n *Node) SetDurableApplyFunc(fn func(slot int64, value []byte)) error {
+	applied, err := n.storage.LoadApplied()
+	if err != nil {
+		return err
+	}
+	n.learner.SetApplyFrom(applied + 1)
+	n.learner.SetApplyFunc(func(slot int64, value []byte) {
+		fn(slot, value)
+		if err := n.storage.SaveApplied(slot); err != nil {
+			log.Printf("[%s] save applied slot %d: %v", n.id, slot, err)
+		}
+	})
+	return nil
+}
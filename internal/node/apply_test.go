@@ -0,0 +1,87 @@
+package node
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+// applyRecorder collects the slots a durable apply func was called with.
+type applyRecorder struct {
+	mu    sync.Mutex
+	slots []int64
+}
+
+func (r *applyRecorder) apply(slot int64, value []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.slots = append(r.slots, slot)
+}
+
+func (r *applyRecorder) applied() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.slots...)
+}
+
+func startDurable(t *testing.T, s storage.Storage) (*Node, *applyRecorder) {
+	t.Helper()
+	n, err := NewNode("n0", 1, transport.NewNetwork().AddNode("n0"), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &applyRecorder{}
+	if err := n.SetDurableApplyFunc(r.apply); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return n, r
+}
+
+func TestDurableApplyResumesAfterRestart(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	choose := func(slot int64) {
+		if err := s.SaveSlot(slot, storage.SlotState{Chosen: true, ChosenValue: []byte{byte('a' + slot)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for slot := int64(0); slot < 5; slot++ {
+		choose(slot)
+	}
+
+	n, r := startDurable(t, s)
+	eventually(t, time.Second, "slots 0-4 to be applied", func() bool { return len(r.applied()) == 5 })
+	if err := n.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	for i, slot := range r.applied() {
+		if slot != int64(i) {
+			t.Fatalf("first run applied %v, want 0-4 in order", r.applied())
+		}
+	}
+	if got, err := s.LoadApplied(); err != nil || got != 4 {
+		t.Fatalf("applied index = %d, %v, want 4", got, err)
+	}
+
+	// Restart over the same storage with two more slots chosen meanwhile.
+	choose(5)
+	choose(6)
+	n, r = startDurable(t, s)
+	defer n.Stop()
+	eventually(t, time.Second, "slots 5 and 6 to be applied", func() bool { return len(r.applied()) >= 2 })
+	time.Sleep(20 * time.Millisecond)
+	if got := r.applied(); len(got) != 2 || got[0] != 5 || got[1] != 6 {
+		t.Fatalf("second run applied %v, want only [5 6]", got)
+	}
+	if log := n.GetLog(); len(log) != 7 {
+		t.Fatalf("log has %d slots after restart, want all 7 replayed", len(log))
+	}
+	if got, err := s.LoadApplied(); err != nil || got != 6 {
+		t.Fatalf("applied index = %d, %v, want 6", got, err)
+	}
+}
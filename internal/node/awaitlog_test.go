@@ -0,0 +1,54 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAwaitLogWakesForItsSlot(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	n := nodes[0]
+	type result struct {
+		value []byte
+		err   error
+	}
+	got := make(chan result, 1)
+	go func() {
+		v, err := n.AwaitLog(context.Background(), 2)
+		got <- result{v, err}
+	}()
+
+	n.learnLocally(3, []byte("d"))
+	n.learnLocally(0, []byte("a"))
+	select {
+	case r := <-got:
+		t.Fatalf("AwaitLog(2) returned %q, %v before slot 2 was chosen", r.value, r.err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	n.learnLocally(2, []byte("c"))
+	select {
+	case r := <-got:
+		if r.err != nil || string(r.value) != "c" {
+			t.Fatalf("AwaitLog(2) = %q, %v, want c", r.value, r.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("AwaitLog(2) did not wake when slot 2 was chosen")
+	}
+
+	// An already chosen slot returns at once, even with slot 1 still open.
+	if v, err := n.AwaitLog(context.Background(), 3); err != nil || string(v) != "d" {
+		t.Fatalf("AwaitLog(3) = %q, %v, want d", v, err)
+	}
+}
+
+func TestAwaitLogCancelled(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := nodes[0].AwaitLog(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
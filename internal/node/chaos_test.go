@@ -0,0 +1,95 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+// chaosRun has two nodes of five duel for slot 0 under seeded chaos and
+// reports an error if two different values are ever seen chosen.
+// Proposals may time out; that only costs liveness.
+func chaosRun(seed int64) error {
+	net := transport.NewNetwork()
+	nodes := make([]*Node, 5)
+	for i := range nodes {
+		id := fmt.Sprintf("n%d", i)
+		n, err := NewNode(id, 3, net.AddNode(id), storage.NewMemoryStorage(), paxos.WithRetransmit(5*time.Millisecond))
+		if err != nil {
+			return err
+		}
+		if err := n.Start(); err != nil {
+			return err
+		}
+		defer n.Stop()
+		nodes[i] = n
+	}
+	net.EnableChaos(transport.ChaosConfig{
+		LossProb:      0.1,
+		DelayRange:    transport.DelayRange{Max: 2 * time.Millisecond},
+		ReorderProb:   0.1,
+		PartitionProb: 0.02,
+		Seed:          seed,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var mu sync.Mutex
+	var chosen [][]byte
+	var wg sync.WaitGroup
+	for _, n := range nodes[:2] {
+		n := n
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := n.ProposeAt(ctx, 0, []byte(n.ID())); err == nil {
+				mu.Lock()
+				chosen = append(chosen, v)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	net.DisableChaos()
+	time.Sleep(10 * time.Millisecond)
+
+	for _, n := range nodes {
+		if v, ok := n.learner.GetChosenAt(0); ok {
+			chosen = append(chosen, v)
+		}
+	}
+	for i := 1; i < len(chosen); i++ {
+		if !bytes.Equal(chosen[i], chosen[0]) {
+			return fmt.Errorf("seed %d: both %q and %q were chosen", seed, chosen[0], chosen[i])
+		}
+	}
+	return nil
+}
+
+func TestChaosNeverBreaksSafety(t *testing.T) {
+	runs := 100
+	if testing.Short() {
+		runs = 10
+	}
+	// Most of a run is waiting, so runs overlap in batches.
+	const batch = 20
+	for first := 1; first <= runs; first += batch {
+		errs := make(chan error, batch)
+		for seed := first; seed < first+batch && seed <= runs; seed++ {
+			seed := int64(seed)
+			go func() { errs <- chaosRun(seed) }()
+		}
+		for seed := first; seed < first+batch && seed <= runs; seed++ {
+			if err := <-errs; err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// =============================================================================
+// CONFIG - Building a Node From One Declarative Struct
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// NewNode takes a transport and a storage the caller has already built.
+// FromConfig builds them too, from a Config:
+//
+//   n, err := node.FromConfig(node.Config{
+//       ID:            "a",
+//       Peers:         map[string]string{"b": "10.0.0.2:7400", "c": "10.0.0.3:7400"},
+//       StorageKind:   node.StorageFile,
+//       StoragePath:   "/var/lib/quorum/a.wal",
+//       TransportKind: node.TransportTCP,
+//       ListenAddr:    "10.0.0.1:7400",
+//   })
+//   n.Start()
+//   defer n.Close()
+//
+// Peers maps every other member's ID to its address. The memory transport
+// ignores the addresses and needs Config.Network instead, the in-memory
+// network every node of the cluster shares.
+//
+// A QuorumSize of 0 means a majority of ID plus Peers, and the node is also
+// given that member list, as cluster.New does. An explicit QuorumSize is
+// used as is and no member list is set, because SetMembers would replace the
+// quorum with a majority again.
+//
+// =============================================================================
+// VALIDATION
+// =============================================================================
+//
+// Every problem FromConfig can see up front is reported as ErrInvalidConfig:
+// a missing ID, a peer with the node's own ID, a quorum larger than the
+// cluster or no larger than half of it (two such quorums need not overlap),
+// an unknown kind, or a storage path that can't be opened for writing.
+//
+// The node owns what FromConfig built. Stop leaves it open so the node can
+// be started again; Close stops the node and then closes the transport and
+// the storage.
+//
+// =============================================================================
+
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"quorum/internal/paxos"
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+var ErrInvalidConfig = errors.New("invalid node config")
+
+const (
+	StorageMemory = "memory"
+	StorageFile   = "file"
+
+	TransportMemory = "memory"
+	TransportTCP    = "tcp"
+	TransportUDP    = "udp"
+)
+
+// Timeouts are passed to the proposer and the failure detector. Zero
+// leaves the corresponding feature off.
+type Timeouts struct {
+	Quorum            time.Duration
+	Retransmit        time.Duration
+	HeartbeatInterval time.Duration
+	HeartbeatTimeout  time.Duration
+}
+
+type Config struct {
+	ID            string
+	Peers         map[string]string
+	QuorumSize    int
+	StorageKind   string
+	StoragePath   string
+	TransportKind string
+	ListenAddr    string
+	Timeouts      Timeouts
+
+	// Network is required by TransportMemory and ignored otherwise.
+	Network *transport.Network
+}
+
+func (c Config) members() []string {
+	members := []string{c.ID}
+	for id := range c.Peers {
+		members = append(members, id)
+	}
+	sort.Strings(members)
+	return members
+}
+
+func (c Config) validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("%w: ID is empty", ErrInvalidConfig)
+	}
+	if _, ok := c.Peers[c.ID]; ok {
+		return fmt.Errorf("%w: %s is listed as its own peer", ErrInvalidConfig, c.ID)
+	}
+	size := len(c.Peers) + 1
+	if c.QuorumSize != 0 && (c.QuorumSize > size || c.QuorumSize <= size/2) {
+		return fmt.Errorf("%w: quorum %d is not a majority of %d members", ErrInvalidConfig, c.QuorumSize, size)
+	}
+	switch c.StorageKind {
+	case StorageMemory:
+	case StorageFile:
+		if c.StoragePath == "" {
+			return fmt.Errorf("%w: file storage needs StoragePath", ErrInvalidConfig)
+		}
+	default:
+		return fmt.Errorf("%w: unknown storage kind %q", ErrInvalidConfig, c.StorageKind)
+	}
+	switch c.TransportKind {
+	case TransportMemory:
+		if c.Network == nil {
+			return fmt.Errorf("%w: memory transport needs Network", ErrInvalidConfig)
+		}
+	case TransportTCP, TransportUDP:
+		if c.ListenAddr == "" {
+			return fmt.Errorf("%w: %s transport needs ListenAddr", ErrInvalidConfig, c.TransportKind)
+		}
+	default:
+		return fmt.Errorf("%w: unknown transport kind %q", ErrInvalidConfig, c.TransportKind)
+	}
+	if c.Timeouts.HeartbeatInterval < 0 || c.Timeouts.HeartbeatTimeout < 0 {
+		return fmt.Errorf("%w: negative heartbeat timeout", ErrInvalidConfig)
+	}
+	return nil
+}
+
+func (c Config) openStorage() (storage.Storage, error) {
+	if c.StorageKind == StorageMemory {
+		return storage.NewMemoryStorage(), nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.StoragePath), 0o755); err != nil {
+		return nil, fmt.Errorf("%w: storage path %s: %v", ErrInvalidConfig, c.StoragePath, err)
+	}
+	s, err := storage.NewFileStorage(c.StoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: storage path %s: %v", ErrInvalidConfig, c.StoragePath, err)
+	}
+	return s, nil
+}
+
+func (c Config) openTransport() (transport.Transport, error) {
+	switch c.TransportKind {
+	case TransportTCP:
+		return transport.NewTCPTransport(c.ID, c.ListenAddr, c.Peers, transport.GobCodec{})
+	case TransportUDP:
+		return transport.NewUDPTransport(c.ID, c.ListenAddr, c.Peers, transport.GobCodec{})
+	}
+	return c.Network.AddNode(c.ID), nil
+}
+
+func (c Config) proposerOptions() []paxos.ProposerOption {
+	var opts []paxos.ProposerOption
+	if c.Timeouts.Quorum > 0 {
+		opts = append(opts, paxos.WithQuorumTimeout(c.Timeouts.Quorum))
+	}
+	if c.Timeouts.Retransmit > 0 {
+		opts = append(opts, paxos.WithAcceptors(c.members()), paxos.WithRetransmit(c.Timeouts.Retransmit))
+	}
+	return opts
+}
+
+// FromConfig validates c, opens the storage and transport it names, and
+// builds a node over them. The node is not started.
+func FromConfig(c Config) (*Node, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	s, err := c.openStorage()
+	if err != nil {
+		return nil, err
+	}
+	var owned []io.Closer
+	if closer, ok := s.(io.Closer); ok {
+		owned = append(owned, closer)
+	}
+	t, err := c.openTransport()
+	if err != nil {
+		closeAll(owned)
+		return nil, err
+	}
+	owned = append([]io.Closer{t}, owned...)
+	quorumSize := c.QuorumSize
+	if quorumSize == 0 {
+		quorumSize = len(c.members())/2 + 1
+	}
+	n, err := NewNode(c.ID, quorumSize, t, s, c.proposerOptions()...)
+	if err != nil {
+		closeAll(owned)
+		return nil, err
+	}
+	n.owned = owned
+	if c.QuorumSize == 0 {
+		if err := n.SetMembers(c.members()); err != nil {
+			closeAll(owned)
+			return nil, err
+		}
+	}
+	if c.Timeouts.HeartbeatInterval > 0 && c.Timeouts.HeartbeatTimeout > 0 {
+		peers := make([]string, 0, len(c.Peers))
+		for id := range c.Peers {
+			peers = append(peers, id)
+		}
+		n.SetFailureDetector(NewHeartbeatDetector(peers, c.Timeouts.HeartbeatInterval, c.Timeouts.HeartbeatTimeout))
+	}
+	return n, nil
+}
+
+// Close stops the node and closes the transport and storage FromConfig
+// opened for it. For a node built with NewNode it is the same as Stop.
+func (n *Node) Close() error {
+	if err := n.Stop(); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	owned := n.owned
+	n.owned = nil
+	n.mu.Unlock()
+	return closeAll(owned)
+}
+
+func closeAll(closers []io.Closer) error {
+	var first error
+	for _, c := range closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
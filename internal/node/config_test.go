@@ -0,0 +1,123 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"quorum/internal/testutil"
+	"quorum/internal/transport"
+)
+
+// configCluster builds n0, n1 and n2 from configs on one in-memory network;
+// edit adjusts each config first. The nodes are closed when the test ends.
+func configCluster(t *testing.T, net *transport.Network, edit func(*Config)) []*Node {
+	t.Helper()
+	ids := []string{"n0", "n1", "n2"}
+	nodes := make([]*Node, len(ids))
+	for i, id := range ids {
+		c := Config{ID: id, Peers: map[string]string{}, StorageKind: StorageMemory, TransportKind: TransportMemory, Network: net}
+		for _, peer := range ids {
+			if peer != id {
+				c.Peers[peer] = ""
+			}
+		}
+		if edit != nil {
+			edit(&c)
+		}
+		n, err := FromConfig(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { n.Close() })
+		nodes[i] = n
+	}
+	return nodes
+}
+
+func TestFromConfigMemory(t *testing.T) {
+	net := transport.NewNetwork()
+	defer net.Close()
+	nodes := configCluster(t, net, nil)
+	if got := nodes[0].Members(); len(got) != 3 {
+		t.Fatalf("members = %v, want all three", got)
+	}
+	if _, err := nodes[0].Propose([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.AssertConverged(t, nodes); string(got) != "x" {
+		t.Fatalf("agreed on %q, want x", got)
+	}
+}
+
+func TestFromConfigFileStorage(t *testing.T) {
+	dir := t.TempDir()
+	net := transport.NewNetwork()
+	defer net.Close()
+	nodes := configCluster(t, net, func(c *Config) {
+		c.StorageKind = StorageFile
+		c.StoragePath = filepath.Join(dir, "data", c.ID+".wal")
+	})
+	if _, err := nodes[0].Propose([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertConverged(t, nodes)
+	if err := nodes[0].Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data", "n0.wal")); err != nil {
+		t.Fatalf("no WAL where StoragePath said: %v", err)
+	}
+
+	restarted, err := FromConfig(Config{
+		ID:            "n0",
+		Peers:         map[string]string{"n1": "", "n2": ""},
+		StorageKind:   StorageFile,
+		StoragePath:   filepath.Join(dir, "data", "n0.wal"),
+		TransportKind: TransportMemory,
+		Network:       net,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	if err := restarted.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := restarted.GetChosenValue(); !ok || string(v) != "x" {
+		t.Fatalf("restarted n0 has %q, %v, want x from its WAL", v, ok)
+	}
+}
+
+func TestFromConfigRejectsBadConfig(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	good := func() Config {
+		return Config{ID: "n0", Peers: map[string]string{"n1": "", "n2": ""}, StorageKind: StorageMemory,
+			TransportKind: TransportMemory, Network: transport.NewNetwork()}
+	}
+	for name, edit := range map[string]func(*Config){
+		"no ID":             func(c *Config) { c.ID = "" },
+		"self as peer":      func(c *Config) { c.Peers["n0"] = "" },
+		"quorum too small":  func(c *Config) { c.QuorumSize = 1 },
+		"quorum too large":  func(c *Config) { c.QuorumSize = 4 },
+		"storage kind":      func(c *Config) { c.StorageKind = "tape" },
+		"no storage path":   func(c *Config) { c.StorageKind = StorageFile },
+		"unwritable path":   func(c *Config) { c.StorageKind, c.StoragePath = StorageFile, filepath.Join(blocker, "n0.wal") },
+		"transport kind":    func(c *Config) { c.TransportKind = "pigeon" },
+		"no network":        func(c *Config) { c.Network = nil },
+		"no listen address": func(c *Config) { c.TransportKind = TransportTCP },
+	} {
+		c := good()
+		edit(&c)
+		if _, err := FromConfig(c); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: err = %v, want ErrInvalidConfig", name, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"quorum/internal/testutil"
+	"quorum/internal/transport"
+)
+
+// forgottenAccept plays THE FORGOTTEN ACCEPT from testutil/crash.go on
+// three nodes: n2 gets X chosen by n0 and n1, n0 crashes and restarts from
+// its storage, then n1 proposes Y through n0 and n2. It returns the two
+// values the proposals came back with.
+func forgottenAccept(t *testing.T, lie bool) (first, second []byte) {
+	t.Helper()
+	net := transport.NewNetwork()
+	storages := make([]*testutil.CrashStorage, 3)
+	transports := make([]*transport.MemoryTransport, 3)
+	nodes := make([]*Node, 3)
+	start := func(i int) {
+		n, err := NewNode(fmt.Sprintf("n%d", i), 2, transports[i], storages[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { n.Stop() })
+		nodes[i] = n
+	}
+	for i := range nodes {
+		storages[i] = testutil.NewCrashStorage()
+		transports[i] = net.AddNode(fmt.Sprintf("n%d", i))
+		start(i)
+	}
+	storages[0].LieOnSync(lie)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	first, err := nodes[2].ProposeAt(ctx, 0, []byte("X"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes[0].Stop()
+	storages[0].Crash()
+	start(0)
+
+	second, err = nodes[1].ProposeAt(ctx, 0, []byte("Y"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return first, second
+}
+
+func TestCrashAfterAcceptKeepsChosenValue(t *testing.T) {
+	first, second := forgottenAccept(t, false)
+	if string(first) != "X" || string(second) != "X" {
+		t.Fatalf("proposals returned %q and %q, want X twice: the restarted acceptor forgot its accept", first, second)
+	}
+}
+
+// TestCrashAfterUnsyncedAcceptChoosesTwice shows why the acceptor syncs
+// before it acks: when the sync is a lie, the same run chooses two values.
+func TestCrashAfterUnsyncedAcceptChoosesTwice(t *testing.T) {
+	first, second := forgottenAccept(t, true)
+	if string(first) != "X" || string(second) != "Y" {
+		t.Fatalf("proposals returned %q and %q, want X then Y once the accept is lost", first, second)
+	}
+}
@@ -0,0 +1,128 @@
+// =============================================================================
+// FAILURE DETECTION - Heartbeats Between Nodes
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// HeartbeatDetector is the default paxos.FailureDetector. A node that has
+// one broadcasts a Heartbeat every interval, and marks a peer alive for
+// timeout after hearing anything from it - a heartbeat or any other
+// message:
+//
+//   fd := node.NewHeartbeatDetector(peers, 100*time.Millisecond, 500*time.Millisecond)
+//   n.SetFailureDetector(fd)
+//   n.Start()
+//
+// Peers start out alive, so a node that has just started doesn't refuse
+// to propose before the first heartbeats arrive.
+//
+// A *GossipDetector (gossip.go) is fed and gossiped the same way.
+// SetFailureDetector also takes any other paxos.FailureDetector; the node
+// then only passes it to the proposer and consults it in
+// TransferLeadership, and sends no heartbeats of its own.
+//
+// =============================================================================
+
+package node
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"quorum/internal/paxos"
+)
+
+var ErrTargetDown = errors.New("leadership target is suspected to be down")
+
+// Heartbeat says only that its sender is up.
+type Heartbeat struct {
+	From string
+}
+
+func (h Heartbeat) GetFrom() string {
+	return h.From
+}
+
+type HeartbeatDetector struct {
+	interval time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+	lastSeen map[string]time.Time
+	mu       sync.Mutex
+}
+
+func NewHeartbeatDetector(peers []string, interval, timeout time.Duration) *HeartbeatDetector {
+	d := &HeartbeatDetector{
+		interval: interval,
+		timeout:  timeout,
+		now:      time.Now,
+		lastSeen: make(map[string]time.Time),
+	}
+	start := d.now()
+	for _, id := range peers {
+		d.lastSeen[id] = start
+	}
+	return d
+}
+
+// Observe records that id was just heard from.
+func (d *HeartbeatDetector) Observe(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastSeen[id] = d.now()
+}
+
+// Alive reports whether id was heard from within the timeout. Unknown ids
+// are alive until proven otherwise.
+func (d *HeartbeatDetector) Alive(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seen, ok := d.lastSeen[id]
+	return !ok || d.now().Sub(seen) < d.timeout
+}
+
+func (d *HeartbeatDetector) Suspects() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var suspects []string
+	now := d.now()
+	for id, seen := range d.lastSeen {
+		if now.Sub(seen) >= d.timeout {
+			suspects = append(suspects, id)
+		}
+	}
+	sort.Strings(suspects)
+	return suspects
+}
+
+// SetFailureDetector gives the node a detector; call it before Start. A
+// *HeartbeatDetector or *GossipDetector is also fed and sent by the node.
+func (n *Node) SetFailureDetector(fd paxos.FailureDetector) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.detector = fd
+	n.heartbeats, _ = fd.(*HeartbeatDetector)
+	n.gossip, _ = fd.(*GossipDetector)
+	n.proposer.SetFailureDetector(fd)
+}
+
+func (n *Node) sendHeartbeats(d *HeartbeatDetector) {
+	defer n.wg.Done()
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stopCh:
+			return
+		case <-ticker.C:
+			if !n.waitWhilePaused() {
+				return
+			}
+			n.transport.Broadcast(Heartbeat{From: n.id})
+		}
+	}
+}
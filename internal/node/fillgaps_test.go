@@ -0,0 +1,55 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+)
+
+// logWithHole chooses a at slot 0 and c at slot 2, leaving slot 1 open.
+func logWithHole(t *testing.T) []*Node {
+	t.Helper()
+	_, nodes := newTestCluster(t, 3)
+	for slot, v := range map[int64]string{0: "a", 2: "c"} {
+		if _, err := nodes[0].ProposeAt(context.Background(), slot, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return nodes
+}
+
+func TestFillGapsChoosesNoOp(t *testing.T) {
+	nodes := logWithHole(t)
+	if err := nodes[0].FillGaps(3); err != nil {
+		t.Fatal(err)
+	}
+	v, ok := nodes[0].learner.GetChosenAt(1)
+	if !ok || !paxos.IsNoOp(v) {
+		t.Fatalf("slot 1 = %q, %v, want a NoOp", v, ok)
+	}
+	for slot, want := range map[int64]string{0: "a", 2: "c"} {
+		if v, _ := nodes[0].learner.GetChosenAt(slot); string(v) != want {
+			t.Fatalf("slot %d = %q after FillGaps, want %q", slot, v, want)
+		}
+	}
+}
+
+func TestFillGapsRecoversAcceptedValue(t *testing.T) {
+	nodes := logWithHole(t)
+	// An earlier proposer reached n1 at slot 1 and then vanished.
+	nodes[1].acceptor.HandleAccept(paxos.Accept{
+		Slot:           1,
+		ProposalNumber: paxos.NewProposalNumber(1, "gone"),
+		Value:          []byte("b"),
+		From:           "gone",
+	})
+	if err := nodes[0].FillGaps(3); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := nodes[0].learner.GetChosenAt(1); !ok || string(v) != "b" {
+		t.Fatalf("slot 1 = %q, %v, want the accepted b", v, ok)
+	}
+	eventually(t, 2*time.Second, "a contiguous log on n2", func() bool { return len(nodes[2].GetLog()) == 3 })
+}
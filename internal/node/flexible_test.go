@@ -0,0 +1,55 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+	"quorum/internal/storage"
+	"quorum/internal/testutil"
+	"quorum/internal/transport"
+)
+
+func TestFlexibleQuorumsReachConsensus(t *testing.T) {
+	net, nodes := newTestCluster(t, 5, paxos.WithFlexibleQuorums(4, 2, 5))
+	if _, err := nodes[0].Propose([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.AssertConverged(t, nodes); string(got) != "x" {
+		t.Fatalf("agreed on %q, want x", got)
+	}
+
+	// Once n0 holds a window promise, Phase 2 needs only two acceptors.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := nodes[0].Append(ctx, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	net.Partition("n0", "n3")
+	net.Partition("n0", "n4")
+	slot, err := nodes[0].Append(ctx, []byte("b"))
+	if err != nil {
+		t.Fatalf("Append with only n1 and n2 reachable: %v", err)
+	}
+	for _, n := range nodes[:3] {
+		n := n
+		eventually(t, time.Second, n.ID()+" to learn b", func() bool {
+			v, ok := n.learner.GetChosenAt(slot)
+			return ok && string(v) == "b"
+		})
+	}
+}
+
+func TestFlexibleQuorumsMustIntersect(t *testing.T) {
+	net := transport.NewNetwork()
+	defer net.Close()
+	_, err := NewNode("n0", 3, net.AddNode("n0"), storage.NewMemoryStorage(), paxos.WithFlexibleQuorums(2, 2, 5))
+	if !errors.Is(err, paxos.ErrInvalidQuorum) {
+		t.Fatalf("Q1=2, Q2=2 in 5 nodes: err = %v, want ErrInvalidQuorum", err)
+	}
+	if _, err := paxos.NewProposer("p1", 3, nil, paxos.WithFlexibleQuorums(4, 2, 5)); err != nil {
+		t.Fatalf("Q1=4, Q2=2 in 5 nodes: %v", err)
+	}
+}
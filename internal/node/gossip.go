@@ -0,0 +1,179 @@
+// =============================================================================
+// GOSSIP FAILURE DETECTION - Liveness Passed From Peer to Peer
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// HeartbeatDetector needs every node to hear from every other node directly,
+// so one broken link makes a live peer look dead. GossipDetector spreads
+// liveness instead. Each node keeps a heartbeat counter per peer and bumps
+// its own every interval. It then sends the whole vector in a Gossip
+// message to a few peers picked at random:
+//
+//   fd := node.NewGossipDetector("n1", peers, 100*time.Millisecond, time.Second)
+//   n.SetFailureDetector(fd)
+//   n.Start()
+//
+// A receiver keeps the higher counter for each node and marks that node seen
+// whenever its counter moves. News of n3 reaches n1 through n2 when the
+// n1-n3 link is down. A node whose counter stops moving is suspected once
+// timeout passes without news of it. As with HeartbeatDetector, any message
+// heard straight from a peer also counts as seeing it.
+//
+// =============================================================================
+// CHOOSING THE NUMBERS
+// =============================================================================
+//
+// A counter needs a few gossip rounds to cross the cluster: about log(N) of
+// them with gossipFanout peers per round. Pick timeout several of those
+// rounds long, or healthy nodes far from the observer will flap. Peers
+// start out alive, and a node first heard of through gossip is added to
+// the view. GossipDetector is only a hint, like every paxos.FailureDetector.
+//
+// =============================================================================
+
+package node
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// gossipFanout is how many peers each round of gossip goes to.
+const gossipFanout = 3
+
+// Gossip carries its sender's view of every node's heartbeat counter.
+type Gossip struct {
+	From       string
+	Heartbeats map[string]uint64
+}
+
+func (g Gossip) GetFrom() string {
+	return g.From
+}
+
+type GossipDetector struct {
+	self     string
+	interval time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+	counters map[string]uint64
+	lastSeen map[string]time.Time
+	rand     *rand.Rand
+	mu       sync.Mutex
+}
+
+func NewGossipDetector(self string, peers []string, interval, timeout time.Duration) *GossipDetector {
+	d := &GossipDetector{
+		self:     self,
+		interval: interval,
+		timeout:  timeout,
+		now:      time.Now,
+		counters: map[string]uint64{self: 0},
+		lastSeen: make(map[string]time.Time),
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	start := d.now()
+	for _, id := range peers {
+		if id != self {
+			d.lastSeen[id] = start
+		}
+	}
+	return d
+}
+
+// Observe records that id was just heard from directly.
+func (d *GossipDetector) Observe(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if id != d.self {
+		d.lastSeen[id] = d.now()
+	}
+}
+
+// Merge takes in a peer's vector. A node counts as seen only if its counter
+// went up; a stale counter passed along by others says nothing new.
+func (d *GossipDetector) Merge(g Gossip) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	for id, count := range g.Heartbeats {
+		if id == d.self {
+			continue
+		}
+		known, ok := d.counters[id]
+		if !ok || count > known {
+			d.counters[id] = count
+			d.lastSeen[id] = now
+		}
+	}
+}
+
+// Alive reports whether id was seen, directly or through gossip, within the
+// timeout. Unknown ids and this node itself are alive.
+func (d *GossipDetector) Alive(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seen, ok := d.lastSeen[id]
+	return !ok || d.now().Sub(seen) < d.timeout
+}
+
+func (d *GossipDetector) Suspects() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var suspects []string
+	now := d.now()
+	for id, seen := range d.lastSeen {
+		if now.Sub(seen) >= d.timeout {
+			suspects = append(suspects, id)
+		}
+	}
+	sort.Strings(suspects)
+	return suspects
+}
+
+// round bumps this node's counter and returns the message to send and the
+// peers to send it to.
+func (d *GossipDetector) round() (Gossip, []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.counters[d.self]++
+	g := Gossip{From: d.self, Heartbeats: make(map[string]uint64, len(d.counters))}
+	for id, count := range d.counters {
+		g.Heartbeats[id] = count
+	}
+	peers := make([]string, 0, len(d.lastSeen))
+	for id := range d.lastSeen {
+		peers = append(peers, id)
+	}
+	sort.Strings(peers)
+	d.rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+	if len(peers) > gossipFanout {
+		peers = peers[:gossipFanout]
+	}
+	return g, peers
+}
+
+func (n *Node) sendGossip(d *GossipDetector) {
+	defer n.wg.Done()
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stopCh:
+			return
+		case <-ticker.C:
+			if !n.waitWhilePaused() {
+				return
+			}
+			g, peers := d.round()
+			for _, id := range peers {
+				n.transport.Send(id, g)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+func newGossipCluster(t *testing.T, size int, timeout time.Duration) (*transport.Network, []*Node, []*GossipDetector) {
+	t.Helper()
+	net := transport.NewNetwork()
+	ids := make([]string, size)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("n%d", i)
+	}
+	nodes := make([]*Node, size)
+	detectors := make([]*GossipDetector, size)
+	for i, id := range ids {
+		n, err := NewNode(id, size/2+1, net.AddNode(id), storage.NewMemoryStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+		detectors[i] = NewGossipDetector(id, ids, 10*time.Millisecond, timeout)
+		n.SetFailureDetector(detectors[i])
+		if err := n.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { n.Stop() })
+		nodes[i] = n
+	}
+	return net, nodes, detectors
+}
+
+func TestGossipDetectorsSuspectStoppedNode(t *testing.T) {
+	net, nodes, detectors := newGossipCluster(t, 5, 200*time.Millisecond)
+	// n0 and n3 can't reach each other, but hear of each other through
+	// the rest.
+	net.Partition("n0", "n3")
+	time.Sleep(400 * time.Millisecond)
+	for i, d := range detectors {
+		if s := d.Suspects(); len(s) != 0 {
+			t.Fatalf("n%d suspects %v with every node up", i, s)
+		}
+	}
+
+	if err := nodes[4].Stop(); err != nil {
+		t.Fatal(err)
+	}
+	for i, d := range detectors[:4] {
+		d := d
+		eventually(t, 2*time.Second, fmt.Sprintf("n%d to suspect n4", i), func() bool {
+			s := d.Suspects()
+			return len(s) == 1 && s[0] == "n4" && !d.Alive("n4")
+		})
+	}
+	for i, d := range detectors[:4] {
+		for _, id := range []string{"n0", "n1", "n2", "n3"} {
+			if !d.Alive(id) {
+				t.Fatalf("n%d suspects the running %s", i, id)
+			}
+		}
+	}
+}
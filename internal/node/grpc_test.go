@@ -0,0 +1,54 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	"quorum/internal/storage"
+	"quorum/internal/testutil"
+	"quorum/internal/transport"
+)
+
+func TestConsensusOverGRPC(t *testing.T) {
+	ts := make([]*transport.GRPCTransport, 3)
+	for i := range ts {
+		id := fmt.Sprintf("n%d", i)
+		tr, err := transport.NewGRPCTransport(id, map[string]string{id: "127.0.0.1:0"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts[i] = tr
+	}
+	for i, tr := range ts {
+		for j, peer := range ts {
+			if i != j {
+				tr.AddPeer(fmt.Sprintf("n%d", j), peer.Addr().String())
+			}
+		}
+	}
+	nodes := make([]*Node, len(ts))
+	for i, tr := range ts {
+		tr := tr
+		n, err := NewNode(fmt.Sprintf("n%d", i), 2, tr, storage.NewMemoryStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			n.Stop()
+			tr.Close()
+		})
+		nodes[i] = n
+	}
+
+	chosen, err := nodes[1].Propose([]byte("over grpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chosen) != "over grpc" {
+		t.Fatalf("chosen %q, want %q", chosen, "over grpc")
+	}
+	testutil.AssertConverged(t, nodes)
+}
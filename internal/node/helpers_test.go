@@ -0,0 +1,44 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+// newTestCluster starts size nodes, n0 to n<size-1>, on one in-memory
+// network with a majority quorum. They are stopped when the test ends.
+func newTestCluster(t testing.TB, size int, opts ...paxos.ProposerOption) (*transport.Network, []*Node) {
+	t.Helper()
+	net := transport.NewNetwork()
+	nodes := make([]*Node, size)
+	for i := range nodes {
+		id := fmt.Sprintf("n%d", i)
+		n, err := NewNode(id, size/2+1, net.AddNode(id), storage.NewMemoryStorage(), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { n.Stop() })
+		nodes[i] = n
+	}
+	return net, nodes
+}
+
+// eventually polls cond until it holds or timeout passes.
+func eventually(t testing.TB, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,33 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlowLinkLeavesQuorumToFastAcceptors(t *testing.T) {
+	net, nodes := newTestCluster(t, 5)
+	const slow = 300 * time.Millisecond
+	net.SetLinkLatency("n1", "n0", slow)
+
+	start := time.Now()
+	_, trace, err := nodes[0].ProposeWithTrace([]byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took >= slow {
+		t.Fatalf("Propose took %v, as long as n1's slow link", took)
+	}
+	if len(trace.Attempts) != 1 {
+		t.Fatalf("took %d attempts, want 1", len(trace.Attempts))
+	}
+	a := trace.Attempts[0]
+	for _, from := range append(append([]string(nil), a.Promised...), a.Accepted...) {
+		if from == "n1" {
+			t.Fatalf("quorum counted n1 over its slow link: promised %v, accepted %v", a.Promised, a.Accepted)
+		}
+	}
+	if len(a.Promised) < 3 || len(a.Accepted) < 3 {
+		t.Fatalf("promised %v, accepted %v, want a quorum of 3 each", a.Promised, a.Accepted)
+	}
+}
@@ -0,0 +1,165 @@
+// =============================================================================
+// LEARNER-ONLY NODE - Following the Log Without Voting
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Every Node is an acceptor, so adding one changes the quorum. Some
+// processes only want to know what was chosen - a read replica, a cache, an
+// audit log. LearnerOnlyNode is such a process: it registers on the network
+// like any node but runs only a Learner:
+//
+//   net := transport.NewNetwork()
+//   // ... three ordinary nodes "node-0".."node-2" with quorum 2 ...
+//   l, _ := node.NewLearnerOnlyNode("replica", 2, net.AddNode("replica"))
+//   l.Start()
+//   value, _ := l.AwaitLog(ctx, 0)
+//
+// quorumSize is the acceptors' quorum, not counting the learner.
+//
+// =============================================================================
+// WHAT IT HEARS
+// =============================================================================
+//
+// Proposers broadcast Learn to every node once a value is chosen, so a
+// learner-only node hears each decision with no change to the protocol.
+// On a transport without a separate response inbox it also counts any
+// Accepted it receives, as a Node's learner does.
+//
+// Prepare, Accept and MultiPrepare reach it too, since Broadcast goes to
+// every registered node. It ignores them and sends nothing back: it
+// never promises, never accepts, and so can never be part of a quorum.
+// Proposers with WithAcceptors don't even send them.
+//
+// =============================================================================
+
+package node
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"quorum/internal/paxos"
+	"quorum/internal/transport"
+)
+
+type LearnerOnlyNode struct {
+	id        string
+	learner   *paxos.Learner
+	transport transport.Transport
+	mu        sync.Mutex
+	running   bool
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewLearnerOnlyNode(id string, quorumSize int, t transport.Transport) (*LearnerOnlyNode, error) {
+	learner, err := paxos.NewLearner(id, quorumSize)
+	if err != nil {
+		return nil, err
+	}
+	return &LearnerOnlyNode{
+		id:        id,
+		learner:   learner,
+		transport: t,
+	}, nil
+}
+
+func (l *LearnerOnlyNode) Start() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running {
+		return nil
+	}
+	l.running = true
+	l.stopCh = make(chan struct{})
+	l.wg.Add(1)
+	go l.handleMessages()
+	return nil
+}
+
+func (l *LearnerOnlyNode) Stop() error {
+	l.mu.Lock()
+	if !l.running {
+		l.mu.Unlock()
+		return nil
+	}
+	l.running = false
+	close(l.stopCh)
+	l.mu.Unlock()
+	l.wg.Wait()
+	return nil
+}
+
+func (l *LearnerOnlyNode) handleMessages() {
+	defer l.wg.Done()
+	failures := 0
+	for {
+		select {
+		case <-l.stopCh:
+			return
+		default:
+		}
+		msg, err := l.transport.ReceiveTimeout(100 * time.Millisecond)
+		if err == transport.ErrTimeout {
+			continue
+		}
+		if err != nil {
+			failures++
+			log.Printf("[%s] receive error (%d in a row): %v", l.id, failures, err)
+			select {
+			case <-l.stopCh:
+				return
+			case <-time.After(receiveBackoff(failures)):
+			}
+			continue
+		}
+		failures = 0
+		l.routeMessage(msg)
+	}
+}
+
+func (l *LearnerOnlyNode) routeMessage(msg transport.Message) {
+	if err := validateMessage(l.id, msg); err != nil {
+		log.Printf("[%s] dropping message: %v", l.id, err)
+		return
+	}
+	switch m := msg.(type) {
+	case paxos.Learn:
+		l.learner.HandleLearn(m)
+	case *paxos.Learn:
+		l.learner.HandleLearn(*m)
+	case paxos.Accepted:
+		l.learner.HandleAccepted(m)
+	case *paxos.Accepted:
+		l.learner.HandleAccepted(*m)
+	default:
+		// Not a voter: Prepare, Accept and the rest go unanswered.
+	}
+}
+
+func (l *LearnerOnlyNode) ID() string {
+	return l.id
+}
+
+func (l *LearnerOnlyNode) GetChosenValue() ([]byte, bool) {
+	return l.learner.GetChosenValue()
+}
+
+// AwaitLog blocks until slot has a chosen value and returns it, or returns
+// ctx.Err() if ctx is cancelled first.
+func (l *LearnerOnlyNode) AwaitLog(ctx context.Context, slot int64) ([]byte, error) {
+	return l.learner.AwaitChosen(ctx, slot)
+}
+
+func (l *LearnerOnlyNode) GetLog() [][]byte {
+	return l.learner.Log()
+}
+
+func (l *LearnerOnlyNode) SetApplyFunc(fn func(slot int64, value []byte)) {
+	l.learner.SetApplyFunc(fn)
+}
@@ -0,0 +1,50 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLearnerOnlyNodeFollowsWithoutVoting(t *testing.T) {
+	net, nodes := newTestCluster(t, 3)
+	replica, err := NewLearnerOnlyNode("replica", 2, net.AddNode("replica"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replica.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer replica.Stop()
+
+	// With n1 and n2 silent, the replica is the only other node n0 can
+	// reach, and it must not make up a quorum.
+	nodes[1].Pause()
+	nodes[2].Pause()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	if v, err := nodes[0].ProposeAt(ctx, 0, []byte("lonely")); err == nil {
+		t.Fatalf("chose %q with only the replica answering", v)
+	}
+	cancel()
+	nodes[1].Resume()
+	nodes[2].Resume()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	chosen, err := nodes[1].ProposeAt(ctx, 0, []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := replica.AwaitLog(ctx, 0); err != nil || string(v) != string(chosen) {
+		t.Fatalf("replica learned %q, %v, want %q", v, err, chosen)
+	}
+	if _, err := nodes[2].ProposeAt(ctx, 1, []byte("y")); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := replica.AwaitLog(ctx, 1); err != nil || string(v) != "y" {
+		t.Fatalf("replica slot 1 = %q, %v, want y", v, err)
+	}
+	if log := replica.GetLog(); len(log) != 2 {
+		t.Fatalf("replica log = %q, want two entries", log)
+	}
+}
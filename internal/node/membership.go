@@ -0,0 +1,184 @@
+// =============================================================================
+// MEMBERSHIP - Leaving the Cluster Through the Log
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Stopping a node is not the same as removing it. A stopped node still
+// counts toward the quorum, so stopping two of five leaves a cluster that
+// needs three of the remaining three.
+//
+// Leave removes the node properly. The new member list is proposed as an
+// ordinary log entry; once it is chosen, every node that learns it - the
+// leaving node included - recomputes its quorum as a majority of the new
+// list, and the leaving node stops:
+//
+//   c, _ := cluster.New(5)          // quorum 3 of 5
+//   c.Nodes()[4].Leave(ctx)         // quorum 3 of 4
+//   c.Nodes()[3].Leave(ctx)         // quorum 2 of 3
+//
+// Nodes only know the member list once SetMembers has been called;
+// cluster.New does that for every node it builds.
+//
+// =============================================================================
+// WHY ONE AT A TIME IS SAFE
+// =============================================================================
+//
+// Proposals already running keep the quorum they started with, so for a
+// moment old and new quorums are both in use. That is only safe if every
+// old quorum overlaps every new one. Removing a single node from N:
+//
+//   old quorum = N/2 + 1 of N,  new quorum = (N-1)/2 + 1 of N-1
+//   old + new > N, so the two must share a node.
+//
+// Removing several nodes in one entry gives no such guarantee, which is why
+// Leave only ever removes the caller.
+//
+// =============================================================================
+// LIMITS
+// =============================================================================
+//
+// Leave refuses to go below minSafeMembers nodes with ErrUnsafeRemoval:
+// three is the smallest cluster that survives a failure. There is no
+// matching Join; adding nodes means building a new cluster.
+//
+// =============================================================================
+
+package node
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"quorum/internal/paxos"
+)
+
+const minSafeMembers = 3
+
+var (
+	ErrUnsafeRemoval  = errors.New("removing this node would leave too few members")
+	ErrUnknownMembers = errors.New("cluster membership has not been set")
+)
+
+var configPrefix = []byte("\x00quorum/config\x00")
+
+func encodeMembers(members []string) []byte {
+	return append(append([]byte(nil), configPrefix...), strings.Join(members, "\n")...)
+}
+
+func decodeMembers(value []byte) ([]string, bool) {
+	if !bytes.HasPrefix(value, configPrefix) {
+		return nil, false
+	}
+	rest := string(value[len(configPrefix):])
+	if rest == "" {
+		return nil, true
+	}
+	return strings.Split(rest, "\n"), true
+}
+
+// SetMembers tells the node who is in the cluster and sets its quorum to a
+// majority of them.
+func (n *Node) SetMembers(members []string) error {
+	return n.applyMembers(members)
+}
+
+// SetPeers points the proposer at ids, which it then addresses one by one
+// instead of broadcasting; call it again when the transport's set of
+// reachable nodes changes. Include this node's own ID for its acceptor to
+// take part. It fails, changing nothing, if ids are too few for the quorum.
+func (n *Node) SetPeers(ids []string) error {
+	return n.proposer.SetAcceptors(ids)
+}
+
+func (n *Node) Members() []string {
+	n.membersMu.Lock()
+	defer n.membersMu.Unlock()
+	return append([]string(nil), n.members...)
+}
+
+func (n *Node) applyMembers(members []string) error {
+	quorumSize := len(members)/2 + 1
+	if err := n.proposer.SetQuorumSize(quorumSize); err != nil {
+		return err
+	}
+	if err := n.learner.SetMembers(members); err != nil {
+		return err
+	}
+	n.membersMu.Lock()
+	defer n.membersMu.Unlock()
+	n.members = append([]string(nil), members...)
+	return nil
+}
+
+// Leave proposes a member list without this node, waits for it to be
+// chosen, and then stops the node.
+func (n *Node) Leave(ctx context.Context) error {
+	members := n.Members()
+	if len(members) == 0 {
+		return ErrUnknownMembers
+	}
+	remaining := make([]string, 0, len(members))
+	for _, id := range members {
+		if id != n.id {
+			remaining = append(remaining, id)
+		}
+	}
+	if len(remaining) < minSafeMembers {
+		return ErrUnsafeRemoval
+	}
+	if _, err := n.ProposeWithID(ctx, "leave/"+n.id, encodeMembers(remaining)); err != nil {
+		return err
+	}
+	return n.Stop()
+}
+
+// Bootstrap installs value as slot 0's chosen value, provided every member's
+// acceptor, this node's included, is still fresh; otherwise it returns
+// paxos.ErrAlreadyActive. It needs the member list, since all of them must
+// agree. See paxos/bootstrap.go.
+func (n *Node) Bootstrap(value []byte) error {
+	members := n.Members()
+	if len(members) == 0 {
+		return ErrUnknownMembers
+	}
+	prepare := paxos.Prepare{Slot: 0, ProposalNumber: paxos.BootstrapProposal, From: n.id}
+	if promise := n.acceptor.HandlePrepare(prepare); !promise.OK || promise.HasAccepted {
+		return paxos.ErrAlreadyActive
+	}
+	ctx, end, err := n.begin(context.Background())
+	if err != nil {
+		return err
+	}
+	if err := end(n.proposer.Bootstrap(ctx, value, len(members)-1)); err != nil {
+		return err
+	}
+	n.acceptor.HandleAccept(paxos.Accept{Slot: 0, ProposalNumber: paxos.BootstrapProposal, Value: value, From: n.id})
+	n.learnLocally(0, value)
+	return nil
+}
+
+// applyConfig runs from the chosen hook for every chosen entry, including
+// ones replayed from storage, so a restarted node recovers its membership.
+func (n *Node) applyConfig(slot int64, value []byte) {
+	members, ok := decodeMembers(value)
+	if !ok {
+		return
+	}
+	if err := n.applyMembers(members); err != nil {
+		log.Printf("[%s] apply config at slot %d: %v", n.id, slot, err)
+	}
+}
+
+// IsConfigEntry reports whether a chosen value is a membership change
+// rather than a client command, so state machines can skip it like
+// paxos.NoOp.
+func IsConfigEntry(value []byte) bool {
+	_, ok := decodeMembers(value)
+	return ok
+}
@@ -0,0 +1,137 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+	"quorum/internal/storage"
+	"quorum/internal/testutil"
+)
+
+func TestLeaveShrinksFiveToThree(t *testing.T) {
+	_, nodes := newTestCluster(t, 5)
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID()
+	}
+	for _, n := range nodes {
+		if err := n.SetMembers(ids); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, leaving := range nodes[3:] {
+		if err := leaving.Leave(ctx); err != nil {
+			t.Fatalf("%s Leave: %v", leaving.ID(), err)
+		}
+	}
+	for _, n := range nodes[:3] {
+		n := n
+		eventually(t, 2*time.Second, n.ID()+" dropping to three members", func() bool { return len(n.Members()) == 3 })
+	}
+
+	// Quorum is now 2 of the 3 left.
+	slot, err := nodes[0].Append(ctx, []byte("after"))
+	if err != nil {
+		t.Fatalf("Append on the shrunk cluster: %v", err)
+	}
+	if v, err := nodes[2].AwaitLog(ctx, slot); err != nil || string(v) != "after" {
+		t.Fatalf("n2 slot %d = %q, %v", slot, v, err)
+	}
+
+	if err := nodes[2].Leave(ctx); !errors.Is(err, ErrUnsafeRemoval) {
+		t.Fatalf("third Leave: err = %v, want ErrUnsafeRemoval", err)
+	}
+}
+
+func TestLeaveNeedsMembers(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	if err := nodes[0].Leave(context.Background()); !errors.Is(err, ErrUnknownMembers) {
+		t.Fatalf("err = %v, want ErrUnknownMembers", err)
+	}
+}
+
+func TestBootstrapFreshCluster(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	if err := nodes[0].SetMembers([]string{"n0", "n1", "n2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes[0].Bootstrap([]byte("seed")); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.AssertConverged(t, nodes); string(got) != "seed" {
+		t.Fatalf("agreed on %q, want seed", got)
+	}
+	if chosen, err := nodes[1].Propose([]byte("later")); err != nil || string(chosen) != "seed" {
+		t.Fatalf("Propose after Bootstrap = %q, %v, want seed", chosen, err)
+	}
+}
+
+func TestBootstrapRefusesActiveCluster(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	for _, n := range nodes {
+		if err := n.SetMembers([]string{"n0", "n1", "n2"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// n2 alone has seen a proposal.
+	nodes[2].acceptor.HandlePrepare(paxos.Prepare{ProposalNumber: paxos.NewProposalNumber(1, "old"), From: "old"})
+	if err := nodes[0].Bootstrap([]byte("seed")); !errors.Is(err, paxos.ErrAlreadyActive) {
+		t.Fatalf("Bootstrap with n2 active: err = %v, want ErrAlreadyActive", err)
+	}
+	for _, n := range nodes {
+		if v, ok := n.GetChosenValue(); ok {
+			t.Fatalf("%s chose %q after a refused Bootstrap", n.ID(), v)
+		}
+	}
+	if err := nodes[2].Bootstrap([]byte("seed")); !errors.Is(err, paxos.ErrAlreadyActive) {
+		t.Fatalf("Bootstrap from the active node: err = %v, want ErrAlreadyActive", err)
+	}
+	if chosen, err := nodes[0].Propose([]byte("x")); err != nil || string(chosen) != "x" {
+		t.Fatalf("Propose after a refused Bootstrap = %q, %v, want x", chosen, err)
+	}
+}
+
+func TestSetPeersAddsAcceptor(t *testing.T) {
+	net, nodes := newTestCluster(t, 3)
+	if err := nodes[0].SetPeers([]string{"n1"}); !errors.Is(err, paxos.ErrInvalidQuorum) {
+		t.Fatalf("SetPeers below the quorum: err = %v, want ErrInvalidQuorum", err)
+	}
+	if err := nodes[0].SetPeers([]string{"n1", "n2"}); err != nil {
+		t.Fatal(err)
+	}
+	added, err := NewNode("n3", 2, net.AddNode("n3"), storage.NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := added.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer added.Stop()
+
+	if _, err := nodes[0].ProposeAt(context.Background(), 0, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if promised, _, _ := added.acceptor.GetState(); !promised.IsZero() {
+		t.Fatalf("n3 promised %v before it was a peer", promised)
+	}
+
+	if err := nodes[0].SetPeers([]string{"n1", "n2", "n3"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := nodes[0].proposer.Acceptors(); len(got) != 3 || got[2] != "n3" {
+		t.Fatalf("Acceptors = %v, want n3 added", got)
+	}
+	if _, err := nodes[0].ProposeAt(context.Background(), 1, []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, time.Second, "n3 to accept slot 1", func() bool {
+		_, accepted, v := added.acceptor.GetSlotState(1)
+		return !accepted.IsZero() && string(v) == "b"
+	})
+}
@@ -217,7 +217,14 @@
 //
 // 1. Storage error: Crash the node (safety-critical)
 // 2. Transport error: Log and continue (network is unreliable anyway)
+//    - Consecutive receive errors back off exponentially (10ms → 1s) so a
+//      wedged transport can't spin the CPU
+//    - After maxReceiveFailures in a row the node stops itself and reports
+//      the last error through the OnFatal callback
 // 3. Invalid message: Log and ignore (don't crash on bad input)
+// 4. Shutdown: Stop cancels every Propose, Append and read still running,
+//    which return ErrShuttingDown, and waits for them to return. Calls made
+//    after Stop fail with ErrShuttingDown straight away
 //
 // =============================================================================
 // INVARIANT THIS FILE MUST UPHOLD
@@ -296,8 +303,13 @@
 package node
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"quorum/internal/paxos"
@@ -305,26 +317,85 @@ import (
 	"quorum/internal/transport"
 )
 
+func init() {
+	transport.RegisterMessage(paxos.Prepare{})
+	transport.RegisterMessage(paxos.Promise{})
+	transport.RegisterMessage(paxos.Reject{})
+	transport.RegisterMessage(paxos.Accept{})
+	transport.RegisterMessage(paxos.Accepted{})
+	transport.RegisterMessage(paxos.Learn{})
+	transport.RegisterMessage(paxos.MultiPrepare{})
+	transport.RegisterMessage(paxos.MultiPromise{})
+	transport.RegisterMessage(paxos.LeaderTransfer{})
+	transport.RegisterMessage(Heartbeat{})
+	transport.RegisterMessage(Gossip{})
+
+	transport.RegisterResponse(paxos.Promise{})
+	transport.RegisterResponse(paxos.Reject{})
+	transport.RegisterResponse(paxos.Accepted{})
+	transport.RegisterResponse(paxos.MultiPromise{})
+}
+
 type Node struct {
-	id         string
-	proposer   *paxos.Proposer
-	acceptor   *paxos.Acceptor
-	learner    *paxos.Learner
-	transport  transport.Transport
-	storage    storage.Storage
-	quorumSize int
-	mu         sync.Mutex
-	running    bool
-	stopCh     chan struct{}
-	wg         sync.WaitGroup
-}
-
-func NewNode(id string, quorumSize int, t transport.Transport, s storage.Storage) *Node {
-	acceptor := paxos.NewAcceptor(id, s)
-	learner := paxos.NewLearner(id, quorumSize)
+	id              string
+	proposer        *paxos.Proposer
+	acceptor        *paxos.Acceptor
+	learner         *paxos.Learner
+	transport       transport.Transport
+	storage         storage.Storage
+	quorumSize      int
+	mu              sync.Mutex
+	running         bool
+	stopCh          chan struct{}
+	wg              sync.WaitGroup
+	onFatal         func(error)
+	requests        *requestCache
+	members         []string
+	membersMu       sync.Mutex
+	invalidMessages atomic.Uint64
+	life            context.Context
+	endLife         context.CancelFunc
+	ops             sync.WaitGroup
+	detector        paxos.FailureDetector
+	heartbeats      *HeartbeatDetector
+	gossip          *GossipDetector
+	owned           []io.Closer
+	paused          chan struct{}
+	chosen          chosenDispatch
+	stateMachine    Snapshotter
+}
+
+var (
+	ErrShuttingDown    = errors.New("node is shutting down")
+	ErrDuplicateNodeID = errors.New("another node has the same ID")
+)
+
+const (
+	maxReceiveFailures = 10
+	receiveBackoffBase = 10 * time.Millisecond
+	receiveBackoffMax  = time.Second
+)
+
+func NewNode(id string, quorumSize int, t transport.Transport, s storage.Storage, opts ...paxos.ProposerOption) (*Node, error) {
+	learner, err := paxos.NewLearner(id, quorumSize)
+	if err != nil {
+		return nil, err
+	}
+	rounds, err := NewRoundAllocator(s)
+	if err != nil {
+		return nil, err
+	}
 	proposerTransport := &proposerTransportAdapter{transport: t}
-	proposer := paxos.NewProposer(id, quorumSize, proposerTransport)
-	return &Node{
+	opts = append([]paxos.ProposerOption{paxos.WithRoundAllocator(rounds)}, opts...)
+	proposer, err := paxos.NewProposer(id, quorumSize, proposerTransport, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := learner.SetQuorumSize(proposer.AcceptQuorum()); err != nil {
+		return nil, err
+	}
+	acceptor := paxos.NewAcceptor(id, s)
+	n := &Node{
 		id:         id,
 		proposer:   proposer,
 		acceptor:   acceptor,
@@ -333,7 +404,44 @@ func NewNode(id string, quorumSize int, t transport.Transport, s storage.Storage
 		storage:    s,
 		quorumSize: quorumSize,
 		stopCh:     make(chan struct{}),
+		requests:   newRequestCache(maxRecentRequests),
 	}
+	n.life, n.endLife = context.WithCancel(context.Background())
+	learner.SetChosenHook(n.persistChosen)
+	return n, nil
+}
+
+func (n *Node) persistChosen(slot int64, proposal paxos.ProposalNumber, value []byte) {
+	if err := n.acceptor.MarkChosen(slot, value); err != nil {
+		log.Printf("[%s] persist chosen slot %d: %v", n.id, slot, err)
+	}
+	n.applyConfig(slot, value)
+	n.chosen.push(slot, value)
+}
+
+// recoverFromStorage replays every slot the storage recorded as chosen into the
+// learner, so a restarted node has its log back before it handles any
+// message.
+func (n *Node) recoverFromStorage() error {
+	highest, err := n.storage.GetHighestSlot()
+	if err != nil {
+		return err
+	}
+	for slot := int64(0); slot <= highest; slot++ {
+		state, err := n.storage.LoadSlot(slot)
+		if err != nil {
+			return err
+		}
+		if !state.Chosen {
+			continue
+		}
+		n.learner.HandleLearn(paxos.Learn{
+			Slot:  slot,
+			Value: state.ChosenValue,
+			From:  n.id,
+		})
+	}
+	return nil
 }
 
 func (n *Node) Start() error {
@@ -342,10 +450,28 @@ func (n *Node) Start() error {
 	if n.running {
 		return nil
 	}
+	if r, ok := n.transport.(transport.IDRegistry); ok && r.DuplicateID() {
+		return fmt.Errorf("%w: %s", ErrDuplicateNodeID, n.id)
+	}
+	if err := n.recoverFromStorage(); err != nil {
+		return err
+	}
 	n.running = true
 	n.stopCh = make(chan struct{})
+	if n.life.Err() != nil {
+		n.life, n.endLife = context.WithCancel(context.Background())
+	}
 	n.wg.Add(1)
 	go n.handleMessages()
+	if n.heartbeats != nil && n.heartbeats.interval > 0 {
+		n.wg.Add(1)
+		go n.sendHeartbeats(n.heartbeats)
+	}
+	if n.gossip != nil && n.gossip.interval > 0 {
+		n.wg.Add(1)
+		go n.sendGossip(n.gossip)
+	}
+	n.chosen.start(&n.wg)
 	return nil
 }
 
@@ -357,32 +483,163 @@ func (n *Node) Stop() error {
 	}
 	n.running = false
 	close(n.stopCh)
+	n.endLife()
+	n.chosen.stop()
 	n.mu.Unlock()
 	n.wg.Wait()
+	n.ops.Wait()
 	return nil
 }
 
+// Pause freezes the node as a GC pause or a stalled process would: it
+// stops reading its inbox, which fills up and then drops, and sends no
+// heartbeats, but it stays running and keeps its state. Client calls made
+// on it still run. Resume picks up where it left off. Tests only.
+func (n *Node) Pause() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.paused == nil {
+		n.paused = make(chan struct{})
+	}
+}
+
+func (n *Node) Resume() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.paused != nil {
+		close(n.paused)
+		n.paused = nil
+	}
+}
+
+// waitWhilePaused blocks until Resume or Stop and reports whether the
+// node is still running.
+func (n *Node) waitWhilePaused() bool {
+	n.mu.Lock()
+	paused := n.paused
+	n.mu.Unlock()
+	if paused == nil {
+		return true
+	}
+	select {
+	case <-n.stopCh:
+		return false
+	case <-paused:
+		return true
+	}
+}
+
+// begin registers a client operation so Stop can cancel it and wait for it.
+// The returned ctx is cancelled by Stop; end must be called with the
+// operation's error and turns a cancellation caused by Stop into
+// ErrShuttingDown.
+func (n *Node) begin(ctx context.Context) (context.Context, func(error) error, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	life := n.life
+	if life.Err() != nil {
+		return nil, nil, ErrShuttingDown
+	}
+	n.ops.Add(1)
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(life, cancel)
+	end := func(err error) error {
+		stop()
+		cancel()
+		n.ops.Done()
+		if err != nil && life.Err() != nil {
+			return ErrShuttingDown
+		}
+		return err
+	}
+	return ctx, end, nil
+}
+
 func (n *Node) handleMessages() {
 	defer n.wg.Done()
+	failures := 0
 	for {
 		select {
 		case <-n.stopCh:
 			return
 		default:
+			if !n.waitWhilePaused() {
+				return
+			}
 			msg, err := n.transport.ReceiveTimeout(100 * time.Millisecond)
 			if err == transport.ErrTimeout {
 				continue
 			}
 			if err != nil {
-				log.Printf("[%s] receive error: %v", n.id, err)
+				failures++
+				log.Printf("[%s] receive error (%d in a row): %v", n.id, failures, err)
+				if failures >= maxReceiveFailures {
+					n.fail(err)
+					return
+				}
+				select {
+				case <-n.stopCh:
+					return
+				case <-time.After(receiveBackoff(failures)):
+				}
 				continue
 			}
+			failures = 0
 			n.routeMessage(msg)
 		}
 	}
 }
 
+func receiveBackoff(failures int) time.Duration {
+	d := receiveBackoffBase << (failures - 1)
+	if d > receiveBackoffMax || d <= 0 {
+		return receiveBackoffMax
+	}
+	return d
+}
+
+// fail stops the message loop from inside it (Stop would deadlock waiting
+// on ourselves), cancels in-flight client calls as Stop does, and reports
+// err to the OnFatal callback.
+func (n *Node) fail(err error) {
+	n.mu.Lock()
+	if n.running {
+		n.running = false
+		close(n.stopCh)
+		n.endLife()
+		n.chosen.stop()
+	}
+	onFatal := n.onFatal
+	n.mu.Unlock()
+	log.Printf("[%s] stopping after %d consecutive receive errors: %v", n.id, maxReceiveFailures, err)
+	if onFatal != nil {
+		onFatal(err)
+	}
+}
+
+// SetOnFatal registers fn to be called once if the node stops itself
+// because its transport keeps failing.
+func (n *Node) SetOnFatal(fn func(error)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.onFatal = fn
+}
+
 func (n *Node) routeMessage(msg transport.Message) {
+	if err := validateMessage(n.id, msg); err != nil {
+		n.invalidMessages.Add(1)
+		log.Printf("[%s] dropping message: %v", n.id, err)
+		return
+	}
+	n.mu.Lock()
+	heartbeats, gossip := n.heartbeats, n.gossip
+	n.mu.Unlock()
+	if heartbeats != nil {
+		heartbeats.Observe(msg.GetFrom())
+	}
+	if gossip != nil {
+		gossip.Observe(msg.GetFrom())
+	}
 	switch m := msg.(type) {
 	case paxos.Prepare:
 		response := n.acceptor.HandlePrepare(m)
@@ -391,6 +648,9 @@ func (n *Node) routeMessage(msg transport.Message) {
 	case *paxos.Prepare:
 		response := n.acceptor.HandlePrepare(*m)
 		n.transport.Send(m.From, response)
+	case paxos.MultiPrepare:
+		response := n.acceptor.HandleMultiPrepare(m)
+		n.transport.Send(m.From, response)
 	case paxos.Accept:
 		response := n.acceptor.HandleAccept(m)
 		n.transport.Send(m.From, response)
@@ -409,6 +669,17 @@ func (n *Node) routeMessage(msg transport.Message) {
 	case *paxos.Accepted:
 		n.learner.HandleAccepted(*m)
 
+	case paxos.LeaderTransfer:
+		n.wg.Add(1)
+		go n.acceptLeadership(m)
+	case Heartbeat:
+		// Already observed above.
+	case Gossip:
+		if gossip != nil {
+			gossip.Merge(m)
+		}
+	case paxos.Promise, paxos.MultiPromise:
+		// Replies meant for this node's proposer; nothing to route.
 	case paxos.Learn:
 		n.learner.HandleLearn(m)
 
@@ -419,37 +690,311 @@ func (n *Node) routeMessage(msg transport.Message) {
 	}
 }
 
+// Propose runs Paxos for slot 0. Like every Propose variant on Node, it
+// hands the outcome to the local learner before returning, so
+// GetChosenValue on this node reflects it immediately (read-your-writes)
+// rather than once the Accepted messages arrive.
 func (n *Node) Propose(value []byte) ([]byte, error) {
-	return n.proposer.Propose(value)
+	result, err := n.ProposeDetailed(value)
+	if err != nil {
+		return nil, err
+	}
+	return result.Value, nil
+}
+
+func (n *Node) ProposeDetailed(value []byte) (paxos.ProposeResult, error) {
+	ctx, end, err := n.begin(context.Background())
+	if err != nil {
+		return paxos.ProposeResult{}, err
+	}
+	result, err := n.proposer.ProposeDetailedContext(ctx, value)
+	if err = end(err); err != nil {
+		return result, err
+	}
+	n.learnLocally(0, result.Value)
+	return result, nil
+}
+
+// ProposeWithTrace is Propose plus a record of every attempt; see
+// paxos/trace.go.
+func (n *Node) ProposeWithTrace(value []byte) ([]byte, paxos.ProposeTrace, error) {
+	ctx, end, err := n.begin(context.Background())
+	if err != nil {
+		return nil, paxos.ProposeTrace{}, err
+	}
+	chosen, trace, err := n.proposer.ProposeWithTraceContext(ctx, value)
+	if err = end(err); err != nil {
+		return nil, trace, err
+	}
+	n.learnLocally(0, chosen)
+	return chosen, trace, nil
+}
+
+// ProposeAt runs Paxos for one explicit log slot, independent of every other
+// slot. It is how recovery fills a hole it has found in the log.
+func (n *Node) ProposeAt(ctx context.Context, slot int64, value []byte) ([]byte, error) {
+	ctx, end, err := n.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	chosen, err := n.proposer.ProposeAt(ctx, slot, value)
+	if err = end(err); err != nil {
+		return nil, err
+	}
+	n.learnLocally(slot, chosen)
+	return chosen, nil
+}
+
+// Append adds value at the next slot of this node's log, running Phase 2
+// alone while the node holds a window promise. See paxos/pipeline.go.
+func (n *Node) Append(ctx context.Context, value []byte) (int64, error) {
+	ctx, end, err := n.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	slot, err := n.proposer.Append(ctx, value)
+	if err = end(err); err != nil {
+		return 0, err
+	}
+	n.learnLocally(slot, value)
+	return slot, nil
+}
+
+// IsLeader reports whether this node holds a window promise; see Append.
+func (n *Node) IsLeader() bool {
+	return n.proposer.IsLeading()
+}
+
+// TransferLeadership steps down and tells target to take over straight
+// away. It doesn't wait for target to succeed, and refuses with
+// ErrTargetDown if the failure detector suspects target.
+func (n *Node) TransferLeadership(target string) error {
+	n.mu.Lock()
+	fd := n.detector
+	n.mu.Unlock()
+	if fd != nil && !fd.Alive(target) {
+		return ErrTargetDown
+	}
+	next := n.proposer.NextSlot()
+	if logged := int64(len(n.learner.Log())); logged > next {
+		next = logged
+	}
+	n.proposer.StepDown()
+	return n.transport.Send(target, paxos.LeaderTransfer{NextSlot: next, From: n.id, To: target})
+}
+
+// acceptLeadership runs outside the message loop, since taking over waits
+// for MultiPromises that the loop must keep receiving.
+func (n *Node) acceptLeadership(m paxos.LeaderTransfer) {
+	defer n.wg.Done()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-n.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	from := m.NextSlot
+	if logged := int64(len(n.learner.Log())); logged > from {
+		from = logged
+	}
+	if err := n.proposer.TakeLeadership(ctx, from); err != nil {
+		log.Printf("[%s] take leadership from %s: %v", n.id, m.From, err)
+	}
+}
+
+func (n *Node) learnLocally(slot int64, value []byte) {
+	n.learner.HandleLearn(paxos.Learn{Slot: slot, Value: value, From: n.id})
+}
+
+// LinearizableRead confirms the answer with a quorum before returning it,
+// so a node cut off from the majority returns an error instead of stale
+// data. GetChosenValue, by contrast, is a purely local read of what this
+// node's learner has seen so far.
+func (n *Node) LinearizableRead(ctx context.Context) ([]byte, bool, error) {
+	ctx, end, err := n.begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok, err := n.proposer.Read(ctx)
+	if err = end(err); err != nil || !ok {
+		return nil, false, err
+	}
+	n.learnLocally(0, value)
+	return value, true, nil
+}
+
+// Probe runs Phase 1 only and reports the highest accepted value a quorum
+// holds, for deciding whether to take over. See paxos/probe.go.
+func (n *Node) Probe(ctx context.Context) (paxos.ProbeResult, error) {
+	ctx, end, err := n.begin(ctx)
+	if err != nil {
+		return paxos.ProbeResult{}, err
+	}
+	result, err := n.proposer.Probe(ctx)
+	return result, end(err)
+}
+
+// FillGaps makes slots [0, upTo) contiguous. Every slot this node hasn't
+// seen chosen gets a Paxos round proposing paxos.NoOp; if some earlier
+// proposal may already have been chosen there, Phase 1 recovers it instead.
+func (n *Node) FillGaps(upTo int64) error {
+	for slot := int64(0); slot < upTo; slot++ {
+		if _, ok := n.learner.GetChosenAt(slot); ok {
+			continue
+		}
+		if _, err := n.ProposeAt(context.Background(), slot, paxos.NoOp); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (n *Node) GetChosenValue() ([]byte, bool) {
 	return n.learner.GetChosenValue()
 }
 
+// AwaitLog blocks until slot has a chosen value and returns it, or returns
+// ctx.Err() if ctx is cancelled first. Slots may fill in any order; only
+// slot itself matters.
+func (n *Node) AwaitLog(ctx context.Context, slot int64) ([]byte, error) {
+	return n.learner.AwaitChosen(ctx, slot)
+}
+
+func (n *Node) GetLog() [][]byte {
+	return n.learner.Log()
+}
+
+func (n *Node) SetApplyFunc(fn func(slot int64, value []byte)) {
+	n.learner.SetApplyFunc(fn)
+}
+
+// SetOnConflict reports Learn messages that contradict a value this node has
+// already seen chosen. The chosen value is never replaced.
+func (n *Node) SetOnConflict(fn func(paxos.ConflictEvent)) {
+	n.learner.SetOnConflict(fn)
+}
+
+// NodeState is a snapshot of a node's slot 0 state, for display.
+type NodeState struct {
+	ID            string
+	Promised      paxos.ProposalNumber
+	Accepted      paxos.ProposalNumber
+	AcceptedValue []byte
+	Chosen        []byte
+	HasChosen     bool
+	Leader        bool
+}
+
+// Describe reports the node's acceptor and learner state for slot 0 and
+// whether its proposer leads. The fields are read one at a time, so under
+// traffic they may not be mutually consistent.
+func (n *Node) Describe() NodeState {
+	promised, accepted, value := n.acceptor.GetState()
+	chosen, ok := n.learner.GetChosenValue()
+	return NodeState{
+		ID:            n.id,
+		Promised:      promised,
+		Accepted:      accepted,
+		AcceptedValue: value,
+		Chosen:        chosen,
+		HasChosen:     ok,
+		Leader:        n.IsLeader(),
+	}
+}
+
 func (n *Node) ID() string {
 	return n.id
 }
 
+// ProposerTransport adapts t for a paxos.Proposer running outside a Node,
+// such as a client that proposes without being an acceptor itself.
+func ProposerTransport(t transport.Transport) paxos.Transport {
+	return &proposerTransportAdapter{transport: t}
+}
+
 type proposerTransportAdapter struct {
 	transport transport.Transport
 }
 
 func (a *proposerTransportAdapter) Broadcast(msg interface{}) error {
+	m, ok := msg.(transport.Message)
+	if !ok {
+		m = &messageWrapper{msg: msg}
+	}
+	err := a.transport.Broadcast(m)
+	if err == transport.ErrNoPeers {
+		return paxos.ErrNoPeers
+	}
+	return err
+}
+
+// BroadcastDetailed gives the proposer each destination's send error when
+// the transport reports them, and an empty result otherwise.
+func (a *proposerTransportAdapter) BroadcastDetailed(msg interface{}) (map[string]error, error) {
+	m, ok := msg.(transport.Message)
+	if !ok {
+		m = &messageWrapper{msg: msg}
+	}
+	d, ok := a.transport.(transport.DetailedBroadcaster)
+	if !ok {
+		return nil, a.Broadcast(msg)
+	}
+	result, err := d.BroadcastDetailed(m)
+	if err == transport.ErrNoPeers {
+		return nil, paxos.ErrNoPeers
+	}
+	return result, err
+}
+
+func (a *proposerTransportAdapter) Send(to string, msg interface{}) error {
 	if m, ok := msg.(transport.Message); ok {
-		return a.transport.Broadcast(m)
+		return a.transport.Send(to, m)
 	}
-	return a.transport.Broadcast(&messageWrapper{msg: msg})
+	return a.transport.Send(to, &messageWrapper{msg: msg})
 }
 
+// Receive reads the response inbox when the transport has one, so the
+// proposer never takes requests meant for the node's message loop.
 func (a *proposerTransportAdapter) Receive() (interface{}, error) {
-	msg, err := a.transport.Receive()
+	receive := a.transport.Receive
+	if rr, ok := a.transport.(transport.ResponseReceiver); ok {
+		receive = rr.ReceiveResponse
+	}
+	msg, err := receive()
 	if err != nil {
 		return nil, err
 	}
 	return msg, nil
 }
 
+func (a *proposerTransportAdapter) ReceiveTimeout(timeout time.Duration) (interface{}, error) {
+	receive := a.transport.ReceiveTimeout
+	if rr, ok := a.transport.(transport.ResponseReceiver); ok {
+		receive = rr.ReceiveResponseTimeout
+	}
+	msg, err := receive(timeout)
+	if err == transport.ErrTimeout {
+		return nil, paxos.ErrTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// TryReceive drains only a separate response inbox. With a single shared
+// one it finds nothing, so the proposer never discards a request.
+func (a *proposerTransportAdapter) TryReceive() (interface{}, bool) {
+	rr, ok := a.transport.(transport.ResponseReceiver)
+	if !ok {
+		return nil, false
+	}
+	return rr.TryReceiveResponse()
+}
+
 type messageWrapper struct {
 	msg  interface{}
 	from string
@@ -0,0 +1,310 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+func TestNewNodeRejectsInvalidQuorum(t *testing.T) {
+	net := transport.NewNetwork()
+	if _, err := NewNode("n0", 0, net.AddNode("n0"), storage.NewMemoryStorage()); !errors.Is(err, paxos.ErrInvalidQuorum) {
+		t.Fatalf("quorum 0: err = %v, want ErrInvalidQuorum", err)
+	}
+	_, err := NewNode("n1", 2, net.AddNode("n1"), storage.NewMemoryStorage(),
+		paxos.WithAcceptors([]string{"n2", "n3", "n4", "n5"}))
+	if !errors.Is(err, paxos.ErrInvalidQuorum) {
+		t.Fatalf("quorum 2 of 4 acceptors: err = %v, want ErrInvalidQuorum", err)
+	}
+}
+
+func TestLinearizableReadFailsWhenPartitioned(t *testing.T) {
+	net, nodes := newTestCluster(t, 5)
+	for _, n := range nodes[1:] {
+		net.Partition("n0", n.ID())
+	}
+	if _, err := nodes[1].Propose([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	// n0 missed the decision: a local read is stale.
+	if v, ok := nodes[0].GetChosenValue(); ok {
+		t.Fatalf("partitioned node already knows %q", v)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if v, ok, err := nodes[0].LinearizableRead(ctx); err == nil {
+		t.Fatalf("LinearizableRead on a partitioned node returned %q, %v", v, ok)
+	}
+
+	for _, n := range nodes[1:] {
+		net.Heal("n0", n.ID())
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	v, ok, err := nodes[0].LinearizableRead(ctx)
+	if err != nil || !ok || string(v) != "new" {
+		t.Fatalf("LinearizableRead after healing = %q, %v, %v", v, ok, err)
+	}
+}
+
+func TestStartRecoversChosenSlots(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	for slot, v := range []string{"a", "b", "c"} {
+		if err := s.SaveSlot(int64(slot), storage.SlotState{Chosen: true, ChosenValue: []byte(v)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := NewNode("n0", 1, transport.NewNetwork().AddNode("n0"), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.GetLog(); len(got) != 0 {
+		t.Fatalf("log before Start = %q, want empty", got)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer n.Stop()
+
+	got := n.GetLog()
+	if len(got) != 3 || string(got[0]) != "a" || string(got[1]) != "b" || string(got[2]) != "c" {
+		t.Fatalf("log after Start = %q, want [a b c]", got)
+	}
+}
+
+// brokenTransport fails every receive, as a wedged transport would.
+type brokenTransport struct {
+	*transport.MemoryTransport
+	receives atomic.Int32
+}
+
+var errWedged = errors.New("transport wedged")
+
+func (b *brokenTransport) ReceiveTimeout(time.Duration) (transport.Message, error) {
+	b.receives.Add(1)
+	return nil, errWedged
+}
+
+func TestReceiveErrorsBackOffThenFail(t *testing.T) {
+	tr := &brokenTransport{MemoryTransport: transport.NewNetwork().AddNode("n0")}
+	n, err := NewNode("n0", 1, tr, storage.NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fatal := make(chan error, 1)
+	n.SetOnFatal(func(err error) { fatal <- err })
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer n.Stop()
+
+	// 10+20+40+80ms of backoff leaves room for about five receives.
+	time.Sleep(150 * time.Millisecond)
+	if got := tr.receives.Load(); got > 5 {
+		t.Fatalf("%d receives in 150ms: the loop is not backing off", got)
+	}
+
+	select {
+	case err := <-fatal:
+		if !errors.Is(err, errWedged) {
+			t.Fatalf("OnFatal got %v, want %v", err, errWedged)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("OnFatal was not called")
+	}
+	if got := tr.receives.Load(); got != maxReceiveFailures {
+		t.Fatalf("%d receives before failing, want %d", got, maxReceiveFailures)
+	}
+	if _, err := n.Append(context.Background(), []byte("x")); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("Append after failing: err = %v, want ErrShuttingDown", err)
+	}
+}
+
+func TestReceiveBackoff(t *testing.T) {
+	for failures, want := range map[int]time.Duration{
+		1:  receiveBackoffBase,
+		2:  2 * receiveBackoffBase,
+		4:  8 * receiveBackoffBase,
+		20: receiveBackoffMax,
+		80: receiveBackoffMax,
+	} {
+		if got := receiveBackoff(failures); got != want {
+			t.Errorf("receiveBackoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestProposeWithoutPeersFailsFast(t *testing.T) {
+	n, err := NewNode("n0", 1, transport.NewNetwork().AddNode("n0"), storage.NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer n.Stop()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := n.Propose([]byte("x"))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, paxos.ErrNoPeers) {
+			t.Fatalf("err = %v, want ErrNoPeers", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Propose blocked on a network with no peers")
+	}
+}
+
+func TestProposeReadsItsOwnWrite(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	for i := 0; i < 20; i++ {
+		// Each round uses a fresh slot so every Propose is decided anew.
+		slot := int64(i)
+		value := []byte{byte('a' + i)}
+		chosen, err := nodes[i%3].ProposeAt(context.Background(), slot, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := nodes[i%3].learner.GetChosenAt(slot)
+		if !ok || string(got) != string(chosen) {
+			t.Fatalf("slot %d: ProposeAt returned %q but the local learner has %q, %v", slot, chosen, got, ok)
+		}
+	}
+	chosen, err := nodes[1].Propose([]byte("z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := nodes[1].GetChosenValue(); !ok || string(got) != string(chosen) {
+		t.Fatalf("Propose returned %q but GetChosenValue has %q, %v", chosen, got, ok)
+	}
+}
+
+func TestStopAbortsPendingPropose(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	nodes[1].Pause()
+	nodes[2].Pause()
+	done := make(chan error, 1)
+	go func() {
+		_, err := nodes[0].Propose([]byte("x"))
+		done <- err
+	}()
+	eventually(t, time.Second, "the Prepare to reach n1", func() bool {
+		return nodes[1].transport.(*transport.MemoryTransport).Pending() > 0
+	})
+
+	start := time.Now()
+	if err := nodes[0].Stop(); err != nil {
+		t.Fatal(err)
+	}
+	// Stop waits for client operations, so the result is already in.
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrShuttingDown) {
+			t.Fatalf("Propose = %v, want ErrShuttingDown", err)
+		}
+	default:
+		t.Fatal("Stop returned with the proposal still running")
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("Stop took %v with a proposal pending", took)
+	}
+}
+
+func TestPausedMinorityCatchesUpOnResume(t *testing.T) {
+	_, nodes := newTestCluster(t, 5)
+	paused := nodes[4]
+	paused.Pause()
+
+	chosen, err := nodes[0].Propose([]byte("x"))
+	if err != nil {
+		t.Fatalf("Propose with one node paused: %v", err)
+	}
+	if string(chosen) != "x" {
+		t.Fatalf("chose %q, want x", chosen)
+	}
+	for _, n := range nodes[1:4] {
+		n := n
+		eventually(t, time.Second, n.ID()+" to learn x", func() bool {
+			v, ok := n.GetChosenValue()
+			return ok && string(v) == "x"
+		})
+	}
+	if v, ok := paused.GetChosenValue(); ok {
+		t.Fatalf("paused node learned %q while paused", v)
+	}
+	if paused.transport.(*transport.MemoryTransport).Pending() == 0 {
+		t.Fatal("nothing queued for the paused node")
+	}
+
+	paused.Resume()
+	eventually(t, time.Second, "the resumed node to learn x", func() bool {
+		v, ok := paused.GetChosenValue()
+		return ok && string(v) == "x"
+	})
+}
+
+func TestSingleNodeWithSelfDeliveryChoosesValue(t *testing.T) {
+	tr := transport.NewNetwork().AddNode("n0")
+	tr.SetSelfDelivery(true)
+	n, err := NewNode("n0", 1, tr, storage.NewMemoryStorage(), paxos.WithQuorumTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer n.Stop()
+
+	chosen, err := n.Propose([]byte("solo"))
+	if err != nil {
+		t.Fatalf("Propose on a one-node cluster: %v", err)
+	}
+	if string(chosen) != "solo" {
+		t.Fatalf("chose %q, want solo", chosen)
+	}
+	if v, ok := n.GetChosenValue(); !ok || string(v) != "solo" {
+		t.Fatalf("GetChosenValue = %q, %v", v, ok)
+	}
+	if _, accepted, _ := n.acceptor.GetState(); accepted.IsZero() {
+		t.Fatal("the node's own acceptor never accepted")
+	}
+}
+
+func TestStartFailsOnDuplicateID(t *testing.T) {
+	net := transport.NewNetwork()
+	defer net.Close()
+	first, second := net.AddNode("n0"), net.AddNode("n0")
+	for i, tr := range []*transport.MemoryTransport{first, second} {
+		n, err := NewNode("n0", 2, tr, storage.NewMemoryStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Start(); !errors.Is(err, ErrDuplicateNodeID) {
+			n.Stop()
+			t.Fatalf("node %d: Start = %v, want ErrDuplicateNodeID", i, err)
+		}
+		if _, err := n.Propose([]byte("x")); err == nil {
+			t.Fatalf("node %d proposed after a failed Start", i)
+		}
+	}
+
+	other, err := NewNode("n1", 2, net.AddNode("n1"), storage.NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Start(); err != nil {
+		t.Fatalf("a node with its own ID: Start = %v", err)
+	}
+	other.Stop()
+}
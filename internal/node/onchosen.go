@@ -0,0 +1,143 @@
+// =============================================================================
+// ON CHOSEN - Reporting Each Decision Off the Message Path
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// SetApplyFunc and the learner's hooks run with the learner locked, so a
+// handler that writes to a database or calls another service stalls every
+// Accepted and Learn behind it. OnChosen runs its callback on a goroutine of
+// its own instead:
+//
+//   n.OnChosen(func(slot int64, value []byte) {
+//       publish(slot, value) // may block; messages keep flowing
+//   })
+//
+// Each slot is reported once, the first time this node's learner sees it
+// chosen. Duplicate Learn and Accepted messages for a decided slot never
+// reach the callback: the learner decides a slot once and only that
+// decision is queued. NoOp slots are reported too; skip them with
+// paxos.IsNoOp.
+//
+// =============================================================================
+// ORDER AND BACKLOG
+// =============================================================================
+//
+// Slots are reported strictly in slot order, one call at a time. A slot
+// chosen ahead of a gap - concurrent proposals finish out of order all the
+// time - is held back until every slot below it has been reported, then
+// released with the rest of the run. The dispatcher tracks that position
+// even while no callback is set, so slots the log had already reached when
+// OnChosen is called are not reported, and a later registration doesn't
+// wait on them.
+//
+// The buffer between the learner and the callback is unbounded, so a
+// callback that never returns holds every later decision in memory. A node
+// built over storage that already holds decisions reports them again at
+// Start, when it replays them into its fresh learner.
+//
+// The dispatcher goroutine belongs to the node: Stop waits for a running
+// callback to return and nothing more is reported until the next Start.
+// The callback must therefore not call Stop itself.
+//
+// =============================================================================
+
+package node
+
+import (
+	"sync"
+)
+
+type chosenDispatch struct {
+	mu      sync.Mutex
+	fn      func(slot int64, value []byte)
+	pending map[int64][]byte
+	next    int64
+	active  bool
+	running bool
+	wg      *sync.WaitGroup
+}
+
+// OnChosen registers fn to be called once per chosen slot, in slot order,
+// on a goroutine separate from message processing. A later call replaces
+// fn; nil stops reporting.
+func (n *Node) OnChosen(fn func(slot int64, value []byte)) {
+	n.chosen.mu.Lock()
+	defer n.chosen.mu.Unlock()
+	n.chosen.fn = fn
+	n.chosen.kick()
+}
+
+// push runs from the chosen hook, with the learner locked, so it only
+// buffers the slot and wakes the dispatcher.
+func (d *chosenDispatch) push(slot int64, value []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if slot < d.next {
+		return
+	}
+	if d.pending == nil {
+		d.pending = make(map[int64][]byte)
+	}
+	d.pending[slot] = value
+	d.kick()
+}
+
+// start lets the dispatcher run on wg, the node's goroutine group.
+func (d *chosenDispatch) start(wg *sync.WaitGroup) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.wg = wg
+	d.active = true
+	d.kick()
+}
+
+// stop keeps the dispatcher from picking up another slot. It doesn't wait;
+// the node's wg does.
+func (d *chosenDispatch) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active = false
+}
+
+// kick must be called with d.mu held. Without a callback it skips the
+// contiguous run at next; otherwise it starts a dispatcher goroutine if
+// the next slot is ready and none is running.
+func (d *chosenDispatch) kick() {
+	if d.fn == nil {
+		for {
+			if _, ok := d.pending[d.next]; !ok {
+				return
+			}
+			delete(d.pending, d.next)
+			d.next++
+		}
+	}
+	if _, ok := d.pending[d.next]; !ok || !d.active || d.running {
+		return
+	}
+	d.running = true
+	d.wg.Add(1)
+	go d.run()
+}
+
+func (d *chosenDispatch) run() {
+	defer d.wg.Done()
+	for {
+		d.mu.Lock()
+		value, ok := d.pending[d.next]
+		if !ok || d.fn == nil || !d.active {
+			d.running = false
+			d.kick()
+			d.mu.Unlock()
+			return
+		}
+		slot, fn := d.next, d.fn
+		delete(d.pending, slot)
+		d.next++
+		d.mu.Unlock()
+		fn(slot, value)
+	}
+}
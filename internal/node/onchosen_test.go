@@ -0,0 +1,93 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type chosenRecorder struct {
+	mu    sync.Mutex
+	slots []int64
+}
+
+func (r *chosenRecorder) record(slot int64, value []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.slots = append(r.slots, slot)
+}
+
+func (r *chosenRecorder) get() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.slots...)
+}
+
+func TestOnChosenOncePerSlotInOrder(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	var rec chosenRecorder
+	nodes[0].OnChosen(rec.record)
+	for _, v := range []string{"a", "b", "c"} {
+		if _, err := nodes[0].Append(context.Background(), []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A duplicate decision must not be reported again.
+	nodes[0].learnLocally(1, []byte("b"))
+
+	eventually(t, 2*time.Second, "three callbacks", func() bool { return len(rec.get()) >= 3 })
+	time.Sleep(20 * time.Millisecond)
+	got := rec.get()
+	if len(got) != 3 || got[0] != 0 || got[1] != 1 || got[2] != 2 {
+		t.Fatalf("callbacks for slots %v, want [0 1 2]", got)
+	}
+}
+
+func TestOnChosenHoldsBackSlotsAfterAGap(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	var rec chosenRecorder
+	nodes[0].OnChosen(rec.record)
+
+	nodes[0].learnLocally(2, []byte("c"))
+	nodes[0].learnLocally(1, []byte("b"))
+	time.Sleep(20 * time.Millisecond)
+	if got := rec.get(); len(got) != 0 {
+		t.Fatalf("reported %v while slot 0 is undecided", got)
+	}
+
+	nodes[0].learnLocally(0, []byte("a"))
+	eventually(t, 2*time.Second, "three callbacks", func() bool { return len(rec.get()) == 3 })
+	if got := rec.get(); got[0] != 0 || got[1] != 1 || got[2] != 2 {
+		t.Fatalf("callbacks for slots %v, want [0 1 2]", got)
+	}
+}
+
+func TestStopWaitsForOnChosen(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	nodes[0].OnChosen(func(slot int64, value []byte) {
+		close(entered)
+		<-release
+	})
+	nodes[0].learnLocally(0, []byte("a"))
+	<-entered
+
+	stopped := make(chan struct{})
+	go func() {
+		nodes[0].Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while the callback was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return after the callback did")
+	}
+}
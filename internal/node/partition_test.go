@@ -0,0 +1,88 @@
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+	"quorum/internal/storage"
+)
+
+func TestMinorityProposerReportsNoQuorum(t *testing.T) {
+	net, nodes := newTestCluster(t, 5, paxos.WithQuorumTimeout(100*time.Millisecond))
+	for _, minority := range nodes[:2] {
+		for _, majority := range nodes[2:] {
+			net.Partition(minority.ID(), majority.ID())
+		}
+	}
+
+	start := time.Now()
+	_, err := nodes[0].Propose([]byte("minority"))
+	if !errors.Is(err, paxos.ErrNoQuorum) {
+		t.Fatalf("minority Propose: err = %v, want ErrNoQuorum", err)
+	}
+	if took := time.Since(start); took > 2*time.Second {
+		t.Fatalf("minority Propose took %v to give up", took)
+	}
+
+	for _, minority := range nodes[:2] {
+		for _, majority := range nodes[2:] {
+			net.Heal(minority.ID(), majority.ID())
+		}
+	}
+	chosen, err := nodes[0].Propose([]byte("healed"))
+	if err != nil || string(chosen) != "healed" {
+		t.Fatalf("Propose after healing = %q, %v", chosen, err)
+	}
+}
+
+func TestSlowStorageTimesOutPhaseThenRetrySucceeds(t *testing.T) {
+	_, nodes := newTestCluster(t, 3, paxos.WithQuorumTimeout(50*time.Millisecond))
+	slow := []*storage.MemoryStorage{nodes[1].storage.(*storage.MemoryStorage), nodes[2].storage.(*storage.MemoryStorage)}
+	for _, s := range slow {
+		// A save and a sync per reply: 160ms, well past the quorum timeout.
+		s.SetWriteLatency(80 * time.Millisecond)
+	}
+
+	start := time.Now()
+	_, first, err := nodes[0].ProposeWithTrace([]byte("x"))
+	if !errors.Is(err, paxos.ErrNoQuorum) {
+		t.Fatalf("Propose against slow disks: err = %v, want ErrNoQuorum", err)
+	}
+	if len(first.Attempts) != 1 {
+		t.Fatalf("made %d attempts, want the timeout to end the call after one", len(first.Attempts))
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("the phase gave up after %v, not at its 50ms timeout", took)
+	}
+
+	// The stall passes, though the acceptors are still working through
+	// the first attempt's Prepare. The proposer doesn't retry on its own,
+	// so the caller does; each call starts over with a higher number, and
+	// the acceptors' late promises to the old one must not count.
+	for _, s := range slow {
+		s.SetWriteLatency(0)
+	}
+	var chosen []byte
+	var trace paxos.ProposeTrace
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		chosen, trace, err = nodes[0].ProposeWithTrace([]byte("x"))
+		if err == nil || !errors.Is(err, paxos.ErrNoQuorum) || time.Now().After(deadline) {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("retry after the stall: %v", err)
+	}
+	if string(chosen) != "x" {
+		t.Fatalf("chosen %q, want x", chosen)
+	}
+	last := trace.Attempts[len(trace.Attempts)-1]
+	if !first.Attempts[0].Proposal.LessThan(last.Proposal) {
+		t.Fatalf("retry used %v, want a number above the timed-out %v", last.Proposal, first.Attempts[0].Proposal)
+	}
+	if promised, _, _ := nodes[1].acceptor.GetState(); promised != last.Proposal {
+		t.Fatalf("n1 promised %v, want the retry's %v", promised, last.Proposal)
+	}
+}
@@ -0,0 +1,37 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProposeAtFillsSlotPastEmptyOnes(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	chosen, err := nodes[0].ProposeAt(ctx, 3, []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chosen) != "x" {
+		t.Fatalf("slot 3 chose %q, want x", chosen)
+	}
+
+	for _, n := range nodes {
+		n := n
+		eventually(t, 2*time.Second, n.ID()+" learning slot 3", func() bool {
+			v, ok := n.learner.GetChosenAt(3)
+			return ok && string(v) == "x"
+		})
+		for slot := int64(0); slot < 3; slot++ {
+			if v, ok := n.learner.GetChosenAt(slot); ok {
+				t.Fatalf("%s: empty slot %d chose %q", n.ID(), slot, v)
+			}
+		}
+		// The log stops at the first gap.
+		if log := n.GetLog(); len(log) != 0 {
+			t.Fatalf("%s: log = %q, want empty while slot 0 is undecided", n.ID(), log)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+func TestJournalRecordsARound(t *testing.T) {
+	net := transport.NewNetwork()
+	journal := transport.NewJournal()
+	nodes := make([]*Node, 3)
+	for i := range nodes {
+		id := fmt.Sprintf("n%d", i)
+		n, err := NewNode(id, 2, transport.NewRecordingTransport(id, net.AddNode(id), journal), storage.NewMemoryStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { n.Stop() })
+		nodes[i] = n
+	}
+	if _, err := nodes[0].Propose([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, 2*time.Second, "n2 learning x", func() bool {
+		_, ok := nodes[2].GetChosenValue()
+		return ok
+	})
+
+	want := []struct {
+		kind transport.EventKind
+		node string
+		typ  string
+	}{
+		{transport.EventBroadcast, "n0", "paxos.Prepare"},
+		{transport.EventReceive, "n1", "paxos.Prepare"},
+		{transport.EventReceive, "n0", "paxos.Promise"},
+		{transport.EventBroadcast, "n0", "paxos.Accept"},
+		{transport.EventReceive, "n0", "paxos.Accepted"},
+		{transport.EventBroadcast, "n0", "paxos.Learn"},
+		{transport.EventReceive, "n2", "paxos.Learn"},
+	}
+	events := journal.Events()
+	i := 0
+	for _, e := range events {
+		if i < len(want) && e.Kind == want[i].kind && e.Node == want[i].node && e.Type == want[i].typ {
+			i++
+		}
+	}
+	if i < len(want) {
+		var buf bytes.Buffer
+		journal.WriteJSON(&buf)
+		t.Fatalf("journal has no %s of %s on %s after step %d:\n%s", want[i].kind, want[i].typ, want[i].node, i, buf.String())
+	}
+
+	var buf bytes.Buffer
+	if err := journal.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) < len(events) {
+		t.Fatalf("JSON has %d events, journal at least %d", len(decoded), len(events))
+	}
+}
@@ -0,0 +1,39 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNetworkReportCountsOneRound(t *testing.T) {
+	net, nodes := newTestCluster(t, 3)
+	net.SetDelay(time.Millisecond, 2*time.Millisecond)
+	net.ResetReport()
+	if _, err := nodes[0].Propose([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range nodes {
+		n := n
+		eventually(t, 2*time.Second, n.ID()+" learning x", func() bool {
+			_, ok := n.GetChosenValue()
+			return ok
+		})
+	}
+
+	r := net.Report()
+	peers := len(nodes) - 1
+	for _, typ := range []string{"paxos.Prepare", "paxos.Accept", "paxos.Learn"} {
+		if r.ByType[typ] < peers {
+			t.Errorf("%d %s messages, want at least one to each of %d peers", r.ByType[typ], typ, peers)
+		}
+	}
+	if min := 3*peers + 2*peers; r.Messages < min {
+		t.Errorf("%d messages, want at least %d: three broadcasts plus a Promise and an Accepted from each peer", r.Messages, min)
+	}
+	if r.Delivered > r.Messages || r.Latency.Count != r.Delivered {
+		t.Errorf("delivered %d of %d, latency samples %d", r.Delivered, r.Messages, r.Latency.Count)
+	}
+	if r.Latency.Max < time.Millisecond {
+		t.Errorf("max latency %v under the injected 1ms delay", r.Latency.Max)
+	}
+}
@@ -0,0 +1,266 @@
+// =============================================================================
+// CLIENT REQUEST IDS - Making Propose Safe to Retry
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// A client that times out can't tell whether its command was chosen. If it
+// simply calls Propose again, the command may land in two slots and be
+// applied twice.
+//
+// ProposeWithID takes a client-chosen request ID. The node reserves a slot
+// for each ID before proposing, and a retry with the same ID goes back to
+// that slot instead of the next free one:
+//
+//   v, err := n.ProposeWithID(ctx, "client-7/42", cmd)   // slot 3, ctx times out
+//   v, err  = n.ProposeWithID(ctx, "client-7/42", cmd)   // slot 3 again
+//
+// If the first call's value was chosen after all, the retry finds it there
+// and returns it. If slot 3 went to someone else, the ID moves to the next
+// free slot. Either way at most one slot ends up holding the command.
+//
+// =============================================================================
+// LIMITS
+// =============================================================================
+//
+// - The cache is local and bounded (maxRecentRequests, oldest evicted
+//   first). A retry sent to a different node, or after the ID was evicted,
+//   is proposed again. Full exactly-once needs the ID inside the command so
+//   the state machine can drop duplicates when applying.
+//
+// - A slot counts as the request's when the value chosen there went out
+//   under a proposal number sent for the ID. The entry records those
+//   numbers across calls, so a retry recognises what an earlier call left
+//   accepted. Any other byte-identical command - another client's, or one
+//   this node proposed for a different request - is not ours.
+//
+// - The cache lock covers only lookups, so calls with different IDs run
+//   their rounds concurrently, each in its own reserved slot.
+//
+// =============================================================================
+// PROPOSE UNTIL OWN
+// =============================================================================
+//
+// ProposeUntilOwn is the same walk up the log without the cache: it keeps
+// proposing into the next slot until one chooses this proposer's own value,
+// and returns that slot. A slot where Phase 1 adopted a value this call
+// didn't send - even a byte-identical one from another client - is learned
+// and skipped (paxos.ErrValueAdopted). Use it for commands that
+// must be inserted once each, such as a unique ID allocation, when the
+// caller won't retry on its own:
+//
+//   slot, err := n.ProposeUntilOwn(ctx, cmd)
+//
+// It returns only when the value is chosen, the proposal fails outright, or
+// ctx ends. A ctx that ends mid-slot leaves the value possibly accepted
+// there; it may still be chosen later.
+//
+// =============================================================================
+
+package node
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"quorum/internal/paxos"
+)
+
+const maxRecentRequests = 1024
+
+type recentRequest struct {
+	slot   int64
+	value  []byte
+	chosen bool
+	// sent holds the proposal numbers the value went out under at slot.
+	sent []paxos.ProposalNumber
+}
+
+type requestCache struct {
+	limit    int
+	entries  map[string]*recentRequest
+	order    []string
+	reserved map[int64]string
+	mu       sync.Mutex
+}
+
+func newRequestCache(limit int) *requestCache {
+	return &requestCache{
+		limit:    limit,
+		entries:  make(map[string]*recentRequest),
+		reserved: make(map[int64]string),
+	}
+}
+
+func (c *requestCache) get(id string) (*recentRequest, bool) {
+	r, ok := c.entries[id]
+	return r, ok
+}
+
+// reserve assigns id the slot and keeps other IDs out of it until the
+// request is chosen or moves on.
+func (c *requestCache) reserve(id string, slot int64) *recentRequest {
+	r, ok := c.entries[id]
+	if !ok {
+		r = &recentRequest{}
+		c.entries[id] = r
+		c.order = append(c.order, id)
+	} else if c.reserved[r.slot] == id {
+		delete(c.reserved, r.slot)
+	}
+	if r.slot != slot {
+		r.sent = nil
+	}
+	r.slot = slot
+	c.reserved[slot] = id
+	for len(c.order) > c.limit {
+		evicted := c.order[0]
+		if e := c.entries[evicted]; e != nil && c.reserved[e.slot] == evicted {
+			delete(c.reserved, e.slot)
+		}
+		delete(c.entries, evicted)
+		c.order = c.order[1:]
+	}
+	return r
+}
+
+func (c *requestCache) markChosen(id string, value []byte) {
+	r, ok := c.entries[id]
+	if !ok {
+		return
+	}
+	r.chosen = true
+	r.value = value
+	if c.reserved[r.slot] == id {
+		delete(c.reserved, r.slot)
+	}
+}
+
+// freeSlot is the first slot after this node's log that is neither chosen
+// nor reserved by a request. Call it with n.requests.mu held.
+func (n *Node) freeSlot(from int64) int64 {
+	slot := from
+	if logged := int64(len(n.learner.Log())); slot < logged {
+		slot = logged
+	}
+	for {
+		if _, taken := n.requests.reserved[slot]; !taken {
+			if _, ok := n.learner.GetChosenAt(slot); !ok {
+				return slot
+			}
+		}
+		slot++
+	}
+}
+
+// ProposeWithID appends value to the log at the first slot it can win,
+// unless requestID was already chosen through this node, in which case the
+// earlier result is returned and no slot is used. A retry after an error
+// re-drives the slot reserved for requestID.
+func (n *Node) ProposeWithID(ctx context.Context, requestID string, value []byte) ([]byte, error) {
+	n.requests.mu.Lock()
+	r, ok := n.requests.get(requestID)
+	if ok && r.chosen {
+		chosen := r.value
+		n.requests.mu.Unlock()
+		return chosen, nil
+	}
+	if !ok {
+		r = n.requests.reserve(requestID, n.freeSlot(0))
+	}
+	slot := r.slot
+	sent := append([]paxos.ProposalNumber(nil), r.sent...)
+	n.requests.mu.Unlock()
+	for {
+		result, err := n.proposeDetailedAt(ctx, slot, value, &sent)
+		n.requests.mu.Lock()
+		if r, ok := n.requests.get(requestID); ok && r.slot == slot {
+			r.sent = mergeSent(r.sent, sent)
+		}
+		if err != nil {
+			n.requests.mu.Unlock()
+			return nil, err
+		}
+		if result.OwnValueChosen {
+			n.requests.markChosen(requestID, result.Value)
+			n.requests.mu.Unlock()
+			return result.Value, nil
+		}
+		slot = n.freeSlot(slot + 1)
+		sent = nil
+		n.requests.reserve(requestID, slot)
+		n.requests.mu.Unlock()
+	}
+}
+
+// mergeSent adds to have the numbers in more it doesn't hold yet. A
+// concurrent call for the same ID may have recorded numbers of its own.
+func mergeSent(have, more []paxos.ProposalNumber) []paxos.ProposalNumber {
+	for _, p := range more {
+		known := false
+		for _, h := range have {
+			if h == p {
+				known = true
+				break
+			}
+		}
+		if !known {
+			have = append(have, p)
+		}
+	}
+	return have
+}
+
+func (n *Node) proposeDetailedAt(ctx context.Context, slot int64, value []byte, sent *[]paxos.ProposalNumber) (paxos.ProposeResult, error) {
+	ctx, end, err := n.begin(ctx)
+	if err != nil {
+		return paxos.ProposeResult{}, err
+	}
+	result, err := n.proposer.ProposeDetailedAtAgain(ctx, slot, value, sent)
+	if err = end(err); err != nil {
+		return paxos.ProposeResult{}, err
+	}
+	n.learnLocally(slot, result.Value)
+	return result, nil
+}
+
+// ProposeUntilOwn proposes value into successive slots, starting after this
+// node's log, until one chooses it. See the banner above.
+func (n *Node) ProposeUntilOwn(ctx context.Context, value []byte) (int64, error) {
+	slot := int64(len(n.learner.Log()))
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if _, ok := n.learner.GetChosenAt(slot); ok {
+			slot++
+			continue
+		}
+		err := n.proposeOwnAt(ctx, slot, value)
+		if err == nil {
+			return slot, nil
+		}
+		if !errors.Is(err, paxos.ErrValueAdopted) {
+			return 0, err
+		}
+		slot++
+	}
+}
+
+// proposeOwnAt learns whatever slot chose, ours or not.
+func (n *Node) proposeOwnAt(ctx context.Context, slot int64, value []byte) error {
+	ctx, end, err := n.begin(ctx)
+	if err != nil {
+		return err
+	}
+	chosen, err := n.proposer.ProposeOwnAt(ctx, slot, value)
+	decided := err == nil || errors.Is(err, paxos.ErrValueAdopted)
+	err = end(err)
+	if decided {
+		n.learnLocally(slot, chosen)
+	}
+	return err
+}
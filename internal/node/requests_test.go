@@ -0,0 +1,162 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+)
+
+func TestProposeUntilOwnSkipsAdoptedSlot(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	old := paxos.ProposalNumber{Round: 1, ProposerID: "old"}
+	for _, n := range nodes[1:] {
+		if err := n.acceptor.SetStateForTest(old, old, []byte("other")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	slot, err := nodes[0].ProposeUntilOwn(ctx, []byte("mine"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slot != 1 {
+		t.Fatalf("slot = %d, want 1", slot)
+	}
+	log := nodes[0].GetLog()
+	if len(log) != 2 || string(log[0]) != "other" || string(log[1]) != "mine" {
+		t.Fatalf("log = %q, want [other mine]", log)
+	}
+}
+
+func TestProposeUntilOwnIdenticalValueIsNotOurs(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	old := paxos.ProposalNumber{Round: 1, ProposerID: "old"}
+	for _, n := range nodes[1:] {
+		if err := n.acceptor.SetStateForTest(old, old, []byte("cmd")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	slot, err := nodes[0].ProposeUntilOwn(ctx, []byte("cmd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slot != 1 {
+		t.Fatalf("slot = %d, want 1: slot 0 holds another proposer's copy", slot)
+	}
+}
+
+func TestProposeUntilOwnCancelled(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := nodes[0].ProposeUntilOwn(ctx, []byte("x")); err == nil {
+		t.Fatal("cancelled context: want an error")
+	}
+}
+
+func TestProposeWithIDConsumesOneSlot(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	ctx := context.Background()
+	first, err := nodes[0].ProposeWithID(ctx, "client-7/42", []byte("cmd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := nodes[0].ProposeWithID(ctx, "client-7/42", []byte("cmd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != "cmd" || string(second) != "cmd" {
+		t.Fatalf("results %q, %q", first, second)
+	}
+	if log := nodes[0].GetLog(); len(log) != 1 {
+		t.Fatalf("log = %q, want one slot", log)
+	}
+}
+
+func TestProposeWithIDRetryAfterUnseenSuccess(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	if _, err := nodes[0].ProposeWithID(context.Background(), "req", []byte("cmd")); err != nil {
+		t.Fatal(err)
+	}
+	// The value was chosen at the reserved slot, but the caller never saw
+	// the reply: forget the success so the retry has to re-drive slot 0.
+	nodes[0].requests.mu.Lock()
+	nodes[0].requests.entries["req"].chosen = false
+	nodes[0].requests.reserved[0] = "req"
+	nodes[0].requests.mu.Unlock()
+	if _, err := nodes[0].ProposeWithID(context.Background(), "req", []byte("cmd")); err != nil {
+		t.Fatal(err)
+	}
+	if log := nodes[0].GetLog(); len(log) != 1 {
+		t.Fatalf("log = %q, want the retry to reuse slot 0", log)
+	}
+}
+
+func TestProposeWithIDIdenticalValueFromSameNode(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := nodes[0].ProposeWithID(cancelled, "req", []byte("cmd")); err == nil {
+		t.Fatal("cancelled context: want an error")
+	}
+	// Another request's copy of the command, proposed by this same node,
+	// takes the slot reserved for req.
+	if _, err := nodes[0].proposer.ProposeAt(context.Background(), 0, []byte("cmd")); err != nil {
+		t.Fatal(err)
+	}
+	nodes[0].learnLocally(0, []byte("cmd"))
+	if _, err := nodes[0].ProposeWithID(context.Background(), "req", []byte("cmd")); err != nil {
+		t.Fatal(err)
+	}
+	log := nodes[0].GetLog()
+	if len(log) != 2 || string(log[1]) != "cmd" {
+		t.Fatalf("log = %q, want the request in slot 1 after the other copy", log)
+	}
+}
+
+func TestProposeWithIDIdenticalValueFromOtherClient(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	old := paxos.ProposalNumber{Round: 1, ProposerID: "old"}
+	for _, n := range nodes[1:] {
+		if err := n.acceptor.SetStateForTest(old, old, []byte("cmd")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := nodes[0].ProposeWithID(context.Background(), "req", []byte("cmd")); err != nil {
+		t.Fatal(err)
+	}
+	log := nodes[0].GetLog()
+	if len(log) != 2 || string(log[1]) != "cmd" {
+		t.Fatalf("log = %q, want the request in slot 1 after the other client's copy", log)
+	}
+}
+
+func TestProposeWithIDConcurrentIDsGetDistinctSlots(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		id := fmt.Sprintf("req-%d", i)
+		go func() {
+			_, err := nodes[0].ProposeWithID(context.Background(), id, []byte(id))
+			errs <- err
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, v := range nodes[0].GetLog() {
+		seen[string(v)] = true
+	}
+	if len(seen) != 4 {
+		t.Fatalf("log = %q, want 4 distinct requests", nodes[0].GetLog())
+	}
+}
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+func TestRoundAllocatorResumesAboveEveryRound(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	a, err := NewRoundAllocator(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last int64
+	for i := 0; i < 3; i++ {
+		if last, err = a.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A rejection revealed a round past the reservation.
+	a.Observe(250)
+	if last, err = a.Next(); err != nil || last != 251 {
+		t.Fatalf("Next after Observe(250) = %d, %v, want 251", last, err)
+	}
+
+	restarted, err := NewRoundAllocator(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next, err := restarted.Next(); err != nil || next <= last {
+		t.Fatalf("Next after restart = %d, %v, want more than %d", next, err, last)
+	}
+}
+
+func TestRoundAllocatorReservesInBatches(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	a, err := NewRoundAllocator(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < roundReservation; i++ {
+		a.Next()
+	}
+	if stored, _ := s.LoadRound(); stored != roundReservation {
+		t.Fatalf("stored %d after %d rounds, want one reservation of %d", stored, roundReservation, roundReservation)
+	}
+	a.Next()
+	if stored, _ := s.LoadRound(); stored != 2*roundReservation {
+		t.Fatalf("stored %d after passing the reservation, want %d", stored, 2*roundReservation)
+	}
+}
+
+func TestRestartedLeaderUsesHigherRound(t *testing.T) {
+	net := transport.NewNetwork()
+	storages := make([]storage.Storage, 3)
+	nodes := make([]*Node, 3)
+	start := func(i int, tr *transport.MemoryTransport) {
+		n, err := NewNode(fmt.Sprintf("n%d", i), 2, tr, storages[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { n.Stop() })
+		nodes[i] = n
+	}
+	trs := make([]*transport.MemoryTransport, 3)
+	for i := range nodes {
+		storages[i] = storage.NewMemoryStorage()
+		trs[i] = net.AddNode(fmt.Sprintf("n%d", i))
+		start(i, trs[i])
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := nodes[0].ProposeAt(ctx, 0, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	before, _, _ := nodes[1].acceptor.GetSlotState(0)
+
+	nodes[0].Stop()
+	start(0, trs[0])
+	if _, err := nodes[0].ProposeAt(ctx, 1, []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	after, _, _ := nodes[1].acceptor.GetSlotState(1)
+	if after.Round <= before.Round {
+		t.Fatalf("restarted n0 prepared round %d, want more than its earlier %d", after.Round, before.Round)
+	}
+}
@@ -0,0 +1,149 @@
+// =============================================================================
+// SNAPSHOT - A Whole Node in One Blob
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Seeding a new node by replaying the log means replaying every slot. A
+// snapshot carries the result instead: the application's state machine,
+// the last slot applied to it, and the acceptor state (see Acceptor.Export),
+// which includes every chosen value:
+//
+//   n.SetStateMachine(kv)          // kv implements Snapshotter
+//   blob, _ := n.Snapshot()
+//
+//   fresh.SetStateMachine(emptyKV)
+//   fresh.SetApplyFunc(emptyKV.Apply)
+//   fresh.Restore(blob)            // emptyKV now equals kv; only later
+//                                  // slots reach Apply
+//
+// Without a state machine the snapshot still carries the acceptor state and
+// the log, and Restore leaves the apply position alone.
+//
+// =============================================================================
+// CONSISTENCY AND SAFETY
+// =============================================================================
+//
+// Snapshot captures the state machine with the learner locked, so no slot
+// is applied halfway through and the applied slot matches the bytes.
+//
+// Restore goes through Acceptor.Import and so refuses, with
+// paxos.ErrWouldRegress and before changing anything, a snapshot that would
+// lower any promise or accepted proposal the node already holds: an
+// acceptor that forgot a promise could let two values be chosen. It then
+// restores the state machine and resumes applying after the snapshot's
+// slot. If the state machine's Restore fails, the acceptor state has
+// already been imported; that is safe, only the application state is
+// missing.
+//
+// =============================================================================
+
+package node
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+
+	"quorum/internal/paxos"
+)
+
+const snapshotVersion = 1
+
+var ErrBadSnapshot = errors.New("invalid node snapshot")
+
+// Snapshotter is an application state machine that can save and load its
+// whole state.
+type Snapshotter interface {
+	Snapshot() ([]byte, error)
+	Restore(state []byte) error
+}
+
+type nodeSnapshot struct {
+	Version  int
+	Acceptor paxos.AcceptorState
+	HasState bool
+	Applied  int64
+	State    []byte
+}
+
+// SetStateMachine registers the state machine Snapshot saves and Restore
+// loads. It does not apply anything; use SetApplyFunc for that.
+func (n *Node) SetStateMachine(sm Snapshotter) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.stateMachine = sm
+}
+
+// Snapshot serializes the node's acceptor state, its state machine and the
+// last slot applied to it. See the banner above.
+func (n *Node) Snapshot() ([]byte, error) {
+	n.mu.Lock()
+	sm := n.stateMachine
+	n.mu.Unlock()
+	snap := nodeSnapshot{Version: snapshotVersion, Applied: -1}
+	if sm != nil {
+		err := n.learner.Checkpoint(func(applied int64) error {
+			state, err := sm.Snapshot()
+			if err != nil {
+				return err
+			}
+			snap.HasState = true
+			snap.Applied = applied
+			snap.State = state
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	acceptor, err := n.acceptor.Export()
+	if err != nil {
+		return nil, err
+	}
+	snap.Acceptor = acceptor
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Restore loads a Snapshot into the node. It refuses one that would lower
+// the node's acceptor state; see the banner above.
+func (n *Node) Restore(blob []byte) error {
+	var snap nodeSnapshot
+	if err := gob.NewDecoder(bytes.NewReader(blob)).Decode(&snap); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSnapshot, err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("%w: version %d", ErrBadSnapshot, snap.Version)
+	}
+	n.mu.Lock()
+	sm := n.stateMachine
+	n.mu.Unlock()
+	if snap.HasState && sm == nil {
+		return fmt.Errorf("%w: it holds state machine state but no state machine is set", ErrBadSnapshot)
+	}
+	if err := n.acceptor.Import(snap.Acceptor); err != nil {
+		return err
+	}
+	if snap.HasState {
+		if err := sm.Restore(snap.State); err != nil {
+			return err
+		}
+		if err := n.storage.SaveApplied(snap.Applied); err != nil {
+			return err
+		}
+		n.learner.SetApplyFrom(snap.Applied + 1)
+	}
+	for slot, st := range snap.Acceptor.Slots {
+		if st.Chosen {
+			n.learnLocally(slot, st.ChosenValue)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+// joinMachine is a state machine whose state is every applied value joined
+// with commas.
+type joinMachine struct {
+	mu      sync.Mutex
+	state   []string
+	applied []int64
+}
+
+func (m *joinMachine) apply(slot int64, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state = append(m.state, string(value))
+	m.applied = append(m.applied, slot)
+}
+
+func (m *joinMachine) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return []byte(strings.Join(m.state, ",")), nil
+}
+
+func (m *joinMachine) Restore(state []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state = strings.Split(string(state), ",")
+	return nil
+}
+
+func (m *joinMachine) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return strings.Join(m.state, ",")
+}
+
+func (m *joinMachine) appliedSlots() []int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int64(nil), m.applied...)
+}
+
+func newSnapshotNode(t *testing.T, id string) (*Node, *joinMachine) {
+	t.Helper()
+	tr := transport.NewNetwork().AddNode(id)
+	tr.SetSelfDelivery(true)
+	n, err := NewNode(id, 1, tr, storage.NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := &joinMachine{}
+	n.SetStateMachine(sm)
+	n.SetApplyFunc(sm.apply)
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { n.Stop() })
+	return n, sm
+}
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	src := nodes[0]
+	sm := &joinMachine{}
+	src.SetStateMachine(sm)
+	src.SetApplyFunc(sm.apply)
+	values := []string{"a", "b", "c", "d"}
+	for slot, v := range values {
+		if _, err := src.ProposeAt(context.Background(), int64(slot), []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, time.Second, "every slot to be applied", func() bool { return len(sm.appliedSlots()) == len(values) })
+	blob, err := src.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fresh, restored := newSnapshotNode(t, "n9")
+	if err := fresh.Restore(blob); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := restored.String(), sm.String(); got != want {
+		t.Fatalf("restored state machine = %q, want %q", got, want)
+	}
+	if got := restored.appliedSlots(); len(got) != 0 {
+		t.Fatalf("restore applied slots %v the snapshot already covers", got)
+	}
+	gotLog, wantLog := fresh.GetLog(), src.GetLog()
+	if len(gotLog) != len(wantLog) {
+		t.Fatalf("restored log = %q, want %q", gotLog, wantLog)
+	}
+	for i := range wantLog {
+		if string(gotLog[i]) != string(wantLog[i]) {
+			t.Fatalf("restored log = %q, want %q", gotLog, wantLog)
+		}
+	}
+	wantPromised, _, _ := src.acceptor.GetState()
+	if promised, _, _ := fresh.acceptor.GetState(); promised != wantPromised {
+		t.Fatalf("restored acceptor promised %v, want %v", promised, wantPromised)
+	}
+
+	// Later slots carry on from the snapshot.
+	if _, err := fresh.ProposeAt(context.Background(), int64(len(values)), []byte("e")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, time.Second, "the next slot to be applied", func() bool { return len(restored.appliedSlots()) == 1 })
+	if got := restored.appliedSlots(); got[0] != int64(len(values)) {
+		t.Fatalf("applied %v after restore, want only slot %d", got, len(values))
+	}
+	if got := restored.String(); got != "a,b,c,d,e" {
+		t.Fatalf("state after the next slot = %q", got)
+	}
+}
+
+func TestRestoreRefusesToRegressPromise(t *testing.T) {
+	src, _ := newSnapshotNode(t, "n0")
+	if _, err := src.Propose([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	blob, err := src.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ahead, sm := newSnapshotNode(t, "n1")
+	if promise := ahead.acceptor.HandlePrepare(paxos.Prepare{ProposalNumber: paxos.NewProposalNumber(1000, "n2"), From: "n2"}); !promise.OK {
+		t.Fatal("prepare refused")
+	}
+	if err := ahead.Restore(blob); !errors.Is(err, paxos.ErrWouldRegress) {
+		t.Fatalf("Restore over a higher promise = %v, want ErrWouldRegress", err)
+	}
+	if log := ahead.GetLog(); len(log) != 0 || sm.String() != "" {
+		t.Fatalf("a refused restore changed the node: log %q, state %q", log, sm.String())
+	}
+}
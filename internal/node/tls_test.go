@@ -0,0 +1,55 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	"quorum/internal/storage"
+	"quorum/internal/testutil"
+	"quorum/internal/transport"
+)
+
+func TestConsensusOverTLS(t *testing.T) {
+	ca := testutil.NewTestCA(t)
+	ts := make([]*transport.TCPTransport, 3)
+	for i := range ts {
+		tr, err := transport.NewTCPTransport(fmt.Sprintf("n%d", i), "127.0.0.1:0", nil, transport.GobCodec{},
+			transport.WithTLS(ca.TLSConfig(t)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts[i] = tr
+	}
+	for i, tr := range ts {
+		for j, peer := range ts {
+			if i != j {
+				tr.AddPeer(fmt.Sprintf("n%d", j), peer.Addr().String())
+			}
+		}
+	}
+	nodes := make([]*Node, len(ts))
+	for i, tr := range ts {
+		tr := tr
+		n, err := NewNode(fmt.Sprintf("n%d", i), 2, tr, storage.NewMemoryStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			n.Stop()
+			tr.Close()
+		})
+		nodes[i] = n
+	}
+
+	chosen, err := nodes[0].Propose([]byte("secure"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chosen) != "secure" {
+		t.Fatalf("chosen %q, want secure", chosen)
+	}
+	testutil.AssertConverged(t, nodes)
+}
@@ -0,0 +1,66 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTransferLeadershipHandsOverAtOnce(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := nodes[0].Append(ctx, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if !nodes[0].IsLeader() {
+		t.Fatal("n0 isn't leading after Append")
+	}
+
+	if err := nodes[0].TransferLeadership("n2"); err != nil {
+		t.Fatal(err)
+	}
+	if nodes[0].IsLeader() {
+		t.Fatal("n0 still leads after handing off")
+	}
+	// One MultiPrepare round trip on an in-memory network, far below any
+	// failure-detection timeout.
+	eventually(t, 100*time.Millisecond, "n2 to lead", nodes[2].IsLeader)
+
+	slot, err := nodes[2].Append(ctx, []byte("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slot != 1 {
+		t.Fatalf("n2 appended at slot %d, want 1 after n0's slot 0", slot)
+	}
+	eventually(t, time.Second, "n0 to learn b", func() bool {
+		return len(nodes[0].GetLog()) == 2 && string(nodes[0].GetLog()[1]) == "b"
+	})
+}
+
+// deadPeers reports the listed peers dead and everyone else alive.
+type deadPeers map[string]bool
+
+func (d deadPeers) Alive(id string) bool { return !d[id] }
+
+func (d deadPeers) Suspects() []string {
+	var ids []string
+	for id := range d {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestTransferLeadershipRefusesDeadTarget(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	nodes[0].SetFailureDetector(deadPeers{"n2": true})
+	if err := nodes[0].TransferLeadership("n2"); !errors.Is(err, ErrTargetDown) {
+		t.Fatalf("transfer to a suspected node: err = %v, want ErrTargetDown", err)
+	}
+	if err := nodes[0].TransferLeadership("n1"); err != nil {
+		t.Fatalf("transfer to a live node: %v", err)
+	}
+	eventually(t, time.Second, "n1 to lead", nodes[1].IsLeader)
+}
@@ -0,0 +1,48 @@
+package node
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type setCmd struct {
+	Key string
+	Val int
+}
+
+func TestTypedNodeRoundTrip(t *testing.T) {
+	_, nodes := newTestCluster(t, 3)
+	typed := make([]*TypedNode[setCmd], len(nodes))
+	for i, n := range nodes {
+		typed[i] = NewTypedNode(n,
+			func(c setCmd) ([]byte, error) { return json.Marshal(c) },
+			func(b []byte) (setCmd, error) {
+				var c setCmd
+				err := json.Unmarshal(b, &c)
+				return c, err
+			})
+	}
+
+	want := setCmd{Key: "x", Val: 42}
+	got, err := typed[0].Propose(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("Propose returned %+v, want %+v", got, want)
+	}
+	for i, tn := range typed {
+		eventually(t, 2*time.Second, "the command to be learned", func() bool {
+			_, ok, _ := tn.GetChosenValue()
+			return ok
+		})
+		cmd, _, err := tn.GetChosenValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cmd != want {
+			t.Fatalf("n%d learned %+v, want %+v", i, cmd, want)
+		}
+	}
+}
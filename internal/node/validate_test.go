@@ -0,0 +1,52 @@
+package node
+
+import (
+	"testing"
+
+	"quorum/internal/paxos"
+	"quorum/internal/storage"
+	"quorum/internal/transport"
+)
+
+func TestRouteMessageDropsMalformed(t *testing.T) {
+	net := transport.NewNetwork()
+	defer net.Close()
+	n, err := NewNode("n0", 2, net.AddNode("n0"), storage.NewMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pn := paxos.NewProposalNumber(3, "p1")
+	malformed := []transport.Message{
+		paxos.Prepare{ProposalNumber: pn},
+		paxos.Prepare{ProposalNumber: paxos.ProposalNumber{}, From: "p1"},
+		&paxos.Prepare{Slot: -1, ProposalNumber: pn, From: "p1"},
+		paxos.Accept{ProposalNumber: paxos.ProposalNumber{}, Value: []byte("x"), From: "p1"},
+		&paxos.Accept{ProposalNumber: pn, Value: []byte("x")},
+		paxos.MultiPrepare{FromSlot: 4, ToSlot: 2, ProposalNumber: pn, From: "p1"},
+		paxos.MultiPrepare{FromSlot: 0, ToSlot: maxMultiPrepareSlots, ProposalNumber: pn, From: "p1"},
+		paxos.Learn{Slot: -2, ProposalNumber: pn, Value: []byte("x"), From: "p1"},
+		paxos.Accepted{Slot: 0, ProposalNumber: pn, OK: true},
+		paxos.LeaderTransfer{From: "p1", To: "n9"},
+		struct{ transport.Message }{paxos.Prepare{From: "p1"}},
+	}
+	for i, msg := range malformed {
+		n.routeMessage(msg)
+		if got := n.InvalidMessages(); got != uint64(i+1) {
+			t.Fatalf("%T %+v: InvalidMessages = %d, want %d", msg, msg, got, i+1)
+		}
+	}
+	if promised, accepted, _ := n.acceptor.GetSlotState(0); !promised.IsZero() || !accepted.IsZero() {
+		t.Fatalf("acceptor moved to promised %v, accepted %v on malformed input", promised, accepted)
+	}
+	if _, ok := n.learner.GetChosenAt(0); ok {
+		t.Fatal("learner chose a value from malformed input")
+	}
+	if n.IsLeader() {
+		t.Fatal("misaddressed LeaderTransfer was acted on")
+	}
+
+	n.routeMessage(&paxos.Prepare{ProposalNumber: pn, From: "p1"})
+	if promised, _, _ := n.acceptor.GetSlotState(0); promised != pn || n.InvalidMessages() != uint64(len(malformed)) {
+		t.Fatalf("well-formed Prepare: promised %v, %d invalid", promised, n.InvalidMessages())
+	}
+}
@@ -187,6 +187,12 @@
 // FIX: ALWAYS persist state to durable storage BEFORE sending the response.
 // This is called "write-ahead" or "fsync-before-ack".
 //
+// Here every handler saves the slot and then calls Storage.Sync before it
+// builds an OK reply (persistSynced); if either fails the reply is a
+// rejection. testutil.CountingStorage checks the ordering in tests, and
+// testutil.CrashStorage replays the sequence above end to end: with a Sync
+// that lies, a second value gets chosen.
+//
 // For learning, you can skip this (use in-memory storage), but document
 // that production requires durable storage with sync writes.
 //
@@ -194,68 +200,136 @@
 // MULTI-PAXOS EXTENSION POINT
 // =============================================================================
 //
-// In Multi-Paxos, the acceptor manages state PER SLOT:
+// The acceptor manages state PER SLOT:
 //
 //   type Acceptor struct {
-//       slots map[int64]*SlotState  // State for each log slot
+//       slots map[int64]*acceptorSlot  // State for each log slot
 //   }
 //
-//   type SlotState struct {
-//       highestPromised ProposalNumber
-//       acceptedProposal ProposalNumber
-//       acceptedValue []byte
-//   }
+// Every message carries a Slot, and HandlePrepare/HandleAccept only ever
+// touch that slot's state. Slots are loaded from storage on first use and
+// written back with SaveSlot. Single-decree callers simply use slot 0.
 //
 // Each slot runs an independent Paxos instance, but they can share
 // the Phase 1 promise across slots (leader optimization).
 //
 // =============================================================================
+// LEASES
+// =============================================================================
+//
+// With WithLease(T), every successful Prepare or MultiPrepare also grants
+// its proposer a lease, returned as LeaseUntil = now + T:
+//
+//   "Until LeaseUntil I will promise no other proposer, however high its
+//    proposal number."
+//
+// The holder may renew the lease with another Prepare while it is active.
+// Once a quorum has granted it a lease, a leader knows no rival can finish
+// Phase 1 before the earliest LeaseUntil, so it can run Phase 2 alone.
+//
+// CLOCK ASSUMPTION: LeaseUntil is read off the acceptor's clock and checked
+// against the leader's. Leases are only safe if clocks drift by less than
+// some bound ε, and the leader must stop relying on a lease at
+// LeaseUntil - ε. Wall-clock jumps (NTP steps, suspended VMs) break this.
+//
+// Leases live in memory only. An acceptor that restarts forgets the lease
+// it granted; it should wait T before answering Prepares after a restart.
+//
+// =============================================================================
 
 package paxos
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
+
 	"quorum/internal/storage"
 )
 
+var (
+	ErrWouldRegress = errors.New("import would lower acceptor state")
+	// ErrChosenConflict is returned by Import when a slot it carries is
+	// already chosen here with a different value.
+	ErrChosenConflict = errors.New("import disagrees with a value already chosen")
+)
+
 type Storage interface {
 	SavePromised(proposal storage.ProposalNumber) error
 	LoadPromised() (storage.ProposalNumber, error)
 	SaveAccepted(proposal storage.ProposalNumber, value []byte) error
 	LoadAccepted() (storage.ProposalNumber, []byte, error)
+	SaveSlot(slot int64, state storage.SlotState) error
+	LoadSlot(slot int64) (storage.SlotState, error)
+	GetHighestSlot() (int64, error)
+	Slots() ([]int64, error)
+	Sync() error
 	Close() error
 }
 
-type Acceptor struct {
-	id               string
+type acceptorSlot struct {
 	highestPromised  ProposalNumber
 	acceptedProposal ProposalNumber
 	acceptedValue    []byte
-	storage          Storage
-	mu               sync.Mutex
+	chosen           bool
+	chosenValue      []byte
+}
+
+type Acceptor struct {
+	id          string
+	slots       map[int64]*acceptorSlot
+	storage     Storage
+	lease       time.Duration
+	leaseHolder string
+	leaseUntil  time.Time
+	now         func() time.Time
+	mu          sync.Mutex
+
+	acceptsWithoutPrepare uint64
 }
 
-func NewAcceptor(id string, s Storage) *Acceptor {
+type AcceptorOption func(*Acceptor)
+
+// WithLease grants each successful Prepare a lease of d. See LEASES above.
+func WithLease(d time.Duration) AcceptorOption {
+	return func(a *Acceptor) {
+		a.lease = d
+	}
+}
+
+func NewAcceptor(id string, s Storage, opts ...AcceptorOption) *Acceptor {
 	a := &Acceptor{
 		id:      id,
+		slots:   make(map[int64]*acceptorSlot),
 		storage: s,
+		now:     time.Now,
 	}
-	if promised, err := s.LoadPromised(); err == nil {
-		a.highestPromised = ProposalNumber{
-			Round:      promised.Round,
-			ProposerID: promised.ProposerID,
-		}
-	}
-	if accepted, value, err := s.LoadAccepted(); err == nil {
-		a.acceptedProposal = ProposalNumber{
-			Round:      accepted.Round,
-			ProposerID: accepted.ProposerID,
-		}
-		a.acceptedValue = value
+	for _, opt := range opts {
+		opt(a)
 	}
 	return a
 }
 
+// leasedToOther must be called with a.mu held. It reports whether an active
+// lease belongs to someone other than proposer.
+func (a *Acceptor) leasedToOther(proposer string) bool {
+	return a.leaseHolder != "" && a.leaseHolder != proposer && a.now().Before(a.leaseUntil)
+}
+
+// grantLease must be called with a.mu held, after leasedToOther has
+// returned false. It returns the zero time when leases are off.
+func (a *Acceptor) grantLease(proposer string) time.Time {
+	if a.lease <= 0 {
+		return time.Time{}
+	}
+	a.leaseHolder = proposer
+	a.leaseUntil = a.now().Add(a.lease)
+	return a.leaseUntil
+}
+
 func toStorageProposal(p ProposalNumber) storage.ProposalNumber {
 	return storage.ProposalNumber{
 		Round:      p.Round,
@@ -263,55 +337,426 @@ func toStorageProposal(p ProposalNumber) storage.ProposalNumber {
 	}
 }
 
+func fromStorageProposal(p storage.ProposalNumber) ProposalNumber {
+	return ProposalNumber{
+		Round:      p.Round,
+		ProposerID: p.ProposerID,
+	}
+}
+
+// slot returns the in-memory state for a slot, loading it from storage on
+// first use. Slot 0 falls back to the single-decree records so state
+// written before slots existed is still honoured.
+//
+// A failed read is returned and nothing is cached: treating the slot as
+// empty could promise or accept below a promise already on disk. Handlers
+// reject, and the next message for the slot tries the read again.
+func (a *Acceptor) slot(slot int64) (*acceptorSlot, error) {
+	if st, ok := a.slots[slot]; ok {
+		return st, nil
+	}
+	saved, err := a.storage.LoadSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	st := &acceptorSlot{
+		highestPromised:  fromStorageProposal(saved.HighestPromised),
+		acceptedProposal: fromStorageProposal(saved.AcceptedProposal),
+		acceptedValue:    saved.AcceptedValue,
+		chosen:           saved.Chosen,
+		chosenValue:      saved.ChosenValue,
+	}
+	if slot == 0 && st.highestPromised.IsZero() {
+		promised, err := a.storage.LoadPromised()
+		if err != nil {
+			return nil, err
+		}
+		accepted, value, err := a.storage.LoadAccepted()
+		if err != nil {
+			return nil, err
+		}
+		st.highestPromised = fromStorageProposal(promised)
+		st.acceptedProposal = fromStorageProposal(accepted)
+		st.acceptedValue = value
+	}
+	a.slots[slot] = st
+	return st, nil
+}
+
+func (a *Acceptor) persist(slot int64, st *acceptorSlot) error {
+	return a.storage.SaveSlot(slot, storage.SlotState{
+		HighestPromised:  toStorageProposal(st.highestPromised),
+		AcceptedProposal: toStorageProposal(st.acceptedProposal),
+		AcceptedValue:    st.acceptedValue,
+		Chosen:           st.chosen,
+		ChosenValue:      st.chosenValue,
+	})
+}
+
+// persistSynced saves a slot and syncs storage. Handlers call it before an
+// OK reply and reject instead if it fails: an acceptor must never
+// acknowledge state that might not survive a crash.
+func (a *Acceptor) persistSynced(slot int64, st *acceptorSlot) error {
+	if err := a.persist(slot, st); err != nil {
+		return err
+	}
+	return a.storage.Sync()
+}
+
 func (a *Acceptor) HandlePrepare(msg Prepare) Promise {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	return a.prepare(msg)
+}
+
+// HandlePrepareBatch answers msgs in order under a single lock, returning
+// exactly what HandlePrepare would for each in turn. Every promise is
+// still persisted and synced before the next message is looked at.
+func (a *Acceptor) HandlePrepareBatch(msgs []Prepare) []Promise {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	promises := make([]Promise, len(msgs))
+	for i, msg := range msgs {
+		promises[i] = a.prepare(msg)
+	}
+	return promises
+}
 
-	if msg.ProposalNumber.GreaterThan(a.highestPromised) {
-		a.highestPromised = msg.ProposalNumber
-		a.storage.SavePromised(toStorageProposal(a.highestPromised))
-		return Promise{
-			OK:               true,
-			ProposalNumber:   msg.ProposalNumber,
-			AcceptedProposal: a.acceptedProposal,
-			AcceptedValue:    a.acceptedValue,
-			From:             a.id,
+// prepare must be called with a.mu held.
+func (a *Acceptor) prepare(msg Prepare) Promise {
+	st, err := a.slot(msg.Slot)
+	if err != nil {
+		return Promise{Slot: msg.Slot, OK: false, ProposalNumber: msg.ProposalNumber, From: a.id}
+	}
+	leased := a.leasedToOther(msg.ProposalNumber.ProposerID)
+	if !leased && msg.ProposalNumber.GreaterThan(st.highestPromised) {
+		st.highestPromised = msg.ProposalNumber
+		if err := a.persistSynced(msg.Slot, st); err == nil {
+			return Promise{
+				Slot:             msg.Slot,
+				OK:               true,
+				ProposalNumber:   msg.ProposalNumber,
+				AcceptedProposal: st.acceptedProposal,
+				AcceptedValue:    st.acceptedValue,
+				HasAccepted:      !st.acceptedProposal.IsZero(),
+				From:             a.id,
+				LeaseUntil:       a.grantLease(msg.ProposalNumber.ProposerID),
+			}
 		}
 	}
-	return Promise{
-		OK:               false,
-		ProposalNumber:   msg.ProposalNumber,
-		AcceptedProposal: a.highestPromised,
-		From:             a.id,
+	reject := Promise{
+		Slot:           msg.Slot,
+		OK:             false,
+		ProposalNumber: msg.ProposalNumber,
+		HighestSeen:    st.highestPromised,
+		From:           a.id,
+	}
+	if leased {
+		reject.LeaseUntil = a.leaseUntil
 	}
+	return reject
 }
 
 func (a *Acceptor) HandleAccept(msg Accept) Accepted {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	if msg.ProposalNumber.GreaterThan(a.highestPromised) || msg.ProposalNumber.Equal(a.highestPromised) {
-		a.highestPromised = msg.ProposalNumber
-		a.acceptedProposal = msg.ProposalNumber
-		a.acceptedValue = msg.Value
-		a.storage.SavePromised(toStorageProposal(a.highestPromised))
-		a.storage.SaveAccepted(toStorageProposal(a.acceptedProposal), a.acceptedValue)
-		return Accepted{
-			OK:             true,
-			ProposalNumber: msg.ProposalNumber,
-			Value:          msg.Value,
-			From:           a.id,
+	st, err := a.slot(msg.Slot)
+	if err != nil {
+		return Accepted{Slot: msg.Slot, OK: false, ProposalNumber: msg.ProposalNumber, From: a.id}
+	}
+	if (msg.ProposalNumber.GreaterThan(st.highestPromised) || msg.ProposalNumber.Equal(st.highestPromised)) && !st.wouldOverwrite(msg) {
+		unprepared := msg.ProposalNumber.GreaterThan(st.highestPromised)
+		st.highestPromised = msg.ProposalNumber
+		st.acceptedProposal = msg.ProposalNumber
+		st.acceptedValue = msg.Value
+		if err := a.persistSynced(msg.Slot, st); err == nil {
+			if unprepared {
+				a.acceptsWithoutPrepare++
+			}
+			return Accepted{
+				Slot:           msg.Slot,
+				OK:             true,
+				ProposalNumber: msg.ProposalNumber,
+				Value:          msg.Value,
+				From:           a.id,
+			}
 		}
 	}
 	return Accepted{
+		Slot:           msg.Slot,
 		OK:             false,
 		ProposalNumber: msg.ProposalNumber,
+		HighestSeen:    st.highestSeen(),
+		From:           a.id,
+	}
+}
+
+// AcceptsWithoutPrepare counts Accepts taken at a number above anything
+// this acceptor had promised, i.e. with no Prepare (or MultiPrepare) for
+// that number seen first. Paxos allows it, but in normal operation every
+// Accept follows its Prepare, so a rising count usually means Prepares are
+// being lost or a proposer is skipping Phase 1.
+func (a *Acceptor) AcceptsWithoutPrepare() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.acceptsWithoutPrepare
+}
+
+// wouldOverwrite reports whether accepting msg would move acceptedProposal
+// backwards, or give the proposal already accepted a different value. The
+// promise check alone rules both out only while highestPromised never falls
+// below acceptedProposal; this holds even if restored state breaks that.
+func (st *acceptorSlot) wouldOverwrite(msg Accept) bool {
+	if msg.ProposalNumber.LessThan(st.acceptedProposal) {
+		return true
+	}
+	return msg.ProposalNumber.Equal(st.acceptedProposal) && !bytes.Equal(msg.Value, st.acceptedValue)
+}
+
+func (st *acceptorSlot) highestSeen() ProposalNumber {
+	if st.acceptedProposal.GreaterThan(st.highestPromised) {
+		return st.acceptedProposal
+	}
+	return st.highestPromised
+}
+
+// HandleMultiPrepare promises msg.ProposalNumber for every slot in the range,
+// or for none of them: a single slot with an equal or higher promise rejects
+// the whole range, reporting the highest promise found.
+func (a *Acceptor) HandleMultiPrepare(msg MultiPrepare) MultiPromise {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var highest ProposalNumber
+	var loadErr error
+	for slot := msg.FromSlot; slot <= msg.ToSlot; slot++ {
+		st, err := a.slot(slot)
+		if err != nil {
+			loadErr = err
+			break
+		}
+		if st.highestPromised.GreaterThan(highest) {
+			highest = st.highestPromised
+		}
+	}
+	leased := a.leasedToOther(msg.ProposalNumber.ProposerID)
+	if loadErr != nil || leased || !msg.ProposalNumber.GreaterThan(highest) {
+		reject := MultiPromise{
+			FromSlot:       msg.FromSlot,
+			ToSlot:         msg.ToSlot,
+			OK:             false,
+			ProposalNumber: msg.ProposalNumber,
+			HighestSeen:    highest,
+			From:           a.id,
+		}
+		if leased {
+			reject.LeaseUntil = a.leaseUntil
+		}
+		return reject
+	}
+	accepted := make(map[int64]AcceptedEntry)
+	var persistErr error
+	for slot := msg.FromSlot; slot <= msg.ToSlot; slot++ {
+		st := a.slots[slot]
+		st.highestPromised = msg.ProposalNumber
+		if err := a.persist(slot, st); err != nil && persistErr == nil {
+			persistErr = err
+		}
+		if !st.acceptedProposal.IsZero() {
+			accepted[slot] = AcceptedEntry{
+				ProposalNumber: st.acceptedProposal,
+				Value:          st.acceptedValue,
+			}
+		}
+	}
+	if persistErr == nil {
+		persistErr = a.storage.Sync()
+	}
+	if persistErr != nil {
+		return MultiPromise{
+			FromSlot:       msg.FromSlot,
+			ToSlot:         msg.ToSlot,
+			OK:             false,
+			ProposalNumber: msg.ProposalNumber,
+			HighestSeen:    msg.ProposalNumber,
+			From:           a.id,
+		}
+	}
+	return MultiPromise{
+		FromSlot:       msg.FromSlot,
+		ToSlot:         msg.ToSlot,
+		OK:             true,
+		ProposalNumber: msg.ProposalNumber,
+		AcceptedSlots:  accepted,
 		From:           a.id,
+		LeaseUntil:     a.grantLease(msg.ProposalNumber.ProposerID),
+	}
+}
+
+// MarkChosen records that a slot's value was chosen. It goes through the
+// acceptor rather than straight to storage so the chosen marker can never
+// be written alongside a stale promise.
+func (a *Acceptor) MarkChosen(slot int64, value []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st, err := a.slot(slot)
+	if err != nil {
+		return err
 	}
+	if st.chosen {
+		return nil
+	}
+	st.chosen = true
+	st.chosenValue = value
+	return a.persist(slot, st)
 }
 
 func (a *Acceptor) GetState() (ProposalNumber, ProposalNumber, []byte) {
+	return a.GetSlotState(0)
+}
+
+// GetSlotState reports a slot's promise and accepted proposal, or zeros
+// if its state can't be read from storage.
+func (a *Acceptor) GetSlotState(slot int64) (ProposalNumber, ProposalNumber, []byte) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	return a.highestPromised, a.acceptedProposal, a.acceptedValue
-}
\ No newline at end of file
+	st, err := a.slot(slot)
+	if err != nil {
+		return ProposalNumber{}, ProposalNumber{}, nil
+	}
+	return st.highestPromised, st.acceptedProposal, st.acceptedValue
+}
+
+type AcceptorSlotState struct {
+	HighestPromised  ProposalNumber
+	AcceptedProposal ProposalNumber
+	AcceptedValue    []byte
+	Chosen           bool
+	ChosenValue      []byte
+}
+
+// AcceptorState is a backup of every slot the acceptor has touched.
+type AcceptorState struct {
+	Slots map[int64]AcceptorSlotState
+}
+
+// Export snapshots the acceptor's durable state, slot by slot. Only slots
+// that storage or the cache know about are visited, plus slot 0 for state
+// saved before slots existed, so a sparse log costs what it holds.
+func (a *Acceptor) Export() (AcceptorState, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	slots, err := a.knownSlots()
+	if err != nil {
+		return AcceptorState{}, err
+	}
+	state := AcceptorState{Slots: make(map[int64]AcceptorSlotState)}
+	for _, slot := range slots {
+		st, err := a.slot(slot)
+		if err != nil {
+			return AcceptorState{}, err
+		}
+		if st.highestPromised.IsZero() && st.acceptedProposal.IsZero() && !st.chosen {
+			continue
+		}
+		state.Slots[slot] = AcceptorSlotState{
+			HighestPromised:  st.highestPromised,
+			AcceptedProposal: st.acceptedProposal,
+			AcceptedValue:    st.acceptedValue,
+			Chosen:           st.chosen,
+			ChosenValue:      st.chosenValue,
+		}
+	}
+	return state, nil
+}
+
+// knownSlots lists slot 0, every slot in storage and every cached slot,
+// sorted and without repeats. It must be called with a.mu held.
+func (a *Acceptor) knownSlots() ([]int64, error) {
+	stored, err := a.storage.Slots()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[int64]bool{0: true}
+	slots := []int64{0}
+	for _, slot := range stored {
+		if !seen[slot] {
+			seen[slot] = true
+			slots = append(slots, slot)
+		}
+	}
+	for slot := range a.slots {
+		if !seen[slot] {
+			seen[slot] = true
+			slots = append(slots, slot)
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+	return slots, nil
+}
+
+// Import restores a backup into the acceptor and its storage, then syncs.
+//
+// Every slot is checked before anything is written. Going backwards would
+// let the acceptor break a promise or forget a value it accepted, so if any
+// slot's promise or accepted proposal is lower than what the acceptor
+// already holds, ErrWouldRegress is returned. A slot already chosen here
+// with a different value means two values were chosen somewhere, and
+// ErrChosenConflict is returned rather than picking one.
+//
+// Storage has no transactions, so a write or the sync failing partway
+// returns the error with some slots written and others not. Each write
+// only raises the state it replaces, so that is safe, and the cache is
+// dropped for every slot touched so it reads back what storage kept;
+// retrying the same Import finishes the job.
+func (a *Acceptor) Import(state AcceptorState) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	updated := make(map[int64]*acceptorSlot, len(state.Slots))
+	for slot, imported := range state.Slots {
+		st, err := a.slot(slot)
+		if err != nil {
+			return err
+		}
+		if imported.HighestPromised.LessThan(st.highestPromised) ||
+			imported.AcceptedProposal.LessThan(st.acceptedProposal) {
+			return ErrWouldRegress
+		}
+		if st.chosen && imported.Chosen && !bytes.Equal(st.chosenValue, imported.ChosenValue) {
+			return fmt.Errorf("%w: slot %d chosen as %q here, %q in the import",
+				ErrChosenConflict, slot, st.chosenValue, imported.ChosenValue)
+		}
+		next := *st
+		next.highestPromised = imported.HighestPromised
+		next.acceptedProposal = imported.AcceptedProposal
+		next.acceptedValue = imported.AcceptedValue
+		if imported.Chosen && !st.chosen {
+			next.chosen = true
+			next.chosenValue = imported.ChosenValue
+		}
+		updated[slot] = &next
+	}
+	for slot, st := range updated {
+		if err := a.persist(slot, st); err != nil {
+			a.forget(updated)
+			return err
+		}
+	}
+	if err := a.storage.Sync(); err != nil {
+		a.forget(updated)
+		return err
+	}
+	for slot, st := range updated {
+		a.slots[slot] = st
+	}
+	return nil
+}
+
+// forget drops the cached state of slots so the next use reloads them
+// from storage. It must be called with a.mu held.
+func (a *Acceptor) forget(slots map[int64]*acceptorSlot) {
+	for slot := range slots {
+		delete(a.slots, slot)
+	}
+}
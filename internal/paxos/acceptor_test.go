@@ -0,0 +1,483 @@
+package paxos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"quorum/internal/storage"
+	"quorum/internal/testutil"
+)
+
+var errReadFailed = errors.New("read failed")
+
+// flakyStorage fails every load while failLoads is set.
+type flakyStorage struct {
+	*storage.MemoryStorage
+	failLoads bool
+}
+
+func (f *flakyStorage) LoadSlot(slot int64) (storage.SlotState, error) {
+	if f.failLoads {
+		return storage.SlotState{}, errReadFailed
+	}
+	return f.MemoryStorage.LoadSlot(slot)
+}
+
+func (f *flakyStorage) LoadPromised() (storage.ProposalNumber, error) {
+	if f.failLoads {
+		return storage.ProposalNumber{}, errReadFailed
+	}
+	return f.MemoryStorage.LoadPromised()
+}
+
+func TestAcceptorRejectsWhenSlotCannotBeRead(t *testing.T) {
+	for _, slot := range []int64{0, 3} {
+		s := &flakyStorage{MemoryStorage: storage.NewMemoryStorage()}
+		promised := storage.ProposalNumber{Round: 5, ProposerID: "p1"}
+		if err := s.SaveSlot(slot, storage.SlotState{HighestPromised: promised}); err != nil {
+			t.Fatal(err)
+		}
+		a := NewAcceptor("a1", s)
+		low := ProposalNumber{Round: 3, ProposerID: "p2"}
+
+		s.failLoads = true
+		if r := a.HandlePrepare(Prepare{Slot: slot, ProposalNumber: low, From: "p2"}); r.OK {
+			t.Fatalf("slot %d: promised %v while storage was unreadable", slot, low)
+		}
+		if r := a.HandleAccept(Accept{Slot: slot, ProposalNumber: low, Value: []byte("x"), From: "p2"}); r.OK {
+			t.Fatalf("slot %d: accepted %v while storage was unreadable", slot, low)
+		}
+
+		s.failLoads = false
+		if r := a.HandlePrepare(Prepare{Slot: slot, ProposalNumber: low, From: "p2"}); r.OK {
+			t.Fatalf("slot %d: promised %v below the persisted promise 5 after a failed read", slot, low)
+		}
+		high := ProposalNumber{Round: 6, ProposerID: "p2"}
+		if r := a.HandlePrepare(Prepare{Slot: slot, ProposalNumber: high, From: "p2"}); !r.OK {
+			t.Fatalf("slot %d: rejected %v once storage recovered", slot, high)
+		}
+	}
+}
+
+func TestAcceptorMultiPrepareRejectsOnReadError(t *testing.T) {
+	s := &flakyStorage{MemoryStorage: storage.NewMemoryStorage(), failLoads: true}
+	a := NewAcceptor("a1", s)
+	msg := MultiPrepare{FromSlot: 1, ToSlot: 4, ProposalNumber: ProposalNumber{Round: 1, ProposerID: "p1"}, From: "p1"}
+	if r := a.HandleMultiPrepare(msg); r.OK {
+		t.Fatal("MultiPrepare promised while storage was unreadable")
+	}
+	if err := a.MarkChosen(2, []byte("x")); err == nil {
+		t.Fatal("MarkChosen: want the read error")
+	}
+}
+
+// FuzzAcceptor reads its input four bytes per step - kind, round, proposer,
+// value - and plays the steps through an AcceptorChecker, which fails on
+// any step that breaks the promise or accept invariants.
+func FuzzAcceptor(f *testing.F) {
+	f.Add([]byte{0, 5, 0, 'x', 1, 5, 0, 'x'})
+	f.Add([]byte{0, 5, 1, 0, 1, 3, 0, 'y', 1, 5, 1, 'z'})
+	f.Add([]byte{1, 2, 0, 'a', 0, 2, 1, 0, 1, 2, 0, 'b', 1, 2, 1, 'c'})
+	f.Add([]byte{0, 7, 2, 0, 0, 6, 1, 0, 1, 7, 2, 'v', 0, 9, 0, 0, 1, 8, 1, 'w'})
+	f.Fuzz(func(t *testing.T, steps []byte) {
+		c := NewAcceptorChecker(NewAcceptor("a1", storage.NewMemoryStorage()))
+		for i := 0; i+4 <= len(steps); i += 4 {
+			kind, round, proposer, value := steps[i], steps[i+1], steps[i+2], steps[i+3]
+			n := ProposalNumber{Round: int64(round % 16), ProposerID: fmt.Sprintf("p%d", proposer%3)}
+			var err error
+			if kind%2 == 0 {
+				_, err = c.Prepare(Prepare{ProposalNumber: n, From: n.ProposerID})
+			} else {
+				_, err = c.Accept(Accept{ProposalNumber: n, Value: []byte{value}, From: n.ProposerID})
+			}
+			if err != nil {
+				t.Fatalf("step %d: %v", i/4, err)
+			}
+		}
+	})
+}
+
+func TestMultiPromiseReportsEveryAcceptedSlot(t *testing.T) {
+	a := NewAcceptor("a0", storage.NewMemoryStorage())
+	old := NewProposalNumber(1, "old")
+	for slot, v := range map[int64]string{2: "two", 4: "four", 9: "outside"} {
+		if ack := a.HandleAccept(Accept{Slot: slot, ProposalNumber: old, Value: []byte(v), From: "old"}); !ack.OK {
+			t.Fatalf("accept at slot %d refused", slot)
+		}
+	}
+
+	promise := a.HandleMultiPrepare(MultiPrepare{FromSlot: 0, ToSlot: 5, ProposalNumber: NewProposalNumber(2, "new"), From: "new"})
+	if !promise.OK {
+		t.Fatalf("MultiPrepare refused: %+v", promise)
+	}
+	if len(promise.AcceptedSlots) != 2 {
+		t.Fatalf("AcceptedSlots = %v, want slots 2 and 4", promise.AcceptedSlots)
+	}
+	for slot, want := range map[int64]string{2: "two", 4: "four"} {
+		e, ok := promise.AcceptedSlots[slot]
+		if !ok || e.ProposalNumber != old || string(e.Value) != want {
+			t.Fatalf("slot %d reported as %+v, %v, want %v %q", slot, e, ok, old, want)
+		}
+	}
+}
+
+func TestAcceptorExportImportAcrossStorage(t *testing.T) {
+	src := NewAcceptor("a0", storage.NewMemoryStorage())
+	p := NewProposalNumber(2, "p1")
+	for slot, v := range map[int64]string{0: "zero", 3: "three"} {
+		if ack := src.HandleAccept(Accept{Slot: slot, ProposalNumber: p, Value: []byte(v), From: "p1"}); !ack.OK {
+			t.Fatalf("accept at slot %d refused", slot)
+		}
+	}
+	src.HandlePrepare(Prepare{Slot: 5, ProposalNumber: NewProposalNumber(4, "p2"), From: "p2"})
+	if err := src.MarkChosen(0, []byte("zero")); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := src.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backup.Slots) != 3 {
+		t.Fatalf("exported slots %v, want 0, 3 and 5", backup.Slots)
+	}
+
+	path := t.TempDir() + "/acceptor.wal"
+	fs, err := storage.NewFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewAcceptor("a0", fs).Import(backup); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err = storage.NewFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	restored, err := NewAcceptor("a0", fs).Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameAcceptorState(restored, backup) {
+		t.Fatalf("reopened file storage exports\n%+v\nwant\n%+v", restored, backup)
+	}
+}
+
+// sameAcceptorState compares backups, treating nil and empty values alike
+// since storage backends don't agree on which they return.
+func sameAcceptorState(a, b AcceptorState) bool {
+	if len(a.Slots) != len(b.Slots) {
+		return false
+	}
+	for slot, x := range a.Slots {
+		y, ok := b.Slots[slot]
+		if !ok || x.HighestPromised != y.HighestPromised || x.AcceptedProposal != y.AcceptedProposal ||
+			x.Chosen != y.Chosen || !bytes.Equal(x.AcceptedValue, y.AcceptedValue) || !bytes.Equal(x.ChosenValue, y.ChosenValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAcceptorImportRefusesToRegress(t *testing.T) {
+	a := NewAcceptor("a0", storage.NewMemoryStorage())
+	a.HandlePrepare(Prepare{Slot: 1, ProposalNumber: NewProposalNumber(9, "p1"), From: "p1"})
+	older := AcceptorState{Slots: map[int64]AcceptorSlotState{
+		0: {HighestPromised: NewProposalNumber(3, "p1")},
+		1: {HighestPromised: NewProposalNumber(2, "p1")},
+	}}
+	if err := a.Import(older); !errors.Is(err, ErrWouldRegress) {
+		t.Fatalf("err = %v, want ErrWouldRegress", err)
+	}
+	// Nothing is written, not even the slot that would have moved forward.
+	if promised, _, _ := a.GetSlotState(0); !promised.IsZero() {
+		t.Fatalf("slot 0 promised %v after a refused import", promised)
+	}
+	if promised, _, _ := a.GetSlotState(1); promised != NewProposalNumber(9, "p1") {
+		t.Fatalf("slot 1 promised %v, want it left at 9/p1", promised)
+	}
+}
+
+func TestAcceptorImportRefusesChosenConflict(t *testing.T) {
+	a := NewAcceptor("a0", storage.NewMemoryStorage())
+	if err := a.MarkChosen(2, []byte("ours")); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProposalNumber(5, "p1")
+	conflict := AcceptorState{Slots: map[int64]AcceptorSlotState{
+		1: {HighestPromised: p},
+		2: {HighestPromised: p, AcceptedProposal: p, AcceptedValue: []byte("theirs"), Chosen: true, ChosenValue: []byte("theirs")},
+	}}
+	if err := a.Import(conflict); !errors.Is(err, ErrChosenConflict) {
+		t.Fatalf("err = %v, want ErrChosenConflict", err)
+	}
+	exported, err := a.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := exported.Slots[2]; !st.Chosen || string(st.ChosenValue) != "ours" {
+		t.Fatalf("slot 2 after a refused import = %+v, want ours kept", st)
+	}
+	if _, ok := exported.Slots[1]; ok {
+		t.Fatal("slot 1 was written by a refused import")
+	}
+
+	// The same chosen value is no conflict.
+	conflict.Slots[2] = AcceptorSlotState{HighestPromised: p, AcceptedProposal: p, AcceptedValue: []byte("ours"), Chosen: true, ChosenValue: []byte("ours")}
+	if err := a.Import(conflict); err != nil {
+		t.Fatalf("import agreeing with the chosen value: %v", err)
+	}
+}
+
+// failingSlotStorage fails SaveSlot for one slot.
+type failingSlotStorage struct {
+	*storage.MemoryStorage
+	failSlot int64
+}
+
+func (f *failingSlotStorage) SaveSlot(slot int64, state storage.SlotState) error {
+	if slot == f.failSlot {
+		return errReadFailed
+	}
+	return f.MemoryStorage.SaveSlot(slot, state)
+}
+
+func TestAcceptorImportSyncsAndSurvivesPartialFailure(t *testing.T) {
+	p := NewProposalNumber(5, "p1")
+	backup := AcceptorState{Slots: map[int64]AcceptorSlotState{}}
+	for slot := int64(0); slot < 4; slot++ {
+		backup.Slots[slot] = AcceptorSlotState{HighestPromised: p, AcceptedProposal: p, AcceptedValue: []byte("v")}
+	}
+
+	counting := testutil.NewCountingStorage(storage.NewMemoryStorage())
+	if err := NewAcceptor("a0", counting).Import(backup); err != nil {
+		t.Fatal(err)
+	}
+	if counting.Unsynced() {
+		t.Fatal("Import returned with slots saved but not synced")
+	}
+
+	mem := storage.NewMemoryStorage()
+	a := NewAcceptor("a0", &failingSlotStorage{MemoryStorage: mem, failSlot: 2})
+	if err := a.Import(backup); !errors.Is(err, errReadFailed) {
+		t.Fatalf("err = %v, want the failed write", err)
+	}
+	// Whatever was written, the acceptor reports what storage holds.
+	for slot := int64(0); slot < 4; slot++ {
+		saved, err := mem.LoadSlot(slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if promised, _, _ := a.GetSlotState(slot); promised != fromStorageProposal(saved.HighestPromised) {
+			t.Fatalf("slot %d: acceptor says %v, storage has %v", slot, promised, saved.HighestPromised)
+		}
+	}
+}
+
+func TestAcceptorExportSkipsUnusedSlots(t *testing.T) {
+	a := NewAcceptor("a0", storage.NewMemoryStorage())
+	far := int64(1) << 40
+	if ack := a.HandleAccept(Accept{Slot: far, ProposalNumber: NewProposalNumber(1, "p1"), Value: []byte("far"), From: "p1"}); !ack.OK {
+		t.Fatal("accept refused")
+	}
+	done := make(chan AcceptorState, 1)
+	go func() {
+		state, err := a.Export()
+		if err != nil {
+			t.Error(err)
+		}
+		done <- state
+	}()
+	select {
+	case state := <-done:
+		if len(state.Slots) != 1 || string(state.Slots[far].AcceptedValue) != "far" {
+			t.Fatalf("exported %+v, want only slot %d", state.Slots, far)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Export is walking every slot up to %d", far)
+	}
+}
+
+func TestAcceptorLeaseBlocksRivalPrepare(t *testing.T) {
+	now := time.Unix(1000, 0)
+	a := NewAcceptor("a0", storage.NewMemoryStorage(), WithLease(time.Second))
+	a.now = func() time.Time { return now }
+
+	leader := a.HandlePrepare(Prepare{ProposalNumber: NewProposalNumber(1, "leader"), From: "leader"})
+	if !leader.OK || !leader.LeaseUntil.Equal(now.Add(time.Second)) {
+		t.Fatalf("leader's promise = %+v, want OK with a lease until %v", leader, now.Add(time.Second))
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	rival := a.HandlePrepare(Prepare{ProposalNumber: NewProposalNumber(5, "rival"), From: "rival"})
+	if rival.OK || !rival.LeaseUntil.Equal(leader.LeaseUntil) {
+		t.Fatalf("rival's promise during the lease = %+v, want a rejection naming the lease", rival)
+	}
+	if multi := a.HandleMultiPrepare(MultiPrepare{FromSlot: 0, ToSlot: 3, ProposalNumber: NewProposalNumber(6, "rival"), From: "rival"}); multi.OK {
+		t.Fatal("rival's MultiPrepare was promised during the lease")
+	}
+	if renewed := a.HandlePrepare(Prepare{ProposalNumber: NewProposalNumber(2, "leader"), From: "leader"}); !renewed.OK {
+		t.Fatalf("leader could not renew: %+v", renewed)
+	}
+
+	now = now.Add(2 * time.Second)
+	if late := a.HandlePrepare(Prepare{ProposalNumber: NewProposalNumber(7, "rival"), From: "rival"}); !late.OK {
+		t.Fatalf("rival's promise after the lease = %+v, want OK", late)
+	}
+}
+
+func TestAcceptNeverLowersAcceptedProposal(t *testing.T) {
+	high, low := NewProposalNumber(5, "p2"), NewProposalNumber(3, "p1")
+	for name, setup := range map[string]func(a *Acceptor) error{
+		// The higher Accept simply arrives first.
+		"reordered": func(a *Acceptor) error {
+			if ack := a.HandleAccept(Accept{ProposalNumber: high, Value: []byte("high"), From: "p2"}); !ack.OK {
+				return errors.New("higher accept refused")
+			}
+			return nil
+		},
+		// A promise below the accepted number, as storage written by an
+		// older version might hold, lets the lower Accept past the promise
+		// check.
+		"promise below accept": func(a *Acceptor) error {
+			return a.SetStateForTest(NewProposalNumber(2, "p0"), high, []byte("high"))
+		},
+	} {
+		a := NewAcceptor("a0", storage.NewMemoryStorage())
+		if err := setup(a); err != nil {
+			t.Fatal(err)
+		}
+		ack := a.HandleAccept(Accept{ProposalNumber: low, Value: []byte("low"), From: "p1"})
+		if ack.OK || ack.HighestSeen != high {
+			t.Fatalf("%s: lower accept = %+v, want a rejection naming %v", name, ack, high)
+		}
+		if _, accepted, v := a.GetState(); accepted != high || string(v) != "high" {
+			t.Fatalf("%s: accepted %v %q, want %v high kept", name, accepted, v, high)
+		}
+		if ack := a.HandleAccept(Accept{ProposalNumber: high, Value: []byte("other"), From: "p2"}); ack.OK {
+			t.Fatalf("%s: a different value at the same number replaced the accepted one", name)
+		}
+		if ack := a.HandleAccept(Accept{ProposalNumber: high, Value: []byte("high"), From: "p2"}); !ack.OK {
+			t.Fatalf("%s: a repeated accept of the same value was refused", name)
+		}
+	}
+}
+
+func TestAcceptWithoutPrepareIsCounted(t *testing.T) {
+	a := NewAcceptor("a0", storage.NewMemoryStorage())
+	n := NewProposalNumber(4, "p1")
+	if ack := a.HandleAccept(Accept{ProposalNumber: n, Value: []byte("x"), From: "p1"}); !ack.OK {
+		t.Fatalf("unprepared accept = %+v, want it taken", ack)
+	}
+	if promised, accepted, v := a.GetState(); promised != n || accepted != n || string(v) != "x" {
+		t.Fatalf("state = %v %v %q, want x accepted at %v", promised, accepted, v, n)
+	}
+	if got := a.AcceptsWithoutPrepare(); got != 1 {
+		t.Fatalf("AcceptsWithoutPrepare = %d, want 1", got)
+	}
+
+	// The normal flow, and a repeat of an accepted number, leave it alone.
+	next := NewProposalNumber(5, "p1")
+	if promise := a.HandlePrepare(Prepare{ProposalNumber: next, From: "p1"}); !promise.OK {
+		t.Fatal("prepare refused")
+	}
+	for i := 0; i < 2; i++ {
+		if ack := a.HandleAccept(Accept{ProposalNumber: next, Value: []byte("y"), From: "p1"}); !ack.OK {
+			t.Fatal("prepared accept refused")
+		}
+	}
+	if got := a.AcceptsWithoutPrepare(); got != 1 {
+		t.Fatalf("AcceptsWithoutPrepare = %d after a prepared accept, want 1", got)
+	}
+}
+
+// prepareWorkload mixes fresh slots, repeats, lower numbers that must be
+// rejected, and slots with a value already accepted.
+func prepareWorkload(n int) []Prepare {
+	msgs := make([]Prepare, n)
+	for i := range msgs {
+		msgs[i] = Prepare{
+			Slot:           int64(i % 7),
+			ProposalNumber: NewProposalNumber(int64(1+(i*5)%11), fmt.Sprintf("p%d", i%3)),
+			From:           fmt.Sprintf("p%d", i%3),
+		}
+	}
+	return msgs
+}
+
+func newWorkloadAcceptor(tb testing.TB) *Acceptor {
+	tb.Helper()
+	a := NewAcceptor("a0", storage.NewMemoryStorage())
+	for _, slot := range []int64{2, 5} {
+		if ack := a.HandleAccept(Accept{Slot: slot, ProposalNumber: NewProposalNumber(3, "old"), Value: []byte("v"), From: "old"}); !ack.OK {
+			tb.Fatalf("seeding slot %d failed", slot)
+		}
+	}
+	return a
+}
+
+func TestHandlePrepareBatchMatchesSequential(t *testing.T) {
+	msgs := prepareWorkload(60)
+	seq, batched := newWorkloadAcceptor(t), newWorkloadAcceptor(t)
+	want := make([]Promise, len(msgs))
+	for i, msg := range msgs {
+		want[i] = seq.HandlePrepare(msg)
+	}
+	got := batched.HandlePrepareBatch(msgs)
+	if len(got) != len(want) {
+		t.Fatalf("batch returned %d promises for %d prepares", len(got), len(msgs))
+	}
+	oks, rejects := 0, 0
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("prepare %d (%+v): batch %+v, sequential %+v", i, msgs[i], got[i], want[i])
+		}
+		if want[i].OK {
+			oks++
+		} else {
+			rejects++
+		}
+	}
+	if oks == 0 || rejects == 0 {
+		t.Fatalf("workload gave %d promises and %d rejections; it should have both", oks, rejects)
+	}
+	for slot := int64(0); slot < 7; slot++ {
+		p1, a1, v1 := seq.GetSlotState(slot)
+		p2, a2, v2 := batched.GetSlotState(slot)
+		if p1 != p2 || a1 != a2 || !bytes.Equal(v1, v2) {
+			t.Fatalf("slot %d: batch left %v %v %q, sequential %v %v %q", slot, p2, a2, v2, p1, a1, v1)
+		}
+	}
+	if got := batched.HandlePrepareBatch(nil); len(got) != 0 {
+		t.Fatalf("empty batch = %v", got)
+	}
+}
+
+// BenchmarkHandlePrepare answers the same 64 prepares one lock at a time
+// and under one lock; both collect the promises, as a caller would.
+func BenchmarkHandlePrepare(b *testing.B) {
+	msgs := prepareWorkload(64)
+	b.Run("sequential", func(b *testing.B) {
+		a := newWorkloadAcceptor(b)
+		for i := 0; i < b.N; i++ {
+			promises := make([]Promise, len(msgs))
+			for j, msg := range msgs {
+				promises[j] = a.HandlePrepare(msg)
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		a := newWorkloadAcceptor(b)
+		for i := 0; i < b.N; i++ {
+			a.HandlePrepareBatch(msgs)
+		}
+	})
+}
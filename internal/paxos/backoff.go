@@ -0,0 +1,96 @@
+// =============================================================================
+// BACKOFF - Adapting Retry Delays to Contention
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Dueling proposers (see LIVENESS CONSIDERATIONS in proposer.go) keep
+// pre-empting each other as long as they retry at once. The proposer
+// therefore waits a random time before retrying a rejected attempt, and
+// sizes that wait from how contended things have been lately:
+//
+//   rejection      contention++        ceiling doubles
+//   clean success  contention = 0      no wait at all
+//   success after
+//   retries        contention--        ceiling halves
+//
+//   ceiling = backoffBase << (contention-1), capped at backoffMax
+//   wait    = random in [ceiling/2, ceiling]
+//
+// The randomness is what breaks the duel: two proposers with the same
+// ceiling almost never wake at the same moment. Timeouts and unreachable
+// quorums are not contention and leave the estimate alone.
+//
+// Backoff reports the current ceiling.
+//
+// =============================================================================
+
+package paxos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+const (
+	backoffBase = 5 * time.Millisecond
+	backoffMax  = 500 * time.Millisecond
+)
+
+// Backoff is the longest the proposer would currently wait before retrying
+// a rejected attempt. It is 0 when there has been no recent contention.
+func (p *Proposer) Backoff() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.backoffCeiling()
+}
+
+func (p *Proposer) backoffCeiling() time.Duration {
+	if p.contention == 0 {
+		return 0
+	}
+	d := backoffBase
+	for i := 1; i < p.contention && d < backoffMax; i++ {
+		d *= 2
+	}
+	if d > backoffMax {
+		d = backoffMax
+	}
+	return d
+}
+
+// observeSuccess updates the estimate after a value was chosen in attempts
+// rounds.
+func (p *Proposer) observeSuccess(attempts int) {
+	if attempts <= 1 {
+		p.contention = 0
+	} else if p.contention > 0 {
+		p.contention--
+	}
+}
+
+// retryAfter is called after a failed attempt that will be retried. On a
+// rejection it raises the estimate and waits; it returns an error only if
+// ctx ends while waiting.
+func (p *Proposer) retryAfter(ctx context.Context, err error) error {
+	if !errors.Is(err, ErrRejected) {
+		return nil
+	}
+	if p.backoffCeiling() < backoffMax {
+		p.contention++
+	}
+	ceiling := p.backoffCeiling()
+	wait := ceiling/2 + time.Duration(rand.Int63n(int64(ceiling/2)+1))
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return receiveFailure(ctx.Err())
+	}
+}
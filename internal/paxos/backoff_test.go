@@ -0,0 +1,61 @@
+package paxos
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackoffTracksContention(t *testing.T) {
+	p := newTestProposer(t, newTestNet(t, 3), "p1")
+	if got := p.Backoff(); got != 0 {
+		t.Fatalf("fresh proposer backs off %v", got)
+	}
+	// A cancelled context makes retryAfter count the rejection without
+	// sleeping through it.
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+	rejected := &ProposeError{Reason: LowerProposalNumber, Err: ErrRejected}
+	want := []time.Duration{5, 10, 20, 40, 80, 160, 320, 500, 500}
+	for i, w := range want {
+		p.retryAfter(done, rejected)
+		if got := p.Backoff(); got != w*time.Millisecond {
+			t.Fatalf("after %d rejections Backoff = %v, want %v", i+1, got, w*time.Millisecond)
+		}
+	}
+	p.retryAfter(done, &ProposeError{Reason: Timeout, Err: ErrTimeout})
+	if got := p.Backoff(); got != backoffMax {
+		t.Fatalf("a timeout moved Backoff to %v", got)
+	}
+
+	p.observeSuccess(3)
+	if got := p.Backoff(); got != 320*time.Millisecond {
+		t.Fatalf("after a success with retries Backoff = %v, want it halved to 320ms", got)
+	}
+	p.observeSuccess(1)
+	if got := p.Backoff(); got != 0 {
+		t.Fatalf("after a clean success Backoff = %v, want 0", got)
+	}
+}
+
+func TestBackoffFollowsProposals(t *testing.T) {
+	net := newTestNet(t, 3)
+	for _, id := range net.ids {
+		if err := net.acceptor(id).SetStateForTest(NewProposalNumber(9, "rival"), ProposalNumber{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newTestProposer(t, net, "p1")
+	if _, err := p.ProposeAt(context.Background(), 0, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if p.LastTimings().Retries == 0 {
+		t.Fatal("the first proposal was never rejected")
+	}
+	if _, err := p.ProposeAt(context.Background(), 1, []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Backoff(); got != 0 {
+		t.Fatalf("Backoff = %v after an uncontended proposal, want 0", got)
+	}
+}
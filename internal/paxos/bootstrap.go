@@ -0,0 +1,93 @@
+// =============================================================================
+// BOOTSTRAP - Installing a Known Value on a Fresh Cluster
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// A cluster built to take over an earlier decision (a migration, a restore)
+// shouldn't have to win that decision again under contention. Bootstrap
+// installs it directly, but only on acceptors that have never seen anything:
+//
+//   1. Prepare(BootstrapProposal) to every acceptor. All of them - not a
+//      quorum - must promise, and none may report an accepted value.
+//   2. Accept(BootstrapProposal, value) to a quorum, as in Phase 2.
+//
+// Anything else - a rejected Prepare, an accepted value, a rejected Accept
+// - fails with ErrAlreadyActive and leaves the decision to normal Paxos.
+//
+// =============================================================================
+// WHY IT IS SAFE
+// =============================================================================
+//
+// BootstrapProposal is the lowest non-zero proposal number: round 1 with an
+// empty proposer id sorts below every number a Proposer generates. An
+// acceptor that has promised or accepted anything therefore rejects it, and
+// any real proposal overrides a bootstrap still in progress. Bootstrap is
+// just Paxos with a fixed number plus a stricter Phase 1, so it can fail
+// but it can't choose a second value.
+//
+// =============================================================================
+
+package paxos
+
+import (
+	"context"
+	"errors"
+)
+
+// BootstrapProposal is the fixed proposal number Bootstrap runs under.
+var BootstrapProposal = ProposalNumber{Round: 1}
+
+var ErrAlreadyActive = errors.New("cluster already has acceptor state")
+
+// Bootstrap chooses value for slot 0, provided all of the cluster's
+// acceptors (acceptors counts them) are fresh. See the banner above.
+func (p *Proposer) Bootstrap(ctx context.Context, value []byte, acceptors int) error {
+	if err := p.begin(); err != nil {
+		return err
+	}
+	defer p.end()
+	if err := p.validateValue(value); err != nil {
+		return err
+	}
+	p.slot = 0
+	p.currentProposal = BootstrapProposal
+	p.originalValue = value
+	p.valueToPropose = value
+	p.adoptedFrom = ProposalNumber{}
+	p.promise = nil
+	if err := p.send(Prepare{Slot: 0, ProposalNumber: BootstrapProposal, From: p.id}); err != nil {
+		return err
+	}
+	phaseCtx, cancel := p.phaseContext(ctx)
+	defer cancel()
+	promised := make(map[string]bool)
+	for len(promised) < acceptors {
+		msg, err := p.receive(phaseCtx, promised)
+		if err != nil {
+			return p.phaseFailure(ctx, err)
+		}
+		promise, ok := msg.(Promise)
+		if !ok || promise.Slot != 0 || !promise.ProposalNumber.Equal(BootstrapProposal) {
+			p.stale.Add(1)
+			continue
+		}
+		if !promise.OK || promise.HasAccepted {
+			return ErrAlreadyActive
+		}
+		if promised[promise.From] {
+			continue
+		}
+		promised[promise.From] = true
+		p.promise = append(p.promise, promise)
+	}
+	if err := p.runPhase2(ctx); err != nil {
+		if errors.Is(err, ErrRejected) {
+			return ErrAlreadyActive
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// =============================================================================
+// FAILURE DETECTOR - Which Acceptors Are Worth Waiting For
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Without any notion of liveness a proposer facing a dead majority retries
+// until its context runs out, and a fanout policy happily picks a dead
+// acceptor as one of the few it asks first. A FailureDetector answers "is
+// this node up?" so the proposer can do better:
+//
+//   - Before every attempt, if fewer acceptors are alive than the phase
+//     quorum needs, the proposal fails at once with ErrNoQuorum instead of
+//     timing out attempt after attempt.
+//   - When only some acceptors are contacted (WithFanout), live ones are
+//     contacted first.
+//
+// Both need the acceptor list from WithAcceptors or WithFanout; with plain
+// broadcast the proposer doesn't know who the acceptors are.
+//
+// =============================================================================
+// DETECTORS ARE ONLY HINTS
+// =============================================================================
+//
+// In an asynchronous network a slow node and a dead one look the same, so a
+// detector will sometimes be wrong. Paxos safety never depends on it: a
+// wrong answer can only make a proposal give up early or ask the wrong
+// acceptors first. The node package has a heartbeat-based implementation.
+//
+// =============================================================================
+
+package paxos
+
+type FailureDetector interface {
+	Alive(nodeID string) bool
+	Suspects() []string
+}
+
+// WithFailureDetector sets the detector the proposer consults; see the
+// banner above.
+func WithFailureDetector(fd FailureDetector) ProposerOption {
+	return func(p *Proposer) {
+		p.detector = fd
+	}
+}
+
+// SetFailureDetector replaces the detector after construction. nil turns
+// the checks off.
+func (p *Proposer) SetFailureDetector(fd FailureDetector) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.detector = fd
+}
+
+// quorumReachable reports whether enough acceptors are believed alive for
+// quorum. It says yes whenever it can't tell.
+func (p *Proposer) quorumReachable(quorum int) bool {
+	if p.detector == nil || len(p.acceptors) == 0 {
+		return true
+	}
+	alive := 0
+	for _, id := range p.acceptors {
+		if p.detector.Alive(id) {
+			alive++
+		}
+	}
+	return alive >= quorum
+}
+
+// liveFirst returns the acceptor list with acceptors believed alive first,
+// otherwise in configured order.
+func (p *Proposer) liveFirst() []string {
+	if p.detector == nil {
+		return p.acceptors
+	}
+	ordered := make([]string, 0, len(p.acceptors))
+	var suspected []string
+	for _, id := range p.acceptors {
+		if p.detector.Alive(id) {
+			ordered = append(ordered, id)
+		} else {
+			suspected = append(suspected, id)
+		}
+	}
+	return append(ordered, suspected...)
+}
+
+func (p *Proposer) unreachable() error {
+	return &ProposeError{Reason: QuorumNotReached, Err: ErrNoQuorum}
+}
@@ -0,0 +1,64 @@
+package paxos
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// stubDetector reports the listed nodes dead and everyone else alive.
+type stubDetector map[string]bool
+
+func (d stubDetector) Alive(id string) bool { return !d[id] }
+
+func (d stubDetector) Suspects() []string {
+	var ids []string
+	for id := range d {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func newDetectedProposer(t *testing.T, net *testNet, dead stubDetector) (*Proposer, *countingTransport) {
+	t.Helper()
+	tr := &countingTransport{testTransport: net.transport(), sent: make(map[string]map[string]bool)}
+	p, err := NewProposer("p1", 3, tr, WithFanout(net.ids, FanoutPolicy{EscalateAfter: time.Second}), WithFailureDetector(dead))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, tr
+}
+
+func TestDetectorSteersFanoutAroundDeadAcceptor(t *testing.T) {
+	net := newTestNet(t, 5)
+	net.setDown("a0", true)
+	p, tr := newDetectedProposer(t, net, stubDetector{"a0": true})
+	start := time.Now()
+	if _, err := p.Propose([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	// Counting on a0 would have meant waiting out EscalateAfter.
+	if took := time.Since(start); took >= time.Second {
+		t.Fatalf("Propose took %v", took)
+	}
+	for _, typ := range []string{"prepare", "accept"} {
+		if got := tr.contacted(typ); len(got) != 3 || got[0] != "a1" {
+			t.Fatalf("%s went to %v, want a1 to a3", typ, got)
+		}
+	}
+}
+
+func TestDetectorFailsFastWithoutLiveQuorum(t *testing.T) {
+	net := newTestNet(t, 5)
+	p, tr := newDetectedProposer(t, net, stubDetector{"a0": true, "a1": true, "a2": true})
+	if _, err := p.Propose([]byte("x")); !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("err = %v, want ErrNoQuorum", err)
+	}
+	if got := tr.contacted("prepare"); len(got) != 0 {
+		t.Fatalf("sent Prepare to %v with no live quorum", got)
+	}
+	p.SetFailureDetector(nil)
+	if _, err := p.Propose([]byte("x")); err != nil {
+		t.Fatalf("Propose with the detector off: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package paxos
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingTransport records which acceptors each phase was sent to.
+type countingTransport struct {
+	*testTransport
+	mu   sync.Mutex
+	sent map[string]map[string]bool
+}
+
+func (c *countingTransport) Send(to string, msg interface{}) error {
+	c.mu.Lock()
+	typ := "accept"
+	if _, ok := msg.(Prepare); ok {
+		typ = "prepare"
+	}
+	if c.sent[typ] == nil {
+		c.sent[typ] = make(map[string]bool)
+	}
+	c.sent[typ][to] = true
+	c.mu.Unlock()
+	return c.testTransport.Send(to, msg)
+}
+
+func (c *countingTransport) contacted(typ string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ids []string
+	for id := range c.sent[typ] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func newFanoutProposer(t *testing.T, net *testNet) (*Proposer, *countingTransport) {
+	t.Helper()
+	tr := &countingTransport{testTransport: net.transport(), sent: make(map[string]map[string]bool)}
+	p, err := NewProposer("p1", 4, tr, WithFanout(net.ids, FanoutPolicy{Margin: 1, EscalateAfter: 20 * time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, tr
+}
+
+func TestFanoutContactsQuorumPlusMargin(t *testing.T) {
+	net := newTestNet(t, 7)
+	p, tr := newFanoutProposer(t, net)
+	if _, err := p.Propose([]byte("A")); err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range []string{"prepare", "accept"} {
+		if got := tr.contacted(typ); len(got) != 5 || got[4] != "a4" {
+			t.Fatalf("%s went to %v, want a0 to a4", typ, got)
+		}
+	}
+}
+
+func TestFanoutEscalatesWhenSubsetIsSilent(t *testing.T) {
+	net := newTestNet(t, 7)
+	net.setDown("a1", true)
+	net.setDown("a2", true)
+	p, tr := newFanoutProposer(t, net)
+	if _, err := p.Propose([]byte("A")); err != nil {
+		t.Fatal(err)
+	}
+	if got := tr.contacted("prepare"); len(got) != 7 {
+		t.Fatalf("prepare went to %v, want every acceptor after escalating", got)
+	}
+	if _, accepted, _ := net.acceptor("a6").GetState(); accepted.IsZero() {
+		t.Fatal("a6 never accepted: the proposer did not escalate to it")
+	}
+}
@@ -0,0 +1,130 @@
+package paxos
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"quorum/internal/storage"
+)
+
+// testNet wires proposers straight to in-process acceptors: a message is
+// handled the moment it is sent and the reply lands in the sender's inbox.
+type testNet struct {
+	mu        sync.Mutex
+	ids       []string
+	acceptors map[string]*Acceptor
+	down      map[string]bool
+}
+
+func newTestNet(t testing.TB, n int) *testNet {
+	t.Helper()
+	net := &testNet{acceptors: make(map[string]*Acceptor), down: make(map[string]bool)}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("a%d", i)
+		net.ids = append(net.ids, id)
+		net.acceptors[id] = NewAcceptor(id, storage.NewMemoryStorage())
+	}
+	return net
+}
+
+func (n *testNet) setDown(id string, down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.down[id] = down
+}
+
+func (n *testNet) acceptor(id string) *Acceptor {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.acceptors[id]
+}
+
+func (n *testNet) deliver(to string, msg interface{}) (interface{}, bool) {
+	n.mu.Lock()
+	a, down := n.acceptors[to], n.down[to]
+	n.mu.Unlock()
+	if a == nil || down {
+		return nil, false
+	}
+	switch m := msg.(type) {
+	case Prepare:
+		return a.HandlePrepare(m), true
+	case Accept:
+		return a.HandleAccept(m), true
+	case MultiPrepare:
+		return a.HandleMultiPrepare(m), true
+	}
+	return nil, false
+}
+
+type testTransport struct {
+	net   *testNet
+	inbox chan interface{}
+}
+
+func (n *testNet) transport() *testTransport {
+	return &testTransport{net: n, inbox: make(chan interface{}, 4096)}
+}
+
+func (t *testTransport) Broadcast(msg interface{}) error {
+	for _, id := range t.net.ids {
+		t.Send(id, msg)
+	}
+	return nil
+}
+
+func (t *testTransport) Send(to string, msg interface{}) error {
+	if reply, ok := t.net.deliver(to, msg); ok {
+		t.inbox <- reply
+	}
+	return nil
+}
+
+func (t *testTransport) Receive() (interface{}, error) {
+	return <-t.inbox, nil
+}
+
+func (t *testTransport) ReceiveTimeout(d time.Duration) (interface{}, error) {
+	if d <= 0 {
+		select {
+		case msg := <-t.inbox:
+			return msg, nil
+		default:
+			return nil, ErrTimeout
+		}
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case msg := <-t.inbox:
+		return msg, nil
+	case <-timer.C:
+		return nil, ErrTimeout
+	}
+}
+
+func newTestProposer(t testing.TB, net *testNet, id string, opts ...ProposerOption) *Proposer {
+	t.Helper()
+	p, err := NewProposer(id, len(net.ids)/2+1, net.transport(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// drainingTransport is a testTransport whose inbox holds only replies, so
+// the proposer may drain it.
+type drainingTransport struct {
+	*testTransport
+}
+
+func (t drainingTransport) TryReceive() (interface{}, bool) {
+	select {
+	case msg := <-t.inbox:
+		return msg, true
+	default:
+		return nil, false
+	}
+}
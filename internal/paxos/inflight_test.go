@@ -0,0 +1,133 @@
+package paxos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type proposeResult struct {
+	value []byte
+	err   error
+}
+
+// stalledProposal starts ProposeAt for slot against a net with every
+// acceptor down, so the round keeps retransmitting until they come back.
+func stalledProposal(net *testNet, p *Proposer, ctx context.Context, slot int64, value string) chan proposeResult {
+	for _, id := range net.ids {
+		net.setDown(id, true)
+	}
+	done := make(chan proposeResult, 1)
+	go func() {
+		v, err := p.ProposeAt(ctx, slot, []byte(value))
+		done <- proposeResult{v, err}
+	}()
+	return done
+}
+
+func TestSingleFlightRejectsSecondProposal(t *testing.T) {
+	net := newTestNet(t, 3)
+	p := newTestProposer(t, net, "p1", WithSingleFlight(), WithAcceptors(net.ids), WithRetransmit(5*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	first := stalledProposal(net, p, ctx, 0, "a")
+	for !p.inFlight.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if _, err := p.ProposeAt(ctx, 1, []byte("b")); !errors.Is(err, ErrProposalInFlight) {
+		t.Fatalf("second proposal: err = %v, want ErrProposalInFlight", err)
+	}
+	if waited := time.Since(start); waited > 50*time.Millisecond {
+		t.Fatalf("second proposal waited %v before failing", waited)
+	}
+
+	for _, id := range net.ids {
+		net.setDown(id, false)
+	}
+	if r := <-first; r.err != nil || string(r.value) != "a" {
+		t.Fatalf("first proposal = %q, %v", r.value, r.err)
+	}
+	if _, err := p.ProposeAt(ctx, 1, []byte("b")); err != nil {
+		t.Fatalf("proposal after the first finished: %v", err)
+	}
+}
+
+func TestConcurrentProposalsQueueByDefault(t *testing.T) {
+	net := newTestNet(t, 3)
+	p := newTestProposer(t, net, "p1", WithAcceptors(net.ids), WithRetransmit(5*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	first := stalledProposal(net, p, ctx, 0, "a")
+	second := make(chan proposeResult, 1)
+	go func() {
+		v, err := p.ProposeAt(ctx, 1, []byte("b"))
+		second <- proposeResult{v, err}
+	}()
+
+	select {
+	case r := <-second:
+		t.Fatalf("second proposal returned %q, %v while the first was stalled", r.value, r.err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	for _, id := range net.ids {
+		net.setDown(id, false)
+	}
+	for i, done := range []chan proposeResult{first, second} {
+		want := []string{"a", "b"}[i]
+		if r := <-done; r.err != nil || string(r.value) != want {
+			t.Fatalf("proposal for slot %d = %q, %v, want %s", i, r.value, r.err, want)
+		}
+	}
+}
+
+func TestQueueOfTwoBlocksThirdProposal(t *testing.T) {
+	net := newTestNet(t, 3)
+	p := newTestProposer(t, net, "p1", WithMaxQueuedProposals(2), WithAcceptors(net.ids), WithRetransmit(5*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	first := stalledProposal(net, p, ctx, 0, "a")
+	second := make(chan proposeResult, 1)
+	go func() {
+		v, err := p.ProposeAt(ctx, 1, []byte("b"))
+		second <- proposeResult{v, err}
+	}()
+	for p.QueuedProposals() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A third proposal can't join the queue while both are waiting.
+	short, cancelShort := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancelShort()
+	if _, err := p.ProposeAt(short, 2, []byte("c")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("third proposal with a full queue: err = %v, want it to wait out its context", err)
+	}
+	third := make(chan proposeResult, 1)
+	go func() {
+		v, err := p.ProposeAt(ctx, 2, []byte("c"))
+		third <- proposeResult{v, err}
+	}()
+	select {
+	case r := <-third:
+		t.Fatalf("third proposal returned %q, %v with the queue full", r.value, r.err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if got := p.QueuedProposals(); got != 2 {
+		t.Fatalf("QueuedProposals = %d, want the queue of 2", got)
+	}
+
+	for _, id := range net.ids {
+		net.setDown(id, false)
+	}
+	for i, done := range []chan proposeResult{first, second, third} {
+		want := []string{"a", "b", "c"}[i]
+		if r := <-done; r.err != nil || string(r.value) != want {
+			t.Fatalf("proposal for slot %d = %q, %v, want %s", i, r.value, r.err, want)
+		}
+	}
+	if got := p.QueuedProposals(); got != 0 {
+		t.Fatalf("QueuedProposals = %d after every proposal committed", got)
+	}
+}
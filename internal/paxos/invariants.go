@@ -0,0 +1,114 @@
+// =============================================================================
+// ACCEPTOR INVARIANT CHECKER - Harness for Randomized Testing
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// A thin wrapper around an Acceptor that re-checks the acceptor rules after
+// every HandlePrepare/HandleAccept. It is meant to sit under a fuzzer or a
+// randomized driver:
+//
+//   c := NewAcceptorChecker(NewAcceptor("a", storage.NewMemoryStorage()))
+//   for _, step := range randomSteps {
+//       if _, err := c.Accept(step); err != nil {
+//           t.Fatal(err)
+//       }
+//   }
+//
+// The checker keeps its own copy of the last observed state, so it catches
+// violations no matter which code path inside the acceptor caused them.
+//
+// =============================================================================
+// WHAT IS CHECKED
+// =============================================================================
+//
+// 1. highestPromised never decreases.
+//
+// 2. An Accept below the highestPromised observed BEFORE the call is always
+//    rejected (RULE 1 in acceptor.go).
+//
+// 3. acceptedProposal never decreases, and (acceptedProposal, acceptedValue)
+//    only changes as the direct result of an Accept that returned OK.
+//
+// A rejection that reports the wrong number in the rejection field is not
+// a safety bug by itself, but it breaks rule 1 the moment a proposer trusts
+// it. Those are the bugs this harness is for.
+//
+// =============================================================================
+
+package paxos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var ErrInvariantViolated = errors.New("acceptor invariant violated")
+
+type AcceptorChecker struct {
+	acceptor *Acceptor
+	promised ProposalNumber
+	accepted ProposalNumber
+	value    []byte
+}
+
+func NewAcceptorChecker(a *Acceptor) *AcceptorChecker {
+	promised, accepted, value := a.GetState()
+	return &AcceptorChecker{
+		acceptor: a,
+		promised: promised,
+		accepted: accepted,
+		value:    value,
+	}
+}
+
+func (c *AcceptorChecker) Prepare(msg Prepare) (Promise, error) {
+	resp := c.acceptor.HandlePrepare(msg)
+	return resp, c.check()
+}
+
+func (c *AcceptorChecker) Accept(msg Accept) (Accepted, error) {
+	before := c.promised
+	resp := c.acceptor.HandleAccept(msg)
+	if resp.OK && msg.ProposalNumber.LessThan(before) {
+		return resp, fmt.Errorf("%w: accepted %s below promised %s",
+			ErrInvariantViolated, msg.ProposalNumber, before)
+	}
+	if resp.OK {
+		_, accepted, value := c.acceptor.GetState()
+		if !accepted.Equal(msg.ProposalNumber) || !bytes.Equal(value, msg.Value) {
+			return resp, fmt.Errorf("%w: accepted %s but stored %s",
+				ErrInvariantViolated, msg.ProposalNumber, accepted)
+		}
+		if accepted.LessThan(c.accepted) {
+			return resp, fmt.Errorf("%w: acceptedProposal went from %s to %s",
+				ErrInvariantViolated, c.accepted, accepted)
+		}
+		c.accepted = accepted
+		c.value = value
+	}
+	return resp, c.check()
+}
+
+func (c *AcceptorChecker) check() error {
+	promised, accepted, value := c.acceptor.GetState()
+	if promised.LessThan(c.promised) {
+		return fmt.Errorf("%w: highestPromised went from %s to %s",
+			ErrInvariantViolated, c.promised, promised)
+	}
+	if accepted.LessThan(c.accepted) {
+		return fmt.Errorf("%w: acceptedProposal went from %s to %s",
+			ErrInvariantViolated, c.accepted, accepted)
+	}
+	if !accepted.Equal(c.accepted) || !bytes.Equal(value, c.value) {
+		return fmt.Errorf("%w: accepted state changed to %s without an OK accept",
+			ErrInvariantViolated, accepted)
+	}
+	c.promised = promised
+	c.accepted = accepted
+	c.value = value
+	return nil
+}
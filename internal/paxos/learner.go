@@ -179,6 +179,17 @@
 //
 // For learning: Start with Option 1 (stringify). Optimize later.
 //
+// This learner uses Option 3 without its con. The key holds the SHA-256 of
+// the value, and every key maps to the distinct full values seen under it;
+// an Accepted only counts towards a group whose value is byte-for-byte
+// equal. A collision costs one extra comparison, never a wrong choice.
+//
+// Because equality is checked anyway, the key function only has to be
+// cheap and spread values out. SetValueKey swaps SHA256ValueKey for
+// PrefixValueKey, which copies the first 32 bytes: free for small values,
+// and still correct - just more comparisons - for long values that share a
+// prefix.
+//
 // =============================================================================
 // CONSISTENCY CHECK
 // =============================================================================
@@ -255,11 +266,53 @@
 // Gaps in the log indicate slots where the value isn't known yet.
 // The learner can query those slots specifically.
 //
+// This learner keeps one slotLearner per slot, keyed by the Slot field on
+// Accepted/Learn. Single-decree callers never set Slot, so everything they
+// do lands in slot 0 and GetChosenValue/WaitForChosen keep meaning "the"
+// chosen value.
+//
+// SetApplyFunc turns the per-slot results into an ordered log: values are
+// handed to the callback in slot order, and a slot chosen early waits until
+// every slot below it is chosen.
+//
+// Reset() clears everything the learner has accumulated so the same instance
+// can observe a fresh round. ForgetSlot does the same for one slot, so a
+// caller can drop slots once they have been applied and completed rounds
+// don't leak.
+//
+// BOUNDED TRACKING: a slot's Accepted records are dropped as soon as it is
+// chosen; only the chosen value stays. Slots still collecting Accepteds are
+// kept in an LRU capped at SetMaxTrackedSlots (defaultMaxTrackedSlots); the
+// least recently touched one is forgotten when the cap is exceeded. That
+// only delays learning it - a later Learn or a fresh round of Accepteds
+// still chooses it. TrackedSlots reports the current count.
+//
+// REPLAYS AND CONFLICTS: once a slot is chosen, Accepted and Learn messages
+// for it change nothing. A Learn repeating the chosen value is a normal
+// retransmission. A Learn carrying a different value means some node
+// believes something else was chosen - a safety violation somewhere - so
+// it is reported through SetOnConflict, and the chosen value stays.
+//
+// MEMBERSHIP: SetMembers replaces the fixed quorum with a member list. The
+// quorum becomes a majority of the list, Accepted from non-members is
+// ignored, and votes already collected from nodes that have since left no
+// longer count. A slot that a shrunken quorum would now choose is chosen
+// by the next Accepted or Learn for it. Without SetMembers every sender
+// counts, as before.
+//
 // =============================================================================
 
 package paxos
 
-import "sync"
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
 
 type AcceptedRecord struct {
 	proposalNumber ProposalNumber
@@ -267,86 +320,558 @@ type AcceptedRecord struct {
 }
 
 type AcceptedKey struct {
-	ProposalNumber ProposalNumber
-	Value          string 
+	Round      int64
+	ProposerID string
+	ValueHash  [32]byte
+}
+
+// ValueKeyFunc reduces a value to the ValueHash of its AcceptedKey. Equal
+// values must give equal results; unequal ones may collide.
+type ValueKeyFunc func(value []byte) [32]byte
+
+// SHA256ValueKey is the default ValueKeyFunc.
+func SHA256ValueKey(value []byte) [32]byte {
+	return sha256.Sum256(value)
+}
+
+// PrefixValueKey uses the first 32 bytes of the value, zero-padded. It is
+// cheaper than hashing and collision-free for values of up to 32 bytes.
+func PrefixValueKey(value []byte) [32]byte {
+	var key [32]byte
+	copy(key[:], value)
+	return key
+}
+
+// acceptedGroup is one (proposal, value) pair and the acceptors that sent it.
+type acceptedGroup struct {
+	value []byte
+	from  map[string]bool
+}
+
+type slotLearner struct {
+	accepted       map[AcceptedKey][]*acceptedGroup
+	chosenValue    []byte
+	chosenProposal ProposalNumber
+	isChosen       bool
+	// done is closed when the slot is chosen, waking AwaitChosen.
+	done chan struct{}
+}
+
+func newSlotLearner() *slotLearner {
+	return &slotLearner{
+		accepted: make(map[AcceptedKey][]*acceptedGroup),
+		done:     make(chan struct{}),
+	}
 }
 
 type Learner struct {
 	id string
-	quorumSize int
-	accepted map[AcceptedKey]map[string]bool
-	chosenValue []byte
-	chosenProposal ProposalNumber
-	isChosen bool
+	quorumSize atomic.Int64
+	slots map[int64]*slotLearner
 	mu sync.Mutex
 	chosenChan chan []byte
+	applyFn func(slot int64, value []byte)
+	nextApply int64
+	chosenHook func(slot int64, proposal ProposalNumber, value []byte)
+	subscribers map[*subscriber]bool
+	tracked *list.List
+	trackedAt map[int64]*list.Element
+	maxTracked int
+	valueKey ValueKeyFunc
+	onConflict func(ConflictEvent)
+	members atomic.Pointer[map[string]bool]
+}
+
+// ConflictEvent reports a Learn that disagreed with the value already chosen
+// for its slot.
+type ConflictEvent struct {
+	Slot        int64
+	Chosen      []byte
+	Conflicting []byte
+	From        string
+}
+
+const defaultMaxTrackedSlots = 4096
+
+type ChosenEvent struct {
+	Slot  int64
+	Value []byte
+}
+
+const defaultSubscriberBuffer = 64
+
+type subscriber struct {
+	ch       chan ChosenEvent
+	next     int64
+	blocking bool
+}
+
+type SubscribeOption func(*subscriber)
+
+// WithSubscriberBuffer sets the subscriber's channel capacity.
+func WithSubscriberBuffer(n int) SubscribeOption {
+	return func(s *subscriber) {
+		s.ch = make(chan ChosenEvent, n)
+	}
+}
+
+// WithBlockingDelivery makes the learner wait for a full subscriber instead
+// of dropping the event. The learner is locked while it waits, so a stalled
+// subscriber stalls learning; only use it for consumers that always drain.
+func WithBlockingDelivery() SubscribeOption {
+	return func(s *subscriber) {
+		s.blocking = true
+	}
 }
 
-func NewLearner(id string, quorumSize int) *Learner {
-	return &Learner{
+func NewLearner(id string, quorumSize int) (*Learner, error) {
+	if err := ValidateQuorum(quorumSize, 0); err != nil {
+		return nil, err
+	}
+	l := &Learner{
 		id: id,
-		quorumSize: quorumSize,
-		accepted: make(map[AcceptedKey]map[string]bool),
-		chosenValue: nil,
-		chosenProposal: ProposalNumber{},
-		isChosen: false,
+		slots: make(map[int64]*slotLearner),
+		subscribers: make(map[*subscriber]bool),
+		tracked:     list.New(),
+		trackedAt:   make(map[int64]*list.Element),
+		maxTracked:  defaultMaxTrackedSlots,
+		valueKey:    SHA256ValueKey,
 		mu:         sync.Mutex{},
 		chosenChan: make(chan []byte, 1),
 	}
+	l.quorumSize.Store(int64(quorumSize))
+	return l, nil
+}
+
+// SetQuorumSize changes how many matching Accepted messages choose a value.
+// Unlike most learner methods it doesn't take the lock, so a chosen hook
+// may call it when a membership change is learned.
+func (l *Learner) SetQuorumSize(quorumSize int) error {
+	if err := ValidateQuorum(quorumSize, 0); err != nil {
+		return err
+	}
+	l.quorumSize.Store(int64(quorumSize))
+	return nil
+}
+
+// SetMembers makes only members' Accepted messages count, and sets the
+// quorum to a majority of them; see MEMBERSHIP above. Like SetQuorumSize
+// it doesn't take the lock.
+func (l *Learner) SetMembers(members []string) error {
+	if len(members) == 0 {
+		return fmt.Errorf("%w: no members", ErrInvalidQuorum)
+	}
+	set := make(map[string]bool, len(members))
+	for _, id := range members {
+		set[id] = true
+	}
+	quorumSize := len(set)/2 + 1
+	if err := ValidateQuorum(quorumSize, len(set)); err != nil {
+		return err
+	}
+	l.members.Store(&set)
+	l.quorumSize.Store(int64(quorumSize))
+	return nil
+}
+
+// votes counts the senders in from that are current members.
+func (l *Learner) votes(from map[string]bool) int {
+	members := l.members.Load()
+	if members == nil {
+		return len(from)
+	}
+	n := 0
+	for id := range from {
+		if (*members)[id] {
+			n++
+		}
+	}
+	return n
+}
+
+func (l *Learner) isMember(id string) bool {
+	members := l.members.Load()
+	return members == nil || (*members)[id]
+}
+
+// SetValueKey changes how values are reduced to map keys; see
+// DISTINGUISHING ACCEPTED MESSAGES above. Call it before the first Accepted
+// arrives: groups already collected stay under their old keys.
+func (l *Learner) SetValueKey(fn ValueKeyFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if fn == nil {
+		fn = SHA256ValueKey
+	}
+	l.valueKey = fn
+}
+
+func (l *Learner) slot(slot int64) *slotLearner {
+	s, ok := l.slots[slot]
+	if !ok {
+		s = newSlotLearner()
+		l.slots[slot] = s
+	}
+	return s
 }
 
 func (l *Learner) HandleAccepted(msg Accepted) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if l.isChosen {
+	s := l.slot(msg.Slot)
+	if s.isChosen || !l.isMember(msg.From) {
 		return
 	}
+	l.track(msg.Slot)
 
 	key := AcceptedKey{
-		ProposalNumber: msg.ProposalNumber,
-		Value:          string(msg.Value),
+		Round:      msg.ProposalNumber.Round,
+		ProposerID: msg.ProposalNumber.ProposerID,
+		ValueHash:  l.valueKey(msg.Value),
 	}
 
-	if _, ok := l.accepted[key]; !ok {
-		l.accepted[key] = make(map[string]bool)
+	var group *acceptedGroup
+	for _, g := range s.accepted[key] {
+		if bytes.Equal(g.value, msg.Value) {
+			group = g
+			break
+		}
+	}
+	if group == nil {
+		group = &acceptedGroup{value: msg.Value, from: make(map[string]bool)}
+		s.accepted[key] = append(s.accepted[key], group)
 	}
 
-	l.accepted[key][msg.From] = true
+	group.from[msg.From] = true
 
-	if len(l.accepted[key]) >= l.quorumSize {
-		l.chosenValue = msg.Value
-		l.chosenProposal = msg.ProposalNumber
-		l.isChosen = true
+	if l.votes(group.from) >= int(l.quorumSize.Load()) {
+		l.choose(msg.Slot, s, msg.ProposalNumber, msg.Value)
+	}
+}
+
+func (l *Learner) HandleLearn(msg Learn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := l.slot(msg.Slot)
+	if s.isChosen {
+		if !bytes.Equal(s.chosenValue, msg.Value) && l.onConflict != nil {
+			l.onConflict(ConflictEvent{
+				Slot:        msg.Slot,
+				Chosen:      s.chosenValue,
+				Conflicting: msg.Value,
+				From:        msg.From,
+			})
+		}
+		return
+	}
+	l.choose(msg.Slot, s, msg.ProposalNumber, msg.Value)
+}
+
+// choose must be called with l.mu held.
+func (l *Learner) choose(slot int64, s *slotLearner, proposal ProposalNumber, value []byte) {
+	s.chosenValue = value
+	s.chosenProposal = proposal
+	s.isChosen = true
+	s.accepted = nil
+	l.untrack(slot)
+	close(s.done)
+	if l.chosenHook != nil {
+		l.chosenHook(slot, proposal, value)
+	}
+	if slot == 0 {
 		select {
-		case l.chosenChan <- msg.Value:
+		case l.chosenChan <- value:
 		default:
 		}
 	}
+	l.applyReady()
+	l.notifySubscribers()
 }
 
-func (l *Learner) HandleLearn(msg Learn) {
+// track must be called with l.mu held. It marks slot as the most recently
+// active unchosen slot and evicts the least recent one past the cap.
+func (l *Learner) track(slot int64) {
+	if e, ok := l.trackedAt[slot]; ok {
+		l.tracked.MoveToFront(e)
+		return
+	}
+	l.trackedAt[slot] = l.tracked.PushFront(slot)
+	for l.maxTracked > 0 && l.tracked.Len() > l.maxTracked {
+		l.evict(l.tracked.Back().Value.(int64))
+	}
+}
+
+// untrack must be called with l.mu held.
+func (l *Learner) untrack(slot int64) {
+	if e, ok := l.trackedAt[slot]; ok {
+		l.tracked.Remove(e)
+		delete(l.trackedAt, slot)
+	}
+}
+
+// evict must be called with l.mu held. Waiters in AwaitChosen are woken so
+// they re-register on a fresh slotLearner.
+func (l *Learner) evict(slot int64) {
+	l.untrack(slot)
+	if s, ok := l.slots[slot]; ok && !s.isChosen {
+		delete(l.slots, slot)
+		close(s.done)
+	}
+}
+
+// SetMaxTrackedSlots caps how many unchosen slots keep Accepted records.
+// n <= 0 removes the cap.
+func (l *Learner) SetMaxTrackedSlots(n int) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.maxTracked = n
+	for n > 0 && l.tracked.Len() > n {
+		l.evict(l.tracked.Back().Value.(int64))
+	}
+}
+
+// TrackedSlots returns how many unchosen slots are holding Accepted records.
+func (l *Learner) TrackedSlots() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tracked.Len()
+}
 
-	if l.isChosen {
+// applyReady must be called with l.mu held. It hands every chosen slot
+// starting at nextApply to applyFn and stops at the first gap, so slots
+// chosen out of order are buffered until the log is contiguous. NoOp slots
+// advance nextApply without reaching applyFn.
+func (l *Learner) applyReady() {
+	if l.applyFn == nil {
 		return
 	}
+	for {
+		s, ok := l.slots[l.nextApply]
+		if !ok || !s.isChosen {
+			return
+		}
+		if !IsNoOp(s.chosenValue) {
+			l.applyFn(l.nextApply, s.chosenValue)
+		}
+		l.nextApply++
+	}
+}
 
-	l.chosenValue = msg.Value
-	l.isChosen = true
-	select {
-	case l.chosenChan <- msg.Value:
-	default:
+// notifySubscribers must be called with l.mu held. Like applyReady it walks
+// each subscriber's cursor forward over contiguous chosen slots, so events
+// always arrive in slot order.
+func (l *Learner) notifySubscribers() {
+	for sub := range l.subscribers {
+		for {
+			s, ok := l.slots[sub.next]
+			if !ok || !s.isChosen {
+				break
+			}
+			event := ChosenEvent{Slot: sub.next, Value: s.chosenValue}
+			if sub.blocking {
+				sub.ch <- event
+			} else {
+				select {
+				case sub.ch <- event:
+				default:
+				}
+			}
+			sub.next++
+		}
+	}
+}
+
+// Subscribe streams chosen values in slot order, starting at the first slot
+// not yet chosen. By default a full channel drops events rather than block
+// the learner; see WithBlockingDelivery. The returned function unsubscribes
+// and closes the channel.
+func (l *Learner) Subscribe(opts ...SubscribeOption) (<-chan ChosenEvent, func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sub := &subscriber{ch: make(chan ChosenEvent, defaultSubscriberBuffer)}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	for {
+		s, ok := l.slots[sub.next]
+		if !ok || !s.isChosen {
+			break
+		}
+		sub.next++
+	}
+	l.subscribers[sub] = true
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			delete(l.subscribers, sub)
+			close(sub.ch)
+		})
+	}
+}
+
+// SetApplyFunc registers fn to receive chosen values strictly in slot order,
+// each slot exactly once. NoOp slots are skipped. fn runs with the learner
+// locked and must not call back into the learner.
+func (l *Learner) SetApplyFunc(fn func(slot int64, value []byte)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.applyFn = fn
+	l.applyReady()
+}
+
+// SetApplyFrom makes the apply function start at slot next instead of 0,
+// for a state machine that has already applied everything before it. It
+// never moves backwards past slots already applied.
+func (l *Learner) SetApplyFrom(next int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if next > l.nextApply {
+		l.nextApply = next
+	}
+	l.applyReady()
+}
+
+// Checkpoint calls fn with the learner locked and the last slot handed to
+// the apply function (-1 if none), so fn sees a state machine that no
+// apply can change underneath it. fn must not call back into the learner.
+func (l *Learner) Checkpoint(fn func(applied int64) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return fn(l.nextApply - 1)
+}
+
+// SetChosenHook registers fn to be called, with the learner locked, the
+// first time each slot is chosen. The node uses it to persist the decision.
+func (l *Learner) SetChosenHook(fn func(slot int64, proposal ProposalNumber, value []byte)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.chosenHook = fn
+}
+
+// SetOnConflict registers fn to be called, with the learner locked, for
+// every Learn whose value differs from the one already chosen for its slot.
+func (l *Learner) SetOnConflict(fn func(ConflictEvent)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onConflict = fn
+}
+
+// Log returns the chosen values of the contiguous prefix of slots starting
+// at 0, stopping at the first slot that isn't chosen yet.
+func (l *Learner) Log() [][]byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out [][]byte
+	for slot := int64(0); ; slot++ {
+		s, ok := l.slots[slot]
+		if !ok || !s.isChosen {
+			return out
+		}
+		out = append(out, s.chosenValue)
 	}
 }
 
 func (l *Learner) GetChosenValue() ([]byte, bool) {
+	return l.GetChosenAt(0)
+}
+
+func (l *Learner) GetChosenAt(slot int64) ([]byte, bool) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	return l.chosenValue, l.isChosen
+	s, ok := l.slots[slot]
+	if !ok {
+		return nil, false
+	}
+	return s.chosenValue, s.isChosen
+}
+
+// ChosenAt reports slot's chosen value, whether or not the slots before it
+// are decided. It is GetChosenAt under the name log consumers look for.
+func (l *Learner) ChosenAt(slot int64) ([]byte, bool) {
+	return l.GetChosenAt(slot)
+}
+
+// HighestChosenSlot is the commit index: the last slot of the contiguous
+// chosen prefix starting at 0, or -1 if slot 0 isn't chosen. Everything up
+// to it can be applied; a gap stops it even if later slots are chosen.
+func (l *Learner) HighestChosenSlot() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot := int64(0)
+	for {
+		s, ok := l.slots[slot]
+		if !ok || !s.isChosen {
+			return slot - 1
+		}
+		slot++
+	}
+}
+
+// AwaitChosen blocks until slot is chosen and returns its value, or returns
+// ctx.Err() if ctx ends first. It wakes on that slot alone, not on every
+// decision.
+func (l *Learner) AwaitChosen(ctx context.Context, slot int64) ([]byte, error) {
+	for {
+		l.mu.Lock()
+		s := l.slot(slot)
+		if s.isChosen {
+			value := s.chosenValue
+			l.mu.Unlock()
+			return value, nil
+		}
+		done := s.done
+		l.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
 }
 
 func (l *Learner) WaitForChosen() []byte {
 	return <-l.chosenChan
 }
+
+func (l *Learner) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.slots {
+		if !s.isChosen {
+			close(s.done)
+		}
+	}
+	l.slots = make(map[int64]*slotLearner)
+	l.tracked.Init()
+	l.trackedAt = make(map[int64]*list.Element)
+	l.nextApply = 0
+	for sub := range l.subscribers {
+		sub.next = 0
+	}
+	select {
+	case <-l.chosenChan:
+	default:
+	}
+}
+
+// ForgetSlot drops everything the learner holds for slot, chosen or not;
+// afterwards the slot reads as unknown. Forgetting a slot the apply
+// function or a subscriber has already passed doesn't replay it if it is
+// learned again.
+func (l *Learner) ForgetSlot(slot int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.slots[slot]
+	if !ok {
+		return
+	}
+	l.untrack(slot)
+	delete(l.slots, slot)
+	if !s.isChosen {
+		close(s.done)
+	}
+}
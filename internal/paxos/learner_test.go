@@ -0,0 +1,342 @@
+package paxos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLearner(t *testing.T) *Learner {
+	t.Helper()
+	l, err := NewLearner("l1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func acceptQuorum(l *Learner, slot int64, round int64, value string) {
+	proposal := ProposalNumber{Round: round, ProposerID: "p1"}
+	for _, from := range []string{"a1", "a2"} {
+		l.HandleAccepted(Accepted{Slot: slot, ProposalNumber: proposal, Value: []byte(value), From: from, OK: true})
+	}
+}
+
+func TestLearnerReset(t *testing.T) {
+	l := newTestLearner(t)
+	acceptQuorum(l, 0, 1, "x")
+	if v, ok := l.GetChosenValue(); !ok || string(v) != "x" {
+		t.Fatalf("before reset: got %q, %v", v, ok)
+	}
+
+	l.Reset()
+	if v, ok := l.GetChosenValue(); ok || v != nil {
+		t.Fatalf("after reset: got %q, %v, want nil, false", v, ok)
+	}
+
+	acceptQuorum(l, 0, 2, "y")
+	if v, ok := l.GetChosenValue(); !ok || string(v) != "y" {
+		t.Fatalf("after a new quorum: got %q, %v", v, ok)
+	}
+}
+
+func TestLearnerForgetSlot(t *testing.T) {
+	l := newTestLearner(t)
+	acceptQuorum(l, 0, 1, "a")
+	acceptQuorum(l, 1, 1, "b")
+
+	l.ForgetSlot(0)
+	if _, ok := l.GetChosenAt(0); ok {
+		t.Fatal("slot 0 still chosen after ForgetSlot")
+	}
+	if v, ok := l.GetChosenAt(1); !ok || string(v) != "b" {
+		t.Fatalf("slot 1: got %q, %v, want it untouched", v, ok)
+	}
+
+	acceptQuorum(l, 0, 2, "c")
+	if v, ok := l.GetChosenAt(0); !ok || string(v) != "c" {
+		t.Fatalf("slot 0 after a new quorum: got %q, %v", v, ok)
+	}
+}
+
+func TestLearnerForgetSlotWakesWaiters(t *testing.T) {
+	l := newTestLearner(t)
+	l.HandleAccepted(Accepted{Slot: 3, ProposalNumber: ProposalNumber{Round: 1, ProposerID: "p1"}, Value: []byte("x"), From: "a1", OK: true})
+	if n := l.TrackedSlots(); n != 1 {
+		t.Fatalf("TrackedSlots = %d, want 1", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	got := make(chan []byte, 1)
+	go func() {
+		v, _ := l.AwaitChosen(ctx, 3)
+		got <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	l.ForgetSlot(3)
+	if n := l.TrackedSlots(); n != 0 {
+		t.Fatalf("TrackedSlots = %d after ForgetSlot, want 0", n)
+	}
+
+	// The waiter re-registers on a fresh slot and still sees the decision.
+	acceptQuorum(l, 3, 2, "y")
+	if v := <-got; string(v) != "y" {
+		t.Fatalf("AwaitChosen = %q, want y", v)
+	}
+}
+
+func nextEvent(t *testing.T, ch <-chan ChosenEvent) ChosenEvent {
+	t.Helper()
+	select {
+	case e, ok := <-ch:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return ChosenEvent{}
+}
+
+func TestLearnerSubscribersGetSlotsInOrder(t *testing.T) {
+	l := newTestLearner(t)
+	first, cancelFirst := l.Subscribe()
+	defer cancelFirst()
+	second, cancelSecond := l.Subscribe()
+	defer cancelSecond()
+
+	// Out of order: slot 2 waits for 0 and 1.
+	acceptQuorum(l, 2, 1, "c")
+	acceptQuorum(l, 0, 1, "a")
+	acceptQuorum(l, 1, 1, "b")
+	for _, ch := range []<-chan ChosenEvent{first, second} {
+		for slot, want := range []string{"a", "b", "c"} {
+			if e := nextEvent(t, ch); e.Slot != int64(slot) || string(e.Value) != want {
+				t.Fatalf("got slot %d %q, want slot %d %q", e.Slot, e.Value, slot, want)
+			}
+		}
+	}
+}
+
+func TestLearnerUnsubscribeClosesChannel(t *testing.T) {
+	l := newTestLearner(t)
+	ch, cancel := l.Subscribe()
+	cancel()
+	cancel()
+	acceptQuorum(l, 0, 1, "a")
+	if _, ok := <-ch; ok {
+		t.Fatal("event delivered after unsubscribing")
+	}
+}
+
+func TestLearnerSlowSubscriberDoesNotBlock(t *testing.T) {
+	l := newTestLearner(t)
+	slow, cancel := l.Subscribe(WithSubscriberBuffer(1))
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		for slot := int64(0); slot < 5; slot++ {
+			acceptQuorum(l, slot, 1, "v")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a full subscriber blocked the learner")
+	}
+	if e := nextEvent(t, slow); e.Slot != 0 {
+		t.Fatalf("kept slot %d, want the first, 0", e.Slot)
+	}
+	if l.HighestChosenSlot() != 4 {
+		t.Fatalf("highest chosen %d, want 4", l.HighestChosenSlot())
+	}
+}
+
+func TestLearnerDropsRecordsOnceChosen(t *testing.T) {
+	l := newTestLearner(t)
+	for slot := int64(0); slot < 1000; slot++ {
+		acceptQuorum(l, slot, 1, "v")
+	}
+	if got := l.TrackedSlots(); got != 0 {
+		t.Fatalf("tracking %d slots after all were chosen, want 0", got)
+	}
+}
+
+func TestLearnerCapsUnchosenSlots(t *testing.T) {
+	l := newTestLearner(t)
+	l.SetMaxTrackedSlots(10)
+	half := func(slot int64) {
+		l.HandleAccepted(Accepted{Slot: slot, ProposalNumber: NewProposalNumber(1, "p1"), Value: []byte("v"), From: "a1", OK: true})
+	}
+	for slot := int64(0); slot < 100; slot++ {
+		half(slot)
+		if got := l.TrackedSlots(); got > 10 {
+			t.Fatalf("tracking %d slots, cap is 10", got)
+		}
+	}
+
+	// Slot 0 was evicted; a full quorum still chooses it.
+	acceptQuorum(l, 0, 1, "v")
+	if _, ok := l.GetChosenAt(0); !ok {
+		t.Fatal("evicted slot 0 could not be chosen again")
+	}
+	l.SetMaxTrackedSlots(3)
+	if got := l.TrackedSlots(); got != 3 {
+		t.Fatalf("tracking %d slots after lowering the cap to 3", got)
+	}
+}
+
+func TestLearnerKeyCollisionKeepsValuesApart(t *testing.T) {
+	// Both values share their first 32 bytes, so PrefixValueKey gives them
+	// the same AcceptedKey.
+	prefix := strings.Repeat("p", 32)
+	x, y := []byte(prefix+"x"), []byte(prefix+"y")
+	if PrefixValueKey(x) != PrefixValueKey(y) {
+		t.Fatal("test values don't collide")
+	}
+	for name, key := range map[string]ValueKeyFunc{
+		"prefix":   PrefixValueKey,
+		"constant": func([]byte) [32]byte { return [32]byte{} },
+	} {
+		l := newTestLearner(t)
+		l.SetValueKey(key)
+		proposal := ProposalNumber{Round: 1, ProposerID: "p1"}
+		l.HandleAccepted(Accepted{Slot: 0, ProposalNumber: proposal, Value: x, From: "a1", OK: true})
+		l.HandleAccepted(Accepted{Slot: 0, ProposalNumber: proposal, Value: y, From: "a2", OK: true})
+		if v, ok := l.GetChosenAt(0); ok {
+			t.Fatalf("%s: chose %q from two acceptors that sent different values", name, v)
+		}
+		l.HandleAccepted(Accepted{Slot: 0, ProposalNumber: proposal, Value: y, From: "a3", OK: true})
+		if v, ok := l.GetChosenAt(0); !ok || !bytes.Equal(v, y) {
+			t.Fatalf("%s: got %q, %v, want the y that a2 and a3 agree on", name, v, ok)
+		}
+	}
+}
+
+// BenchmarkLearnerValueKey times choosing one 64 KiB value per slot under
+// each key scheme.
+func BenchmarkLearnerValueKey(b *testing.B) {
+	value := bytes.Repeat([]byte("v"), 64<<10)
+	for name, key := range map[string]ValueKeyFunc{"sha256": SHA256ValueKey, "prefix": PrefixValueKey} {
+		b.Run(name, func(b *testing.B) {
+			l, err := NewLearner("l1", 2)
+			if err != nil {
+				b.Fatal(err)
+			}
+			l.SetValueKey(key)
+			proposal := ProposalNumber{Round: 1, ProposerID: "p1"}
+			b.SetBytes(int64(len(value)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, from := range []string{"a1", "a2"} {
+					l.HandleAccepted(Accepted{Slot: int64(i), ProposalNumber: proposal, Value: value, From: from, OK: true})
+				}
+			}
+		})
+	}
+}
+
+func TestLearnerReplayedLearnIsHarmless(t *testing.T) {
+	l := newTestLearner(t)
+	var conflicts []ConflictEvent
+	l.SetOnConflict(func(e ConflictEvent) { conflicts = append(conflicts, e) })
+	applied := 0
+	l.SetApplyFunc(func(int64, []byte) { applied++ })
+	learn := Learn{Slot: 0, ProposalNumber: ProposalNumber{Round: 1, ProposerID: "p1"}, Value: []byte("x"), From: "p1"}
+
+	l.HandleLearn(learn)
+	l.HandleLearn(learn)
+	l.HandleAccepted(Accepted{Slot: 0, ProposalNumber: learn.ProposalNumber, Value: []byte("x"), From: "a1", OK: true})
+	if v, ok := l.GetChosenValue(); !ok || string(v) != "x" {
+		t.Fatalf("got %q, %v, want x", v, ok)
+	}
+	if applied != 1 || len(conflicts) != 0 {
+		t.Fatalf("replays applied %d times and raised %v, want 1 and none", applied, conflicts)
+	}
+
+	l.HandleLearn(Learn{Slot: 0, ProposalNumber: ProposalNumber{Round: 2, ProposerID: "p2"}, Value: []byte("y"), From: "p2"})
+	if v, _ := l.GetChosenValue(); string(v) != "x" {
+		t.Fatalf("a conflicting Learn replaced the chosen value with %q", v)
+	}
+	want := ConflictEvent{Slot: 0, Chosen: []byte("x"), Conflicting: []byte("y"), From: "p2"}
+	if len(conflicts) != 1 || conflicts[0].From != want.From || string(conflicts[0].Chosen) != "x" || string(conflicts[0].Conflicting) != "y" {
+		t.Fatalf("conflicts = %+v, want [%+v]", conflicts, want)
+	}
+	if applied != 1 {
+		t.Fatalf("applied %d times, want 1", applied)
+	}
+}
+
+func TestLearnerGrownMembershipNeedsLargerQuorum(t *testing.T) {
+	l := newTestLearner(t)
+	if err := l.SetMembers([]string{"a1", "a2", "a3"}); err != nil {
+		t.Fatal(err)
+	}
+	accepted := func(slot int64, from string) {
+		l.HandleAccepted(Accepted{Slot: slot, ProposalNumber: ProposalNumber{Round: 1, ProposerID: "p1"}, Value: []byte("x"), From: from, OK: true})
+	}
+	accepted(0, "a1")
+	accepted(0, "a2")
+	if _, ok := l.GetChosenAt(0); !ok {
+		t.Fatal("2 of 3 members did not choose slot 0")
+	}
+
+	if err := l.SetMembers([]string{"a1", "a2", "a3", "a4", "a5"}); err != nil {
+		t.Fatal(err)
+	}
+	accepted(1, "a1")
+	accepted(1, "a2")
+	accepted(1, "outsider")
+	if v, ok := l.GetChosenAt(1); ok {
+		t.Fatalf("slot 1 chosen as %q with 2 of 5 members and a non-member", v)
+	}
+	accepted(1, "a5")
+	if v, ok := l.GetChosenAt(1); !ok || string(v) != "x" {
+		t.Fatalf("slot 1 = %q, %v after 3 of 5 members, want x", v, ok)
+	}
+
+	// Votes from nodes that have since left stop counting.
+	accepted(2, "a4")
+	accepted(2, "a5")
+	if err := l.SetMembers([]string{"a1", "a2", "a3"}); err != nil {
+		t.Fatal(err)
+	}
+	accepted(2, "a1")
+	if v, ok := l.GetChosenAt(2); ok {
+		t.Fatalf("slot 2 chosen as %q with one current member's vote", v)
+	}
+	accepted(2, "a2")
+	if _, ok := l.GetChosenAt(2); !ok {
+		t.Fatal("slot 2 not chosen by 2 of the 3 current members")
+	}
+}
+
+func TestLearnerHighestChosenSlotStopsAtGap(t *testing.T) {
+	l := newTestLearner(t)
+	if got := l.HighestChosenSlot(); got != -1 {
+		t.Fatalf("HighestChosenSlot with nothing chosen = %d, want -1", got)
+	}
+	for _, slot := range []int64{0, 1, 2, 4} {
+		acceptQuorum(l, slot, 1, fmt.Sprintf("v%d", slot))
+	}
+	if got := l.HighestChosenSlot(); got != 2 {
+		t.Fatalf("HighestChosenSlot = %d with a gap at 3, want 2", got)
+	}
+	if v, ok := l.ChosenAt(4); !ok || string(v) != "v4" {
+		t.Fatalf("ChosenAt(4) = %q, %v, want v4", v, ok)
+	}
+	if v, ok := l.ChosenAt(3); ok {
+		t.Fatalf("ChosenAt(3) = %q in the gap", v)
+	}
+
+	acceptQuorum(l, 3, 1, "v3")
+	if got := l.HighestChosenSlot(); got != 4 {
+		t.Fatalf("HighestChosenSlot = %d once the gap fills, want 4", got)
+	}
+}
@@ -179,14 +179,16 @@
 // MULTI-PAXOS EXTENSION POINT
 // =============================================================================
 //
-// For Multi-Paxos, add a SlotNumber/Index field to EVERY message:
+// Every message carries a Slot field:
 //
 //   type Prepare struct {
-//       Slot           int64          // NEW: Which log slot this is for
+//       Slot           int64          // Which log slot this is for
 //       ProposalNumber ProposalNumber
 //       From           string
 //   }
 //
+// Single-decree Paxos is just slot 0, the zero value.
+//
 // Each slot runs an independent Paxos instance. The slot number tells
 // nodes which instance this message belongs to.
 //
@@ -194,7 +196,13 @@
 
 package paxos
 
+import (
+	"bytes"
+	"time"
+)
+
 type Prepare struct {
+	Slot int64
 	ProposalNumber ProposalNumber
 	From string
 }
@@ -202,11 +210,20 @@ type Prepare struct {
 func (p Prepare) GetFrom() string { return p.From }
 
 type Promise struct {
+	Slot int64
 	ProposalNumber ProposalNumber
 	AcceptedProposal ProposalNumber
 	AcceptedValue []byte
+	// HasAccepted says whether AcceptedProposal/AcceptedValue report a real
+	// accept. The value may legitimately be empty, so only this field, not
+	// the value, tells "accepted nothing" apart from "accepted []byte{}".
+	HasAccepted bool
+	HighestSeen ProposalNumber
 	From string
 	OK bool
+	// LeaseUntil is set when the acceptor runs with WithLease: until then it
+	// promises no other proposer. See the LEASES section in acceptor.go.
+	LeaseUntil time.Time
 }
 
 func (p Promise) GetFrom() string { return p.From }
@@ -220,6 +237,7 @@ type Reject struct {
 func (r Reject) GetFrom() string { return r.From }
 
 type Accept struct {
+	Slot int64
 	ProposalNumber ProposalNumber
 	Value []byte
 	From string
@@ -228,8 +246,10 @@ type Accept struct {
 func (a Accept) GetFrom() string { return a.From }
 
 type Accepted struct {
+	Slot int64
 	ProposalNumber ProposalNumber
 	Value []byte
+	HighestSeen ProposalNumber
 	From string
 	OK bool
 }
@@ -237,9 +257,62 @@ type Accepted struct {
 func (a Accepted) GetFrom() string { return a.From }
 
 type Learn struct {
+	Slot int64
 	ProposalNumber ProposalNumber
 	Value []byte
 	From string
 }
 
 func (l Learn) GetFrom() string { return l.From }
+
+// MultiPrepare is Phase 1 for every slot in [FromSlot, ToSlot] at once. A
+// stable leader sends it once and can then skip Phase 1 for those slots.
+type MultiPrepare struct {
+	FromSlot int64
+	ToSlot int64
+	ProposalNumber ProposalNumber
+	From string
+}
+
+func (p MultiPrepare) GetFrom() string { return p.From }
+
+type AcceptedEntry struct {
+	ProposalNumber ProposalNumber
+	Value []byte
+}
+
+// MultiPromise answers a MultiPrepare. AcceptedSlots holds every slot in the
+// prepared range where the acceptor has accepted something; slots missing
+// from the map have nothing to adopt.
+type MultiPromise struct {
+	FromSlot int64
+	ToSlot int64
+	ProposalNumber ProposalNumber
+	AcceptedSlots map[int64]AcceptedEntry
+	HighestSeen ProposalNumber
+	From string
+	OK bool
+	LeaseUntil time.Time
+}
+
+func (p MultiPromise) GetFrom() string { return p.From }
+
+// LeaderTransfer asks To to take over leadership now instead of waiting for
+// the current leader to time out. NextSlot is the first slot the old leader
+// hadn't filled, so the new one knows where to start its MultiPrepare.
+type LeaderTransfer struct {
+	NextSlot int64
+	From string
+	To string
+}
+
+func (t LeaderTransfer) GetFrom() string { return t.From }
+
+// NoOp is the value proposed into a log hole that no client value is known
+// for, or by a leader confirming its leadership (Proposer.ProposeNoop). The
+// learner never passes it to an apply function.
+var NoOp = []byte("\x00quorum/noop")
+
+func IsNoOp(value []byte) bool {
+	return bytes.Equal(value, NoOp)
+}
@@ -0,0 +1,59 @@
+package paxos
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProposeNoopFillsSlot(t *testing.T) {
+	net := newTestNet(t, 3)
+	p := newTestProposer(t, net, "p1")
+	if err := p.ProposeNoop(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	accepted := 0
+	for _, id := range net.ids {
+		if _, n, v := net.acceptor(id).GetSlotState(1); !n.IsZero() && IsNoOp(v) {
+			accepted++
+		}
+	}
+	if accepted < 2 {
+		t.Fatalf("%d acceptors accepted the NoOp at slot 1, want a quorum", accepted)
+	}
+	// The slot is decided: a later proposal there adopts the NoOp.
+	chosen, err := newTestProposer(t, net, "p2").ProposeAt(context.Background(), 1, []byte("x"))
+	if err != nil || !IsNoOp(chosen) {
+		t.Fatalf("ProposeAt(1) = %q, %v, want the NoOp", chosen, err)
+	}
+}
+
+func TestProposeNoopKeepsEarlierValue(t *testing.T) {
+	net := newTestNet(t, 3)
+	if _, err := newTestProposer(t, net, "p1").ProposeAt(context.Background(), 1, []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestProposer(t, net, "p2").ProposeNoop(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, v := net.acceptor("a0").GetSlotState(1); string(v) != "x" {
+		t.Fatalf("a0 holds %q at slot 1, want x kept", v)
+	}
+}
+
+func TestApplySkipsChosenNoOp(t *testing.T) {
+	l := newTestLearner(t)
+	var applied []int64
+	l.SetApplyFunc(func(slot int64, value []byte) { applied = append(applied, slot) })
+	acceptQuorum(l, 0, 1, "a")
+	acceptQuorum(l, 1, 1, string(NoOp))
+	acceptQuorum(l, 2, 1, "c")
+	if v, ok := l.GetChosenAt(1); !ok || !IsNoOp(v) {
+		t.Fatalf("slot 1 = %q, %v, want a chosen NoOp", v, ok)
+	}
+	if len(applied) != 2 || applied[0] != 0 || applied[1] != 2 {
+		t.Fatalf("applied slots %v, want [0 2]", applied)
+	}
+	if len(l.Log()) != 3 {
+		t.Fatalf("log has %d entries, want the NoOp to hold its place among 3", len(l.Log()))
+	}
+}
@@ -0,0 +1,142 @@
+// =============================================================================
+// PROPOSAL NUMBER GENERATORS - Pluggable Numbering Schemes
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Paxos only needs proposal numbers to be totally ordered and never reused.
+// How a proposer picks the next one is a policy decision, so it sits behind
+// an interface:
+//
+//   type ProposalNumberGenerator interface {
+//       Next(prev ProposalNumber) ProposalNumber
+//   }
+//
+// prev is the highest number the proposer must beat: its own last number,
+// or, after a rejection, one derived from the HighestSeen it was told
+// about. Next must return something strictly greater than prev.
+//
+// Two generators are provided:
+//
+// - RoundGenerator: Round = prev.Round + 1. This is what the proposer does
+//   when no generator is configured.
+//
+// - HLCGenerator: Round is a hybrid logical clock reading - wall-clock
+//   milliseconds in the high bits, a logical counter in the low 16 bits.
+//   Numbers from different nodes are then roughly ordered by real time,
+//   which cuts collisions between dueling proposers and makes a proposal
+//   number double as a causality timestamp.
+//
+// Either way the ordering itself is still ProposalNumber.LessThan, so the
+// ProposerID tiebreak keeps the order total.
+//
+// =============================================================================
+// HLC LAYOUT
+// =============================================================================
+//
+//   Round = unixMillis << 16 | logical
+//
+// If the clock hasn't moved past prev (same millisecond, clock stepped
+// backwards, or prev came from a node with a faster clock), the generator
+// simply uses prev.Round + 1. Rounds therefore never go backwards even when
+// the wall clock does.
+//
+// =============================================================================
+// ROUND FLOORS
+// =============================================================================
+//
+// A proposer without a RoundAllocator keeps its round in memory and starts
+// again at 1 after a restart, reusing numbers it may already have sent
+// with a different value. WithRoundFloor makes every number it generates
+// at least the floor, whatever the generator:
+//
+//   paxos.NewProposer(id, q, t, paxos.WithRoundFloor(paxos.ClockRoundFloor(time.Now())))
+//
+// ClockRoundFloor uses the HLC layout, so a restart lands above every round
+// the previous run used provided that run issued fewer than 2^16 rounds
+// per millisecond it was alive and the clock hasn't stepped back since.
+// That is a much weaker promise than persisting a high-water mark, which is
+// what RoundAllocator does and what a node should use when it has storage;
+// a floor loaded from such a mark works here too.
+//
+// =============================================================================
+
+package paxos
+
+import (
+	"sync"
+	"time"
+)
+
+const hlcLogicalBits = 16
+
+type ProposalNumberGenerator interface {
+	Next(prev ProposalNumber) ProposalNumber
+}
+
+// WithRoundFloor makes the proposer never generate a round below floor.
+// See ROUND FLOORS above.
+func WithRoundFloor(floor int64) ProposerOption {
+	return func(p *Proposer) {
+		if floor > 0 && floor <= MaxRound {
+			p.roundFloor = floor
+		}
+	}
+}
+
+// ClockRoundFloor is a floor taken from t, in HLCGenerator's layout.
+func ClockRoundFloor(t time.Time) int64 {
+	return t.UnixMilli() << hlcLogicalBits
+}
+
+// applyRoundFloor raises highestRound, and the allocator's view of it, to
+// just below the floor, so the next round generated is at least the floor.
+func (p *Proposer) applyRoundFloor() {
+	if p.roundFloor == 0 || p.highestRound >= p.roundFloor-1 {
+		return
+	}
+	p.highestRound = p.roundFloor - 1
+	if p.rounds != nil {
+		p.rounds.Observe(p.highestRound)
+	}
+}
+
+type RoundGenerator struct {
+	ID string
+}
+
+func (g RoundGenerator) Next(prev ProposalNumber) ProposalNumber {
+	return ProposalNumber{Round: prev.Round + 1, ProposerID: g.ID}
+}
+
+type HLCGenerator struct {
+	id   string
+	now  func() time.Time
+	last int64
+	mu   sync.Mutex
+}
+
+// NewHLCGenerator returns an HLC generator for proposer id. now may be nil,
+// in which case time.Now is used.
+func NewHLCGenerator(id string, now func() time.Time) *HLCGenerator {
+	if now == nil {
+		now = time.Now
+	}
+	return &HLCGenerator{id: id, now: now}
+}
+
+func (g *HLCGenerator) Next(prev ProposalNumber) ProposalNumber {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	round := g.now().UnixMilli() << hlcLogicalBits
+	if round <= prev.Round {
+		round = prev.Round + 1
+	}
+	if round <= g.last {
+		round = g.last + 1
+	}
+	g.last = round
+	return ProposalNumber{Round: round, ProposerID: g.id}
+}
@@ -0,0 +1,102 @@
+package paxos
+
+import (
+	"testing"
+	"time"
+)
+
+// steppingClock returns each reading in turn, then repeats the last.
+func steppingClock(readings ...time.Time) func() time.Time {
+	return func() time.Time {
+		t := readings[0]
+		if len(readings) > 1 {
+			readings = readings[1:]
+		}
+		return t
+	}
+}
+
+func TestHLCGeneratorStrictlyIncreasing(t *testing.T) {
+	base := time.UnixMilli(1_700_000_000_000)
+	// Same millisecond twice, a step back, then forward again.
+	g := NewHLCGenerator("n1", steppingClock(base, base, base.Add(-time.Second), base.Add(time.Millisecond)))
+	var prev ProposalNumber
+	seen := make(map[ProposalNumber]bool)
+	for i := 0; i < 6; i++ {
+		next := g.Next(prev)
+		if !next.GreaterThan(prev) {
+			t.Fatalf("step %d: %v does not exceed %v", i, next, prev)
+		}
+		if seen[next] {
+			t.Fatalf("step %d: %v issued twice", i, next)
+		}
+		seen[next] = true
+		prev = next
+	}
+	if first := ClockRoundFloor(base); prev.Round < first {
+		t.Fatalf("round %d fell below the clock reading %d", prev.Round, first)
+	}
+}
+
+func TestHLCGeneratorBeatsPrev(t *testing.T) {
+	g := NewHLCGenerator("n1", steppingClock(time.UnixMilli(1000)))
+	// prev came from a node whose clock runs far ahead.
+	ahead := ProposalNumber{Round: ClockRoundFloor(time.UnixMilli(5000)), ProposerID: "n2"}
+	if next := g.Next(ahead); next.Round != ahead.Round+1 || next.ProposerID != "n1" {
+		t.Fatalf("Next(%v) = %v, want round %d for n1", ahead, next, ahead.Round+1)
+	}
+}
+
+func TestHLCGeneratorsOnTwoNodesNeverCollide(t *testing.T) {
+	clock := steppingClock(time.UnixMilli(1000))
+	a, b := NewHLCGenerator("a", clock), NewHLCGenerator("b", clock)
+	var prevA, prevB ProposalNumber
+	seen := make(map[ProposalNumber]bool)
+	for i := 0; i < 100; i++ {
+		prevA, prevB = a.Next(prevA), b.Next(prevB)
+		if seen[prevA] || seen[prevB] || prevA.Equal(prevB) {
+			t.Fatalf("step %d: collision between %v and %v", i, prevA, prevB)
+		}
+		seen[prevA], seen[prevB] = true, true
+		if prevA.LessThan(prevB) == prevB.LessThan(prevA) {
+			t.Fatalf("%v and %v are not totally ordered", prevA, prevB)
+		}
+	}
+}
+
+func TestProposerUsesGenerator(t *testing.T) {
+	net := newTestNet(t, 3)
+	at := time.UnixMilli(1_700_000_000_000)
+	p := newTestProposer(t, net, "p1", WithProposalNumberGenerator(NewHLCGenerator("p1", steppingClock(at))))
+	if _, err := p.Propose([]byte("A")); err != nil {
+		t.Fatal(err)
+	}
+	promised, _, _ := net.acceptor("a0").GetState()
+	if promised.Round < ClockRoundFloor(at) || promised.ProposerID != "p1" {
+		t.Fatalf("a0 promised %v, want an HLC round from p1", promised)
+	}
+}
+
+func TestRoundFloorBoundsFirstNumber(t *testing.T) {
+	p := newTestProposer(t, newTestNet(t, 3), "p1", WithRoundFloor(1000))
+	first, err := p.generateProposalNumber()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Round != 1000 {
+		t.Fatalf("first round = %d, want the floor 1000", first.Round)
+	}
+	if next, err := p.generateProposalNumber(); err != nil || next.Round <= first.Round {
+		t.Fatalf("second round = %v, %v, want above %d", next, err, first.Round)
+	}
+
+	net := newTestNet(t, 3)
+	floor := ClockRoundFloor(time.UnixMilli(1_700_000_000_000))
+	p = newTestProposer(t, net, "p1", WithRoundFloor(floor))
+	if _, err := p.Propose([]byte("A")); err != nil {
+		t.Fatal(err)
+	}
+	if promised, _, _ := net.acceptor("a0").GetState(); promised.Round < floor {
+		t.Fatalf("a0 promised round %d, below the floor %d", promised.Round, floor)
+	}
+}
@@ -0,0 +1,42 @@
+package paxos
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestGenerateProposalNumberStopsAtMaxRound(t *testing.T) {
+	p := newTestProposer(t, newTestNet(t, 3), "p1")
+	p.highestRound = MaxRound - 1
+	n, err := p.generateProposalNumber()
+	if err != nil || n.Round != MaxRound {
+		t.Fatalf("last round = %v, %v, want %d", n, err, int64(MaxRound))
+	}
+	if n, err := p.generateProposalNumber(); !errors.Is(err, ErrRoundExhausted) {
+		t.Fatalf("past MaxRound got %v, %v, want ErrRoundExhausted", n, err)
+	}
+	if p.highestRound < 0 {
+		t.Fatalf("highestRound wrapped to %d", p.highestRound)
+	}
+}
+
+func TestRejectionAtMaxInt64ExhaustsRounds(t *testing.T) {
+	net := newTestNet(t, 3)
+	huge := NewProposalNumber(math.MaxInt64, "evil")
+	for _, id := range net.ids {
+		if err := net.acceptor(id).SetStateForTest(huge, ProposalNumber{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newTestProposer(t, net, "p1")
+	if _, err := p.Propose([]byte("x")); !errors.Is(err, ErrRoundExhausted) {
+		t.Fatalf("err = %v, want ErrRoundExhausted", err)
+	}
+	if p.highestRound < 0 || p.highestRound > MaxRound {
+		t.Fatalf("highestRound = %d after the rejection", p.highestRound)
+	}
+	if promised, _, _ := net.acceptor("a0").GetState(); promised != huge {
+		t.Fatalf("a0 promised %v, want it left at %v", promised, huge)
+	}
+}
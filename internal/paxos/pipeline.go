@@ -0,0 +1,272 @@
+// =============================================================================
+// PIPELINING - Phase 1 Once, Phase 2 per Value
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// ProposeAt runs both phases for every slot: 2 round trips per value. But a
+// Phase 1 promise doesn't have to be per slot. MultiPrepare asks every
+// acceptor to promise a whole window of slots at once, and a proposer that
+// holds such a promise from a quorum can go straight to Accept for each
+// slot in the window:
+//
+//   Append("a")  MultiPrepare[0,63] → MultiPromise×Q → Accept(0) → Accepted×Q
+//   Append("b")                                        Accept(1) → Accepted×Q
+//   Append("c")                                        Accept(2) → Accepted×Q
+//
+// After the first value, each Append costs one round trip. This is the
+// steady state of Multi-Paxos: a stable leader appending to the log.
+//
+// =============================================================================
+// WHY SKIPPING PHASE 1 IS SAFE
+// =============================================================================
+//
+// Phase 1 exists to learn what might already be chosen and to stop lower
+// proposals. The MultiPromise quorum did both for every slot in the window
+// at once: each acceptor reported what it had accepted in the window, and
+// promised to ignore anything lower. So for each slot Append proposes the
+// highest reported value if there was one (and then moves on to the next
+// slot with its own value), or its own value otherwise - exactly what
+// per-slot Phase 1 would have concluded.
+//
+// If anyone with a higher number gets in, an Accept is rejected. Append
+// then drops its leadership and falls back to a fresh MultiPrepare,
+// starting at the slot it was trying to fill.
+//
+// =============================================================================
+// LIMITS
+// =============================================================================
+//
+// - The log position is the proposer's own counter. Append never learns of
+//   slots chosen through other proposers except via MultiPromise reports,
+//   so mixing Append with ProposeAt on the same slots from the same
+//   proposer isn't supported.
+//
+// - LEADER TRANSFER: a leader that wants to stop calls StepDown and sends
+//   LeaderTransfer to a successor, which calls TakeLeadership at once. Its
+//   higher-numbered MultiPrepare supersedes the old window, so the hand-off
+//   costs one round trip rather than a failure-detection timeout.
+//
+// - The window is fixed (WithPipelineWindow, default
+//   defaultPipelineWindow). Reaching its end costs one more MultiPrepare.
+//
+// =============================================================================
+
+package paxos
+
+import (
+	"context"
+	"log"
+)
+
+const defaultPipelineWindow = 64
+
+// WithPipelineWindow sets how many slots each MultiPrepare issued by Append
+// covers.
+func WithPipelineWindow(n int64) ProposerOption {
+	return func(p *Proposer) {
+		if n > 0 {
+			p.pipelineWindow = n
+		}
+	}
+}
+
+type pipelineState struct {
+	leading  bool
+	proposal ProposalNumber
+	toSlot   int64
+	accepted map[int64]AcceptedEntry
+	nextSlot int64
+}
+
+// Append chooses value in the next free slot of this proposer's log and
+// returns that slot. While the proposer holds a window promise it runs
+// Phase 2 only; see the banner above.
+func (p *Proposer) Append(ctx context.Context, value []byte) (int64, error) {
+	if err := p.joinQueue(ctx); err != nil {
+		return 0, err
+	}
+	defer p.leaveQueue()
+	if err := p.begin(); err != nil {
+		return 0, err
+	}
+	defer p.end()
+	if err := p.validateValue(value); err != nil {
+		return 0, err
+	}
+	p.timings = ProposeTimings{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, receiveFailure(err)
+		}
+		slot := p.pipeline.nextSlot
+		quorum := p.acceptQuorum()
+		if !p.pipeline.leading || slot > p.pipeline.toSlot {
+			quorum = p.prepareQuorum()
+		}
+		if !p.quorumReachable(quorum) {
+			return 0, p.unreachable()
+		}
+		p.timings.startAttempt()
+		if !p.pipeline.leading || slot > p.pipeline.toSlot {
+			err := p.timePhase(1, func() error { return p.runMultiPrepare(ctx, slot, slot+p.window()-1) })
+			if isFatal(err) {
+				return 0, err
+			}
+			if err != nil {
+				if err := p.retryAfter(ctx, err); err != nil {
+					return 0, err
+				}
+				continue
+			}
+		}
+		p.slot = slot
+		p.currentProposal = p.pipeline.proposal
+		p.originalValue = value
+		p.valueToPropose = value
+		p.adoptedFrom = ProposalNumber{}
+		p.promise = nil
+		if entry, ok := p.pipeline.accepted[slot]; ok {
+			p.valueToPropose = entry.Value
+			p.adoptedFrom = entry.ProposalNumber
+			p.promise = []Promise{{Slot: slot, AcceptedProposal: entry.ProposalNumber, AcceptedValue: entry.Value, HasAccepted: true}}
+		}
+		err := p.timePhase(2, func() error { return p.runPhase2(ctx) })
+		if isFatal(err) {
+			return 0, err
+		}
+		if err != nil {
+			p.pipeline.leading = false
+			if err := p.retryAfter(ctx, err); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		p.pipeline.nextSlot++
+		if p.adoptedFrom.IsZero() {
+			p.observeSuccess(len(p.timings.Attempts))
+			return slot, nil
+		}
+	}
+}
+
+// IsLeading reports whether the proposer holds a window promise, so the
+// next Append skips Phase 1.
+func (p *Proposer) IsLeading() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pipeline.leading
+}
+
+// NextSlot is the slot the next Append will try first.
+func (p *Proposer) NextSlot() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pipeline.nextSlot
+}
+
+// StepDown gives up the window promise. Nothing is sent; the next Append
+// runs Phase 1 again.
+func (p *Proposer) StepDown() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pipeline.leading = false
+}
+
+// TakeLeadership runs MultiPrepare from fromSlot (or the proposer's own next
+// slot, if later) until it wins a window, retrying with higher numbers
+// after rejections. It is how a LeaderTransfer target takes over.
+func (p *Proposer) TakeLeadership(ctx context.Context, fromSlot int64) error {
+	if err := p.begin(); err != nil {
+		return err
+	}
+	defer p.end()
+	if fromSlot < p.pipeline.nextSlot {
+		fromSlot = p.pipeline.nextSlot
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return receiveFailure(err)
+		}
+		err := p.runMultiPrepare(ctx, fromSlot, fromSlot+p.window()-1)
+		if err == nil || isFatal(err) {
+			return err
+		}
+		if err := p.retryAfter(ctx, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Proposer) window() int64 {
+	if p.pipelineWindow > 0 {
+		return p.pipelineWindow
+	}
+	return defaultPipelineWindow
+}
+
+// runMultiPrepare is Phase 1 for slots [from, to]. On success the proposer
+// leads the window with the highest accepted entry reported for each slot.
+func (p *Proposer) runMultiPrepare(ctx context.Context, from, to int64) error {
+	proposal, err := p.generateProposalNumber()
+	if err != nil {
+		return err
+	}
+	p.pipeline.leading = false
+	p.currentProposal = proposal
+	if err := p.send(MultiPrepare{FromSlot: from, ToSlot: to, ProposalNumber: proposal, From: p.id}); err != nil {
+		return err
+	}
+	phaseCtx, cancel := p.phaseContext(ctx)
+	defer cancel()
+	promised := make(map[string]bool)
+	accepted := make(map[int64]AcceptedEntry)
+	for len(promised) < p.prepareQuorum() {
+		msg, err := p.receive(phaseCtx, promised)
+		if err != nil {
+			return p.phaseFailure(ctx, err)
+		}
+		promise, ok := msg.(MultiPromise)
+		if !ok || promise.FromSlot != from || !promise.ProposalNumber.Equal(proposal) {
+			p.stale.Add(1)
+			continue
+		}
+		if !promise.OK {
+			p.handleRejection(promise.HighestSeen)
+			return &ProposeError{Reason: LowerProposalNumber, HighestSeen: promise.HighestSeen, Err: ErrRejected}
+		}
+		if promised[promise.From] || !validMultiPromise(p.id, promise) {
+			continue
+		}
+		promised[promise.From] = true
+		for slot, entry := range promise.AcceptedSlots {
+			if best, ok := accepted[slot]; !ok || entry.ProposalNumber.GreaterThan(best.ProposalNumber) {
+				accepted[slot] = entry
+			}
+		}
+	}
+	p.pipeline = pipelineState{
+		leading:  true,
+		proposal: proposal,
+		toSlot:   to,
+		accepted: accepted,
+		nextSlot: from,
+	}
+	return nil
+}
+
+// validMultiPromise rejects a promise reporting an entry accepted above the
+// number being promised, which no correct acceptor sends; adopting it could
+// resurrect a value that was never proposed.
+func validMultiPromise(id string, promise MultiPromise) bool {
+	for slot, entry := range promise.AcceptedSlots {
+		if entry.ProposalNumber.GreaterThan(promise.ProposalNumber) {
+			log.Printf("[%s] ignoring multi-promise from %s: slot %d accepted %v above promised %v",
+				id, promise.From, slot, entry.ProposalNumber, promise.ProposalNumber)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package paxos
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+// messageCounter counts the requests a proposer sends, one per acceptor
+// reached.
+type messageCounter struct {
+	*testTransport
+	sent     atomic.Int64
+	prepares atomic.Int64
+}
+
+func (c *messageCounter) Send(to string, msg interface{}) error {
+	c.sent.Add(1)
+	switch msg.(type) {
+	case Prepare, MultiPrepare:
+		c.prepares.Add(1)
+	}
+	return c.testTransport.Send(to, msg)
+}
+
+func (c *messageCounter) Broadcast(msg interface{}) error {
+	for _, id := range c.net.ids {
+		c.Send(id, msg)
+	}
+	return nil
+}
+
+func newCountedProposer(tb testing.TB, net *testNet) (*Proposer, *messageCounter) {
+	tb.Helper()
+	c := &messageCounter{testTransport: net.transport()}
+	p, err := NewProposer("p1", len(net.ids)/2+1, c)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return p, c
+}
+
+func TestAppendSkipsPhase1OnceLeading(t *testing.T) {
+	net := newTestNet(t, 3)
+	p, c := newCountedProposer(t, net)
+	ctx := context.Background()
+	if _, err := p.Append(ctx, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsLeading() {
+		t.Fatal("not leading after the first Append")
+	}
+	c.sent.Store(0)
+	c.prepares.Store(0)
+	for i, v := range []string{"b", "c", "d"} {
+		slot, err := p.Append(ctx, []byte(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slot != int64(i+1) {
+			t.Fatalf("Append(%s) went to slot %d, want %d", v, slot, i+1)
+		}
+	}
+	// Each value costs an Accept and a Learn per acceptor, and no Prepare.
+	if c.prepares.Load() != 0 || c.sent.Load() != 3*2*3 {
+		t.Fatalf("3 warm Appends sent %d messages, %d of them Phase 1; want an Accept and a Learn per acceptor each",
+			c.sent.Load(), c.prepares.Load())
+	}
+}
+
+func TestAppendFallsBackToPhase1OnRejection(t *testing.T) {
+	net := newTestNet(t, 3)
+	p, c := newCountedProposer(t, net)
+	ctx := context.Background()
+	if _, err := p.Append(ctx, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	// A rival's higher Prepare for slot 1 breaks the window promise there.
+	rival := newTestProposer(t, net, "p9")
+	if _, err := rival.ProposeAt(ctx, 1, []byte("rival")); err != nil {
+		t.Fatal(err)
+	}
+	c.prepares.Store(0)
+	slot, err := p.Append(ctx, []byte("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.prepares.Load() == 0 {
+		t.Fatal("Append after a rejection never re-ran Phase 1")
+	}
+	if slot != 2 {
+		t.Fatalf("Append(b) went to slot %d, want 2 after adopting the rival's slot 1", slot)
+	}
+	if _, _, v := net.acceptor("a0").GetSlotState(1); string(v) != "rival" {
+		t.Fatalf("slot 1 holds %q, want the rival's value kept", v)
+	}
+}
+
+// BenchmarkProposeAt and BenchmarkAppend report msgs/op: every value costs
+// ProposeAt both phases, while a leading Append skips Phase 1.
+func BenchmarkProposeAt(b *testing.B) {
+	net := newTestNet(b, 3)
+	p, c := newCountedProposer(b, net)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.ProposeAt(ctx, int64(i), []byte("v")); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(c.sent.Load())/float64(b.N), "msgs/op")
+}
+
+func BenchmarkAppend(b *testing.B) {
+	net := newTestNet(b, 3)
+	p, c := newCountedProposer(b, net)
+	ctx := context.Background()
+	if _, err := p.Append(ctx, []byte("warm")); err != nil {
+		b.Fatal(err)
+	}
+	c.sent.Store(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Append(ctx, []byte("v")); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(c.sent.Load())/float64(b.N), "msgs/op")
+}
@@ -0,0 +1,103 @@
+// =============================================================================
+// PROBE - Phase 1 Without Phase 2
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// A node deciding whether to take over as leader wants to know what it would
+// be walking into: how high the numbers in use have climbed, and whether any
+// value is already accepted. Probe runs Phase 1 against a quorum and reports
+// what the promises said, then stops:
+//
+//   r, err := p.Probe(ctx)
+//   // r.Proposal:         the number the quorum promised
+//   // r.HighestRejected:  highest number held against an earlier probe attempt
+//   // r.HasAccepted:      some promise reported an accepted value
+//   // r.Accepted:         the highest such proposal number ...
+//   // r.Value:            ... and its value
+//
+// Read, by contrast, goes on to re-commit an accepted value in Phase 2.
+//
+// =============================================================================
+// NOT FREE
+// =============================================================================
+//
+// A probe is a real Prepare. The acceptors that promise it will reject
+// anything numbered lower, so probing can stall a proposal already running
+// under a lower number, and that proposer will retry with a higher one. A
+// rejected probe is retried the same way. HighestRejected keeps the highest
+// number it was turned away by, a sign another proposer is active; it is
+// zero if the first attempt succeeded. Probe sends no Accept and so never
+// changes what is chosen.
+//
+// =============================================================================
+
+package paxos
+
+import (
+	"context"
+	"errors"
+)
+
+type ProbeResult struct {
+	Proposal        ProposalNumber
+	HighestRejected ProposalNumber
+	Promised        []string
+	HasAccepted     bool
+	Accepted        ProposalNumber
+	Value           []byte
+}
+
+// Probe runs Phase 1 for slot 0 until a quorum promises, and reports what
+// the promises held. See the banner above.
+func (p *Proposer) Probe(ctx context.Context) (ProbeResult, error) {
+	if err := p.begin(); err != nil {
+		return ProbeResult{}, err
+	}
+	defer p.end()
+	var rejectedBy ProposalNumber
+	for {
+		if err := ctx.Err(); err != nil {
+			return ProbeResult{}, receiveFailure(err)
+		}
+		if !p.quorumReachable(p.prepareQuorum()) {
+			return ProbeResult{}, p.unreachable()
+		}
+		proposal, err := p.generateProposalNumber()
+		if err != nil {
+			return ProbeResult{}, err
+		}
+		p.currentProposal = proposal
+		p.slot = 0
+		p.originalValue = nil
+		p.valueToPropose = nil
+		p.adoptedFrom = ProposalNumber{}
+		p.promise = nil
+		if err := p.runPhase1(ctx); err != nil {
+			if isFatal(err) {
+				return ProbeResult{}, err
+			}
+			var perr *ProposeError
+			if errors.As(err, &perr) && perr.HighestSeen.GreaterThan(rejectedBy) {
+				rejectedBy = perr.HighestSeen
+			}
+			if err := p.retryAfter(ctx, err); err != nil {
+				return ProbeResult{}, err
+			}
+			continue
+		}
+		result := ProbeResult{
+			Proposal:        proposal,
+			HighestRejected: rejectedBy,
+			HasAccepted:     !p.adoptedFrom.IsZero(),
+			Accepted:        p.adoptedFrom,
+			Value:           p.valueToPropose,
+		}
+		for _, promise := range p.promise {
+			result.Promised = append(result.Promised, promise.From)
+		}
+		return result, nil
+	}
+}
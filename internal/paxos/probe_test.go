@@ -0,0 +1,46 @@
+package paxos
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProbeReportsPriorAccept(t *testing.T) {
+	net := newTestNet(t, 3)
+	old := NewProposalNumber(3, "old")
+	for _, id := range []string{"a0", "a1"} {
+		if err := net.acceptor(id).SetStateForTest(old, old, []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newTestProposer(t, net, "p1")
+	r, err := p.Probe(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.HasAccepted || r.Accepted != old || string(r.Value) != "v" {
+		t.Fatalf("probe = %+v, want v accepted at %v", r, old)
+	}
+	if !r.Proposal.GreaterThan(old) || len(r.Promised) < 2 {
+		t.Fatalf("probe = %+v, want a quorum promising above %v", r, old)
+	}
+
+	// Phase 1 only: nothing new was accepted anywhere.
+	if _, accepted, _ := net.acceptor("a2").GetState(); !accepted.IsZero() {
+		t.Fatalf("a2 accepted %v after a probe", accepted)
+	}
+	if _, accepted, v := net.acceptor("a0").GetState(); accepted != old || string(v) != "v" {
+		t.Fatalf("a0 holds %v %q after a probe, want %v v", accepted, v, old)
+	}
+}
+
+func TestProbeOfFreshCluster(t *testing.T) {
+	p := newTestProposer(t, newTestNet(t, 3), "p1")
+	r, err := p.Probe(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.HasAccepted || !r.Accepted.IsZero() || r.Value != nil || !r.HighestRejected.IsZero() {
+		t.Fatalf("probe of an empty cluster = %+v", r)
+	}
+}
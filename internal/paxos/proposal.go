@@ -89,7 +89,10 @@
 
 package paxos
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type ProposalNumber struct {
 	Round int64
@@ -132,7 +135,38 @@ func NewProposalNumber(round int64, proposerID string) ProposalNumber {
     return ProposalNumber{Round: round, ProposerID: proposerID}
 }
 
+var ErrInvalidQuorum = errors.New("invalid quorum size")
 
+// clusterSize <= 0 means the cluster size isn't known yet; only the
+// lower bound is checked in that case.
+func ValidateQuorum(quorumSize, clusterSize int) error {
+	if quorumSize < 1 {
+		return fmt.Errorf("%w: quorum %d must be at least 1", ErrInvalidQuorum, quorumSize)
+	}
+	if clusterSize <= 0 {
+		return nil
+	}
+	if quorumSize > clusterSize {
+		return fmt.Errorf("%w: quorum %d exceeds cluster size %d", ErrInvalidQuorum, quorumSize, clusterSize)
+	}
+	if quorumSize <= clusterSize/2 {
+		return fmt.Errorf("%w: quorum %d is not a majority of %d", ErrInvalidQuorum, quorumSize, clusterSize)
+	}
+	return nil
+}
 
-
-
+// ValidateFlexibleQuorums checks Phase 1 and Phase 2 quorums for Flexible
+// Paxos: each must fit in the cluster, and every Phase 1 quorum must share
+// an acceptor with every Phase 2 quorum, i.e. prepare + accept > clusterSize.
+func ValidateFlexibleQuorums(prepare, accept, clusterSize int) error {
+	if prepare < 1 || accept < 1 {
+		return fmt.Errorf("%w: quorums %d/%d must be at least 1", ErrInvalidQuorum, prepare, accept)
+	}
+	if prepare > clusterSize || accept > clusterSize {
+		return fmt.Errorf("%w: quorums %d/%d exceed cluster size %d", ErrInvalidQuorum, prepare, accept, clusterSize)
+	}
+	if prepare+accept <= clusterSize {
+		return fmt.Errorf("%w: prepare quorum %d and accept quorum %d need not intersect in %d nodes", ErrInvalidQuorum, prepare, accept, clusterSize)
+	}
+	return nil
+}
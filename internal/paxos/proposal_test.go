@@ -0,0 +1,50 @@
+package paxos
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateQuorum(t *testing.T) {
+	for _, tc := range []struct {
+		quorum, cluster int
+		ok              bool
+	}{
+		{0, 0, false},
+		{0, 3, false},
+		{-1, 0, false},
+		{1, 0, true},
+		{2, 4, false}, // half of the cluster: two disjoint quorums
+		{3, 4, true},
+		{2, 3, true},
+		{4, 3, false},
+	} {
+		err := ValidateQuorum(tc.quorum, tc.cluster)
+		if tc.ok && err != nil {
+			t.Errorf("ValidateQuorum(%d, %d) = %v, want nil", tc.quorum, tc.cluster, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrInvalidQuorum) {
+			t.Errorf("ValidateQuorum(%d, %d) = %v, want ErrInvalidQuorum", tc.quorum, tc.cluster, err)
+		}
+	}
+}
+
+func TestConstructorsRejectZeroQuorum(t *testing.T) {
+	net := newTestNet(t, 3)
+	if _, err := NewProposer("p1", 0, net.transport()); !errors.Is(err, ErrInvalidQuorum) {
+		t.Errorf("NewProposer with quorum 0: err = %v", err)
+	}
+	if _, err := NewLearner("l1", 0); !errors.Is(err, ErrInvalidQuorum) {
+		t.Errorf("NewLearner with quorum 0: err = %v", err)
+	}
+	l, err := NewLearner("l1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetMembers([]string{"a", "b"}); err != nil {
+		t.Fatalf("SetMembers of 2: %v", err)
+	}
+	if err := l.SetQuorumSize(0); !errors.Is(err, ErrInvalidQuorum) {
+		t.Errorf("SetQuorumSize(0): err = %v", err)
+	}
+}
@@ -209,6 +209,9 @@
 //
 // For now, don't worry about this. Just document it.
 //
+// The proposer does implement 1: a rejected attempt is retried after a
+// random wait that grows with recent contention (see backoff.go).
+//
 // =============================================================================
 // INVARIANT THIS FILE MUST UPHOLD
 // =============================================================================
@@ -238,6 +241,44 @@
 // You must find the MAXIMUM accepted proposal number and use THAT value.
 //
 // =============================================================================
+// CONCURRENT PROPOSALS
+// =============================================================================
+//
+// A Proposer runs one round at a time. By default a second Propose, ProposeAt,
+// Append or Read on the same proposer waits for the first to finish and then
+// runs; calls for different slots are therefore safe to make concurrently,
+// they just don't overlap on the wire.
+//
+// WithSingleFlight makes the wait explicit: a call made while another is in
+// progress returns ErrProposalInFlight at once, without sending anything.
+// Use it when the caller would rather retry, or report back, than queue.
+//
+// WithMaxQueuedProposals bounds the queue instead. At most n slot proposals -
+// Propose, ProposeAt, ProposeNoop and Append calls - are queued on the
+// proposer at once, the running one included; the next blocks until one
+// of them commits or fails, or until its context ends. Rounds still run one
+// at a time, so this caps the callers parked on a busy proposer, not the
+// slots in Phase 2 together.
+//
+// =============================================================================
+// STALE RESPONSES
+// =============================================================================
+//
+// Responses to an earlier attempt keep arriving after a retry has moved to
+// a new proposal number. The phase loops already skip anything whose slot
+// or number doesn't match, so a late quorum for the old number is never
+// counted as progress. But while the proposer backs off between attempts
+// nobody reads the inbox, and the stragglers pile up ahead of the new
+// attempt's replies - on a bounded inbox they can push those out.
+//
+// So send drains the inbox before every phase message goes out. Nothing
+// queued at that point can answer a message not yet sent. The drain runs
+// only on a ResponseDrainer, whose inbox holds nothing but replies for
+// this proposer; on a shared inbox it would throw away requests meant for
+// the local acceptor. StaleResponses counts everything discarded, drained
+// or skipped, for diagnostics.
+//
+// =============================================================================
 // MULTI-PAXOS EXTENSION POINT
 // =============================================================================
 //
@@ -255,119 +296,905 @@
 package paxos
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"math"
+	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+const receivePollInterval = 50 * time.Millisecond
+
+// DefaultMaxValueSize bounds proposed values unless WithMaxValueSize says
+// otherwise. Every value is held by every acceptor and sent in several
+// messages, so there has to be some ceiling.
+const DefaultMaxValueSize = 1 << 20
+
 type Transport interface {
 	Broadcast(msg interface{}) error
 	Receive() (interface{}, error)
+	ReceiveTimeout(timeout time.Duration) (interface{}, error)
+}
+
+// TargetedTransport is implemented by transports that can address a single
+// acceptor. A fanout policy needs it; without it the proposer broadcasts.
+type TargetedTransport interface {
+	Send(to string, msg interface{}) error
+}
+
+// ResponseDrainer is implemented by transports whose inbox holds only
+// replies for the proposer. TryReceive takes a queued reply without
+// waiting and reports false if there is none. See STALE RESPONSES.
+type ResponseDrainer interface {
+	TryReceive() (interface{}, bool)
+}
+
+// DetailedBroadcaster is implemented by transports whose broadcast reports
+// every destination and its send error. With WithRetransmit and a
+// TargetedTransport, the proposer then retransmits a broadcast phase to
+// whoever hasn't answered, those it failed to reach included.
+type DetailedBroadcaster interface {
+	BroadcastDetailed(msg interface{}) (map[string]error, error)
+}
+
+// FanoutPolicy limits each phase to the first quorum+Margin acceptors. If a
+// quorum hasn't answered after EscalateAfter, the message goes to the rest.
+type FanoutPolicy struct {
+	Margin        int
+	EscalateAfter time.Duration
+}
+
+// RoundAllocator hands out proposal rounds for every proposer on a node.
+// Next must return a round greater than any it has returned before, across
+// restarts, and greater than any round passed to Observe.
+type RoundAllocator interface {
+	Next() (int64, error)
+	Observe(round int64)
 }
 
 type Proposer struct {
 	id string
 	highestRound int64
 	currentProposal ProposalNumber
+	slot int64
 	originalValue []byte
 	valueToPropose []byte
+	adoptedFrom ProposalNumber
+	ownSent []ProposalNumber
 	promise []Promise
-	quorumSize int
+	quorumSize atomic.Int64
+	acceptQuorumSize atomic.Int64
+	flexible *flexibleQuorums
 	transport Transport
+	strictSafety bool
+	maxValueSize int
+	allowEmptyValue bool
+	acceptors []string
+	fanout *FanoutPolicy
+	phaseMsg interface{}
+	contacted []string
+	escalateTo []string
+	escalateAt time.Time
+	retransmitAfter time.Duration
+	retransmitAt time.Time
+	quorumTimeout time.Duration
+	rounds RoundAllocator
+	numbers ProposalNumberGenerator
+	pipeline pipelineState
+	pipelineWindow int64
+	singleFlight bool
+	inFlight atomic.Bool
+	now func() time.Time
+	timings ProposeTimings
+	trace *ProposeTrace
+	detector FailureDetector
+	contention int
+	stale atomic.Uint64
+	roundFloor int64
+	queue chan struct{}
 	mu sync.Mutex
 }
 
-func NewProposer(id string, quorumSize int, transport Transport) *Proposer {
-	return &Proposer{
+type ProposerOption func(*Proposer)
+
+// WithAcceptors makes the proposer address each acceptor with Send instead
+// of broadcasting. It has no effect unless the transport implements
+// TargetedTransport. The list fixes the cluster size, so NewProposer
+// rejects a quorum that isn't a majority of it.
+func WithAcceptors(acceptors []string) ProposerOption {
+	return func(p *Proposer) {
+		p.acceptors = append([]string(nil), acceptors...)
+	}
+}
+
+// SetAcceptors replaces the acceptor list WithAcceptors set, taking effect
+// from the next round; an empty list goes back to broadcasting. It refuses
+// a non-empty list too short for either quorum, since no round could then
+// succeed.
+func (p *Proposer) SetAcceptors(acceptors []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	seen := make(map[string]bool, len(acceptors))
+	list := make([]string, 0, len(acceptors))
+	for _, id := range acceptors {
+		if !seen[id] {
+			seen[id] = true
+			list = append(list, id)
+		}
+	}
+	if len(list) == 0 {
+		p.acceptors = nil
+		return nil
+	}
+	for _, q := range []int{p.prepareQuorum(), p.acceptQuorum()} {
+		if q > len(list) {
+			return fmt.Errorf("%w: quorum %d exceeds %d acceptors", ErrInvalidQuorum, q, len(list))
+		}
+	}
+	p.acceptors = list
+	return nil
+}
+
+// Acceptors returns the current acceptor list, or nil when broadcasting.
+func (p *Proposer) Acceptors() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.acceptors...)
+}
+
+// WithRetransmit re-sends the current phase's message every d, but only to
+// contacted acceptors that haven't answered yet. Like WithAcceptors, it
+// needs a TargetedTransport, and either an acceptor list or a
+// DetailedBroadcaster to learn who a broadcast went to.
+func WithRetransmit(d time.Duration) ProposerOption {
+	return func(p *Proposer) {
+		p.retransmitAfter = d
+	}
+}
+
+// WithFanout sends Prepare and Accept to a subset of acceptors, chosen in
+// list order, instead of broadcasting. It has no effect unless the transport
+// implements TargetedTransport.
+func WithFanout(acceptors []string, policy FanoutPolicy) ProposerOption {
+	return func(p *Proposer) {
+		p.acceptors = append([]string(nil), acceptors...)
+		p.fanout = &policy
+	}
+}
+
+// WithMaxValueSize sets the largest value Propose accepts. n <= 0 removes
+// the limit.
+func WithMaxValueSize(n int) ProposerOption {
+	return func(p *Proposer) {
+		p.maxValueSize = n
+	}
+}
+
+// WithQuorumTimeout turns on a simple failure detector: if a phase hears
+// from fewer than quorumSize distinct acceptors within d, Propose gives up
+// with ErrNoQuorum instead of retrying. A proposer on the minority side of
+// a partition then fails fast rather than spinning forever. Retrying is
+// left to the caller; a new Propose starts over with a higher number.
+func WithQuorumTimeout(d time.Duration) ProposerOption {
+	return func(p *Proposer) {
+		p.quorumTimeout = d
+	}
+}
+
+// WithRoundAllocator makes the proposer take rounds from the allocator instead of its
+// own in-memory counter.
+func WithRoundAllocator(a RoundAllocator) ProposerOption {
+	return func(p *Proposer) {
+		p.rounds = a
+	}
+}
+
+// WithProposalNumberGenerator replaces the default round-incrementing scheme
+// (and any RoundAllocator) with g. See numbering.go.
+func WithProposalNumberGenerator(g ProposalNumberGenerator) ProposerOption {
+	return func(p *Proposer) {
+		p.numbers = g
+	}
+}
+
+// WithAllowEmptyValue lets nil and zero-length values be proposed, for
+// callers that use them as markers.
+func WithAllowEmptyValue() ProposerOption {
+	return func(p *Proposer) {
+		p.allowEmptyValue = true
+	}
+}
+
+// WithSingleFlight makes a call that arrives while another round is running
+// fail with ErrProposalInFlight instead of waiting for it.
+func WithSingleFlight() ProposerOption {
+	return func(p *Proposer) {
+		p.singleFlight = true
+	}
+}
+
+// WithMaxQueuedProposals caps how many slot proposals may be queued at
+// once; see CONCURRENT PROPOSALS above.
+func WithMaxQueuedProposals(n int) ProposerOption {
+	return func(p *Proposer) {
+		if n > 0 {
+			p.queue = make(chan struct{}, n)
+		}
+	}
+}
+
+// QueuedProposals is how many slot proposals are queued under
+// WithMaxQueuedProposals, the running one included, or 0 without it.
+func (p *Proposer) QueuedProposals() int {
+	return len(p.queue)
+}
+
+// WithClock replaces time.Now for the phase timings in LastTimings, so tests
+// can drive them from a fake clock.
+func WithClock(now func() time.Time) ProposerOption {
+	return func(p *Proposer) {
+		if now != nil {
+			p.now = now
+		}
+	}
+}
+
+type flexibleQuorums struct {
+	prepare     int
+	accept      int
+	clusterSize int
+}
+
+// WithFlexibleQuorums uses prepareQuorum promises for Phase 1 and
+// acceptQuorum acceptances for Phase 2 instead of the quorumSize passed to
+// NewProposer (Flexible Paxos). The two only need to intersect; NewProposer
+// rejects them with ErrInvalidQuorum unless
+// prepareQuorum + acceptQuorum > clusterSize. A small acceptQuorum makes
+// every value cheaper to choose, at the cost of a larger Phase 1 whenever
+// leadership changes.
+func WithFlexibleQuorums(prepareQuorum, acceptQuorum, clusterSize int) ProposerOption {
+	return func(p *Proposer) {
+		p.flexible = &flexibleQuorums{prepare: prepareQuorum, accept: acceptQuorum, clusterSize: clusterSize}
+	}
+}
+
+func NewProposer(id string, quorumSize int, transport Transport, opts ...ProposerOption) (*Proposer, error) {
+	if err := ValidateQuorum(quorumSize, 0); err != nil {
+		return nil, err
+	}
+	p := &Proposer{
 		id:        id,
-		quorumSize: quorumSize,
 		transport: transport,
+		maxValueSize: DefaultMaxValueSize,
+		now:          time.Now,
+	}
+	p.quorumSize.Store(int64(quorumSize))
+	for _, opt := range opts {
+		opt(p)
+	}
+	if f := p.flexible; f != nil {
+		if err := ValidateFlexibleQuorums(f.prepare, f.accept, f.clusterSize); err != nil {
+			return nil, err
+		}
+		p.quorumSize.Store(int64(f.prepare))
+		p.acceptQuorumSize.Store(int64(f.accept))
+	} else if len(p.acceptors) > 0 {
+		if err := ValidateQuorum(quorumSize, distinctCount(p.acceptors)); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func distinctCount(ids []string) int {
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	return len(seen)
+}
+
+// SetQuorumSize changes the quorum for every phase started afterwards, for
+// use when cluster membership changes. It is safe to call while a proposal
+// is running. It replaces any flexible quorums with quorumSize for both
+// phases.
+func (p *Proposer) SetQuorumSize(quorumSize int) error {
+	if err := ValidateQuorum(quorumSize, 0); err != nil {
+		return err
+	}
+	p.quorumSize.Store(int64(quorumSize))
+	p.acceptQuorumSize.Store(0)
+	return nil
+}
+
+func (p *Proposer) prepareQuorum() int {
+	return int(p.quorumSize.Load())
+}
+
+// AcceptQuorum is how many Accepted messages choose a value: the Phase 2
+// quorum. Learners must count to the same number.
+func (p *Proposer) AcceptQuorum() int {
+	return p.acceptQuorum()
+}
+
+func (p *Proposer) acceptQuorum() int {
+	if q := p.acceptQuorumSize.Load(); q > 0 {
+		return int(q)
+	}
+	return p.prepareQuorum()
+}
+
+func (p *Proposer) quorumFor(msg interface{}) int {
+	if _, ok := msg.(Accept); ok {
+		return p.acceptQuorum()
 	}
+	return p.prepareQuorum()
+}
+
+type ProposeResult struct {
+	Value          []byte
+	OwnValueChosen bool
+	Rounds         int
+	// AdoptedFrom is the proposal whose value the winning attempt adopted
+	// in Phase 1, or zero if it adopted nothing.
+	AdoptedFrom ProposalNumber
 }
 
 func (p *Proposer) Propose(value []byte) ([]byte, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	result, err := p.ProposeDetailed(value)
+	if err != nil {
+		return nil, err
+	}
+	return result.Value, nil
+}
+
+// ProposeDetailed is Propose plus a report of how the value was reached.
+// OwnValueChosen is true only if the value chosen is the one passed in:
+// Phase 1 of the winning attempt adopted nothing, or adopted what an
+// earlier attempt of this same call sent with our value. A value this
+// proposer left behind in an earlier call doesn't count, even its own,
+// unless the caller passes those numbers to ProposeDetailedAtAgain.
+func (p *Proposer) ProposeDetailed(value []byte) (ProposeResult, error) {
+	return p.propose(context.Background(), 0, value)
+}
+
+// ProposeDetailedContext is ProposeDetailed, giving up when ctx is done.
+func (p *Proposer) ProposeDetailedContext(ctx context.Context, value []byte) (ProposeResult, error) {
+	return p.propose(ctx, 0, value)
+}
+
+// ProposeAt runs an independent Paxos instance for slot. Promises for other
+// slots are ignored, so a value already accepted at slot is adopted exactly
+// as Propose would adopt one for the single-decree instance.
+func (p *Proposer) ProposeAt(ctx context.Context, slot int64, value []byte) ([]byte, error) {
+	result, err := p.propose(ctx, slot, value)
+	if err != nil {
+		return nil, err
+	}
+	return result.Value, nil
+}
+
+// ProposeNoop fills slot with NoOp. It succeeds once the slot holds any
+// chosen value: if an earlier proposal was already accepted there, Phase 1
+// adopts it and that value is what gets chosen. A leader uses it as a
+// heartbeat - a success means it still commands a quorum.
+func (p *Proposer) ProposeNoop(ctx context.Context, slot int64) error {
+	_, err := p.ProposeAt(ctx, slot, NoOp)
+	return err
+}
+
+// ProposeDetailedAt is ProposeAt with the report ProposeDetailed gives.
+func (p *Proposer) ProposeDetailedAt(ctx context.Context, slot int64, value []byte) (ProposeResult, error) {
+	return p.propose(ctx, slot, value)
+}
+
+// ProposeDetailedAtAgain is ProposeDetailedAt for a caller that retries the
+// same value at slot across several calls. The numbers in sent count as our
+// own, so a value an earlier call left accepted there is still reported as
+// OwnValueChosen, and the numbers this call sends the value under are
+// added to sent whether or not it succeeds. sent must not be shared by
+// concurrent calls.
+func (p *Proposer) ProposeDetailedAtAgain(ctx context.Context, slot int64, value []byte, sent *[]ProposalNumber) (ProposeResult, error) {
+	return p.proposeTraced(ctx, slot, value, nil, sent)
+}
+
+// ProposeOwnAt is ProposeAt for a caller that needs its own value in the
+// log. If Phase 1 adopts a value not sent by this call, that value is still
+// chosen at slot and returned, with ErrValueAdopted; see ProposeDetailed
+// for what counts as our own.
+func (p *Proposer) ProposeOwnAt(ctx context.Context, slot int64, value []byte) ([]byte, error) {
+	result, err := p.ProposeDetailedAt(ctx, slot, value)
+	if err != nil {
+		return nil, err
+	}
+	if !result.OwnValueChosen {
+		return result.Value, ErrValueAdopted
+	}
+	return result.Value, nil
+}
+
+func (p *Proposer) propose(ctx context.Context, slot int64, value []byte) (ProposeResult, error) {
+	return p.proposeTraced(ctx, slot, value, nil, nil)
+}
+
+// proposeTraced is propose, recording each attempt into trace if it isn't
+// nil. If sent isn't nil, the numbers in it count as our own and the
+// numbers this call sends our value under are added to it.
+func (p *Proposer) proposeTraced(ctx context.Context, slot int64, value []byte, trace *ProposeTrace, sent *[]ProposalNumber) (ProposeResult, error) {
+	if err := p.joinQueue(ctx); err != nil {
+		return ProposeResult{}, err
+	}
+	defer p.leaveQueue()
+	if err := p.begin(); err != nil {
+		return ProposeResult{}, err
+	}
+	defer p.end()
+	p.trace = trace
+	defer func() { p.trace = nil }()
+	if err := p.validateValue(value); err != nil {
+		return ProposeResult{}, err
+	}
+	p.slot = slot
 	p.originalValue = value
-	p.valueToPropose = value
+	p.ownSent = nil
+	if sent != nil {
+		p.ownSent = append(p.ownSent, *sent...)
+		defer func() { *sent = append((*sent)[:0], p.ownSent...) }()
+	}
+	p.timings = ProposeTimings{}
+	rounds := 0
 	for {
-		p.currentProposal = p.generateProposalNumber()
+		if err := ctx.Err(); err != nil {
+			return ProposeResult{}, receiveFailure(err)
+		}
+		if !p.quorumReachable(p.prepareQuorum()) {
+			return ProposeResult{}, p.unreachable()
+		}
+		rounds++
+		p.timings.startAttempt()
+		proposal, err := p.generateProposalNumber()
+		if err != nil {
+			return ProposeResult{}, err
+		}
+		p.currentProposal = proposal
+		p.valueToPropose = value
+		p.adoptedFrom = ProposalNumber{}
 		p.promise = nil 
-		err := p.runPhase1()
+		p.traceAttempt(proposal)
+		err = p.timePhase(1, func() error { return p.runPhase1(ctx) })
+		p.traceEnd(err)
+		if isFatal(err) {
+			return ProposeResult{}, err
+		}
+		if err != nil {
+			if err := p.retryAfter(ctx, err); err != nil {
+				return ProposeResult{}, err
+			}
+			continue
+		}
+		p.traceAdopted(p.adoptedFrom)
+		own := p.ownValue()
+		if own {
+			p.ownSent = append(p.ownSent, proposal)
+		}
+		err = p.timePhase(2, func() error { return p.runPhase2(ctx) })
+		p.traceEnd(err)
+		if isFatal(err) {
+			return ProposeResult{}, err
+		}
+		if err != nil {
+			if err := p.retryAfter(ctx, err); err != nil {
+				return ProposeResult{}, err
+			}
+			continue
+		}
+		p.observeSuccess(rounds)
+		return ProposeResult{
+			Value:          p.valueToPropose,
+			OwnValueChosen: own,
+			Rounds:         rounds,
+			AdoptedFrom:    p.adoptedFrom,
+		}, nil
+	}
+}
+
+// ownValue reports whether the attempt about to run Phase 2 carries the
+// caller's value: nothing was adopted, or what was adopted came from an
+// attempt of this call that carried it.
+func (p *Proposer) ownValue() bool {
+	if p.adoptedFrom.IsZero() {
+		return true
+	}
+	for _, sent := range p.ownSent {
+		if sent == p.adoptedFrom {
+			return true
+		}
+	}
+	return false
+}
+
+// Read runs a Paxos round that cannot change the outcome. Phase 1 against a
+// quorum reveals whether any value may have been chosen: if no promise
+// reports an accepted value, nothing was chosen and Phase 2 is skipped.
+// Otherwise the highest accepted value is re-committed in Phase 2, which
+// is exactly the value a chosen instance would hold.
+//
+// Read fails with ctx's error if a quorum can't be reached in time, which
+// is what stops a partitioned node from serving a stale answer.
+func (p *Proposer) Read(ctx context.Context) ([]byte, bool, error) {
+	if err := p.begin(); err != nil {
+		return nil, false, err
+	}
+	defer p.end()
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, false, receiveFailure(err)
+		}
+		if !p.quorumReachable(p.prepareQuorum()) {
+			return nil, false, p.unreachable()
+		}
+		proposal, err := p.generateProposalNumber()
 		if err != nil {
+			return nil, false, err
+		}
+		p.currentProposal = proposal
+		p.slot = 0
+		p.originalValue = nil
+		p.valueToPropose = nil
+		p.adoptedFrom = ProposalNumber{}
+		p.promise = nil
+		if err := p.runPhase1(ctx); err != nil {
+			if isFatal(err) {
+				return nil, false, err
+			}
+			if err := p.retryAfter(ctx, err); err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+		if p.adoptedFrom.IsZero() {
+			return nil, false, nil
+		}
+		if err := p.runPhase2(ctx); err != nil {
+			if isFatal(err) {
+				return nil, false, err
+			}
+			if err := p.retryAfter(ctx, err); err != nil {
+				return nil, false, err
+			}
 			continue
 		}
-		err = p.runPhase2()
+		return p.valueToPropose, true, nil
+	}
+}
+
+// begin takes the proposer for one round; end releases it. Under
+// WithSingleFlight begin fails instead of waiting.
+func (p *Proposer) begin() error {
+	if p.singleFlight {
+		if !p.inFlight.CompareAndSwap(false, true) {
+			return ErrProposalInFlight
+		}
+	}
+	p.mu.Lock()
+	return nil
+}
+
+// joinQueue takes a place in the WithMaxQueuedProposals queue, waiting for
+// one to free up; leaveQueue gives it back.
+func (p *Proposer) joinQueue(ctx context.Context) error {
+	if p.queue == nil {
+		return nil
+	}
+	select {
+	case p.queue <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return receiveFailure(ctx.Err())
+	}
+}
+
+func (p *Proposer) leaveQueue() {
+	if p.queue != nil {
+		<-p.queue
+	}
+}
+
+func (p *Proposer) end() {
+	p.mu.Unlock()
+	if p.singleFlight {
+		p.inFlight.Store(false)
+	}
+}
+
+func (p *Proposer) validateValue(value []byte) error {
+	if len(value) == 0 && !p.allowEmptyValue {
+		return ErrEmptyValue
+	}
+	if p.maxValueSize > 0 && len(value) > p.maxValueSize {
+		return ErrValueTooLarge
+	}
+	return nil
+}
+
+// send starts a phase. With an acceptor list each acceptor is addressed
+// directly; under a fanout policy only the first quorum+Margin get msg now
+// and the rest are left for tick to escalate to.
+// send reports only ErrNoPeers; other send failures are left for the
+// quorum wait to notice, like a lost message.
+func (p *Proposer) send(msg interface{}) error {
+	p.drainStale()
+	p.phaseMsg = msg
+	p.contacted = nil
+	p.escalateTo = nil
+	targeted, ok := p.transport.(TargetedTransport)
+	if len(p.acceptors) == 0 || !ok {
+		return p.broadcast(msg, ok)
+	}
+	n := len(p.acceptors)
+	if q := p.quorumFor(msg); p.fanout != nil && q+p.fanout.Margin < n {
+		n = q + p.fanout.Margin
+	}
+	acceptors := p.liveFirst()
+	p.contacted = append([]string(nil), acceptors[:n]...)
+	for _, id := range p.contacted {
+		targeted.Send(id, msg)
+	}
+	if n < len(acceptors) {
+		p.escalateTo = acceptors[n:]
+		p.escalateAt = time.Now().Add(p.fanout.EscalateAfter)
+	}
+	p.retransmitAt = time.Now().Add(p.retransmitAfter)
+	return nil
+}
+
+// broadcast sends msg to everyone. When the transport reports who that was
+// and retransmits are on, they become the contacted set for tick, so a
+// destination whose send failed is retried like one whose reply was lost.
+func (p *Proposer) broadcast(msg interface{}, targeted bool) error {
+	detailed, ok := p.transport.(DetailedBroadcaster)
+	if !ok || !targeted || p.retransmitAfter <= 0 {
+		if err := p.transport.Broadcast(msg); errors.Is(err, ErrNoPeers) {
+			return err
+		}
+		return nil
+	}
+	results, err := detailed.BroadcastDetailed(msg)
+	if errors.Is(err, ErrNoPeers) {
+		return err
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	for id, err := range results {
 		if err != nil {
+			log.Printf("[%s] broadcast to %s failed: %v", p.id, id, err)
+		}
+		p.contacted = append(p.contacted, id)
+	}
+	p.retransmitAt = time.Now().Add(p.retransmitAfter)
+	return nil
+}
+
+// tick runs between receives: it escalates to the acceptors a fanout
+// policy held back, and retransmits to contacted acceptors missing from
+// responded.
+func (p *Proposer) tick(responded map[string]bool) {
+	if p.contacted == nil {
+		return
+	}
+	targeted := p.transport.(TargetedTransport)
+	now := time.Now()
+	if p.escalateTo != nil && !now.Before(p.escalateAt) {
+		for _, id := range p.escalateTo {
+			targeted.Send(id, p.phaseMsg)
+		}
+		p.contacted = append(p.contacted, p.escalateTo...)
+		p.escalateTo = nil
+	}
+	if p.retransmitAfter > 0 && !now.Before(p.retransmitAt) {
+		for _, id := range p.contacted {
+			if !responded[id] {
+				targeted.Send(id, p.phaseMsg)
+			}
+		}
+		p.retransmitAt = now.Add(p.retransmitAfter)
+	}
+}
+
+// maxStaleDrain bounds one drain, so a flooded inbox can't hold up a phase.
+const maxStaleDrain = 1024
+
+// drainStale discards whatever is already queued; see STALE RESPONSES.
+func (p *Proposer) drainStale() {
+	d, ok := p.transport.(ResponseDrainer)
+	if !ok {
+		return
+	}
+	for i := 0; i < maxStaleDrain; i++ {
+		if _, ok := d.TryReceive(); !ok {
+			return
+		}
+		p.stale.Add(1)
+	}
+}
+
+// StaleResponses is how many responses to earlier attempts, or to other
+// slots, the proposer has discarded.
+func (p *Proposer) StaleResponses() uint64 {
+	return p.stale.Load()
+}
+
+func (p *Proposer) receive(ctx context.Context, responded map[string]bool) (interface{}, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p.tick(responded)
+		msg, err := p.transport.ReceiveTimeout(receivePollInterval)
+		if errors.Is(err, ErrTimeout) {
 			continue
 		}
-		return p.valueToPropose, nil
+		return msg, err
 	}
 }
 
-func (p *Proposer) runPhase1() error {
+func (p *Proposer) runPhase1(ctx context.Context) error {
 	prepareMsg := Prepare{
+		Slot:           p.slot,
 		ProposalNumber: p.currentProposal,
 		From:           p.id,
 	}
-	p.transport.Broadcast(prepareMsg)
-	promiseCount := 0
-	for promiseCount < p.quorumSize {
-		msg, err := p.transport.Receive()
+	if err := p.send(prepareMsg); err != nil {
+		return err
+	}
+	phaseCtx, cancel := p.phaseContext(ctx)
+	defer cancel()
+	promised := make(map[string]bool)
+	for len(promised) < p.prepareQuorum() {
+		msg, err := p.receive(phaseCtx, promised)
 		if err != nil {
-			return err
+			return p.phaseFailure(ctx, err)
 		}
 		promise, ok := msg.(Promise)
 		if !ok {
+			p.stale.Add(1)
 			continue 
 		}
-		if !promise.ProposalNumber.Equal(p.currentProposal) {
+		if promise.Slot != p.slot || !promise.ProposalNumber.Equal(p.currentProposal) {
+			p.stale.Add(1)
 			continue
 		}
 		if !promise.OK {
-			p.handleRejection(promise.AcceptedProposal)
-			return ErrRejected
+			p.traceRejection(1, promise.From, promise.HighestSeen)
+			p.handleRejection(promise.HighestSeen)
+			return &ProposeError{Reason: LowerProposalNumber, HighestSeen: promise.HighestSeen, Err: ErrRejected}
 		}
+		if promise.AcceptedProposal.GreaterThan(promise.ProposalNumber) {
+			log.Printf("[%s] ignoring promise from %s: accepted %v above promised %v",
+				p.id, promise.From, promise.AcceptedProposal, promise.ProposalNumber)
+			continue
+		}
+		if promise.HasAccepted == promise.AcceptedProposal.IsZero() {
+			log.Printf("[%s] ignoring promise from %s: HasAccepted=%v with accepted %v",
+				p.id, promise.From, promise.HasAccepted, promise.AcceptedProposal)
+			continue
+		}
+		if promised[promise.From] {
+			continue
+		}
+		promised[promise.From] = true
 		p.promise = append(p.promise, promise)
-		promiseCount++
-	}
-	var highestAccepted ProposalNumber
-	for _, promise := range p.promise {
-		if !promise.AcceptedProposal.IsZero() {
-			if promise.AcceptedProposal.GreaterThan(highestAccepted) {
-				highestAccepted = promise.AcceptedProposal
-				p.valueToPropose = promise.AcceptedValue
+		p.tracePromise(promise.From)
+	}
+	highest, value, found, err := highestAccepted(p.promise)
+	if err != nil {
+		return err
+	}
+	if found {
+		p.valueToPropose = value
+		p.adoptedFrom = highest
+	}
+	return nil
+}
+
+// highestAccepted picks the accepted value with the strictly highest
+// proposal number across promises, ignoring promises that accepted nothing
+// (HasAccepted false, or a zero AcceptedProposal). An accepted empty value is adopted like any other.
+// Several promises may report the same proposal; they must agree on its
+// value, since a proposal number is only ever used with one value, and
+// ErrSafetyViolation is returned if they don't.
+func highestAccepted(promises []Promise) (ProposalNumber, []byte, bool, error) {
+	var highest ProposalNumber
+	var value []byte
+	found := false
+	for _, promise := range promises {
+		if !promise.HasAccepted || promise.AcceptedProposal.IsZero() {
+			continue
+		}
+		switch {
+		case !found || promise.AcceptedProposal.GreaterThan(highest):
+			highest = promise.AcceptedProposal
+			value = promise.AcceptedValue
+			found = true
+		case promise.AcceptedProposal.Equal(highest):
+			if !bytes.Equal(promise.AcceptedValue, value) {
+				return ProposalNumber{}, nil, false, ErrSafetyViolation
 			}
 		}
 	}
-	return nil
+	return highest, value, found, nil
 }
 
-func (p *Proposer) runPhase2() error {
+// runPhase2 sends Accept and waits for an accept quorum. When the number
+// of acceptors is known (WithAcceptors or WithFlexibleQuorums) it keeps
+// going past rejections for as long as the acceptors yet to reply could
+// still make up the quorum, and fails with ErrCannotReachQuorum the moment
+// they can't. Without that number the first rejection ends it.
+func (p *Proposer) runPhase2(ctx context.Context) error {
+	if p.strictSafety {
+		if err := p.verifyAdoption(); err != nil {
+			return err
+		}
+	}
 	acceptMsg := Accept{
+		Slot:           p.slot,
 		ProposalNumber: p.currentProposal,
 		Value:          p.valueToPropose,
 		From:           p.id,
 	}
-	p.transport.Broadcast(acceptMsg)
-	acceptedCount := 0
-	for acceptedCount < p.quorumSize {
-		msg, err := p.transport.Receive()
+	if err := p.send(acceptMsg); err != nil {
+		return err
+	}
+	phaseCtx, cancel := p.phaseContext(ctx)
+	defer cancel()
+	acceptedBy := make(map[string]bool)
+	responded := make(map[string]bool)
+	rejected := 0
+	var highestSeen ProposalNumber
+	for len(acceptedBy) < p.acceptQuorum() {
+		msg, err := p.receive(phaseCtx, responded)
 		if err != nil {
-			return err
+			return p.phaseFailure(ctx, err)
 		}
 		accepted, ok := msg.(Accepted)
 		if !ok {
+			p.stale.Add(1)
+			continue
+		}
+		if accepted.Slot != p.slot || !accepted.ProposalNumber.Equal(p.currentProposal) {
+			p.stale.Add(1)
 			continue
 		}
-		if !accepted.ProposalNumber.Equal(p.currentProposal) {
+		if responded[accepted.From] {
 			continue
 		}
+		responded[accepted.From] = true
 		if !accepted.OK {
-			return ErrRejected
+			p.traceRejection(2, accepted.From, accepted.HighestSeen)
+			p.handleRejection(accepted.HighestSeen)
+			if accepted.HighestSeen.GreaterThan(highestSeen) {
+				highestSeen = accepted.HighestSeen
+			}
+			rejected++
+			size := p.clusterSize()
+			if size == 0 {
+				return &ProposeError{Reason: Superseded, HighestSeen: highestSeen, Err: ErrRejected}
+			}
+			if size-rejected < p.acceptQuorum() {
+				return &ProposeError{Reason: Superseded, HighestSeen: highestSeen, Err: ErrCannotReachQuorum}
+			}
+			continue
 		}
-		acceptedCount++
+		acceptedBy[accepted.From] = true
+		p.traceAccepted(accepted.From)
 	}
 	learnMsg := Learn{
+		Slot:           p.slot,
 		ProposalNumber: p.currentProposal,
 		Value:          p.valueToPropose,
 		From:           p.id,
@@ -376,18 +1203,203 @@ func (p *Proposer) runPhase2() error {
 	return nil
 }
 
-func (p *Proposer) generateProposalNumber() ProposalNumber {
-	p.highestRound++
+// clusterSize is the number of acceptors, or 0 if the proposer wasn't told.
+func (p *Proposer) clusterSize() int {
+	if len(p.acceptors) > 0 {
+		return len(p.acceptors)
+	}
+	if p.flexible != nil {
+		return p.flexible.clusterSize
+	}
+	return 0
+}
+
+// SetStrictSafety makes runPhase2 re-derive the value it must propose from
+// the collected promises and refuse to send Accept if valueToPropose
+// disagrees. It is a guard against refactors of the adoption step in
+// runPhase1, not a substitute for it.
+func (p *Proposer) SetStrictSafety(strict bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.strictSafety = strict
+}
+
+func (p *Proposer) verifyAdoption() error {
+	expected := p.originalValue
+	_, value, found, err := highestAccepted(p.promise)
+	if err != nil {
+		return err
+	}
+	if found {
+		expected = value
+	}
+	if !bytes.Equal(expected, p.valueToPropose) {
+		return ErrSafetyViolation
+	}
+	return nil
+}
+
+// MaxRound is the highest round a proposer will use. It sits well below
+// math.MaxInt64 so that no increment or reservation on the way there can
+// wrap a round negative and break ordering.
+const MaxRound = math.MaxInt64 - 1<<32
+
+// generateProposalNumber refuses with ErrRoundExhausted rather than return
+// a round above MaxRound or one that didn't move past highestRound.
+func (p *Proposer) generateProposalNumber() (ProposalNumber, error) {
+	p.applyRoundFloor()
+	if p.highestRound >= MaxRound {
+		return ProposalNumber{}, ErrRoundExhausted
+	}
+	if p.numbers != nil {
+		next := p.numbers.Next(NewProposalNumber(p.highestRound, p.id))
+		if next.Round <= p.highestRound || next.Round > MaxRound {
+			return ProposalNumber{}, ErrRoundExhausted
+		}
+		p.highestRound = next.Round
+		return next, nil
+	}
+	if p.rounds != nil {
+		round, err := p.rounds.Next()
+		if err != nil {
+			return ProposalNumber{}, err
+		}
+		if round > MaxRound {
+			return ProposalNumber{}, ErrRoundExhausted
+		}
+		p.highestRound = round
+	} else {
+		p.highestRound++
+	}
 	return ProposalNumber{
 		Round:      p.highestRound,
 		ProposerID: p.id,
-	}
+	}, nil
 }
 
+// handleRejection moves highestRound so that the next generated number,
+// (highestRound+1, id), strictly exceeds highestSeen - including the case
+// where the rounds tie and only the ProposerID tiebreak would differ. A
+// reported round above MaxRound is clamped to it, so the next
+// generateProposalNumber fails instead of overflowing.
 func (p *Proposer) handleRejection(highestSeen ProposalNumber) {
-	if highestSeen.Round > p.highestRound {
-		p.highestRound = highestSeen.Round
+	if p.highestRound >= MaxRound {
+		return
+	}
+	next := NewProposalNumber(p.highestRound+1, p.id)
+	if next.GreaterThan(highestSeen) {
+		return
+	}
+	if highestSeen.Round > MaxRound {
+		highestSeen.Round = MaxRound
+	}
+	p.highestRound = highestSeen.Round
+	if p.rounds != nil {
+		p.rounds.Observe(highestSeen.Round)
+	}
+}
+var (
+	ErrRejected        = errors.New("proposal rejected")
+	ErrTimeout         = errors.New("receive timeout")
+	ErrSafetyViolation = errors.New("value to propose does not match highest accepted promise")
+	ErrValueTooLarge   = errors.New("value exceeds maximum size")
+	ErrEmptyValue      = errors.New("value is empty")
+	ErrNoQuorum        = errors.New("cannot reach a quorum of acceptors")
+	// ErrNoPeers means Broadcast had nobody to send to. Transports report
+	// it for an empty registry; retrying can't help, so Propose returns it.
+	ErrNoPeers = errors.New("no acceptors to broadcast to")
+	// ErrProposalInFlight is returned under WithSingleFlight when another
+	// round is already running on the proposer.
+	ErrProposalInFlight = errors.New("another proposal is already in flight on this proposer")
+	// ErrCannotReachQuorum means enough acceptors rejected an Accept that
+	// the rest can't form a quorum. It wraps ErrRejected, so Propose retries
+	// with a higher number as for any other rejection.
+	ErrCannotReachQuorum = fmt.Errorf("too many acceptors rejected to reach a quorum: %w", ErrRejected)
+	// ErrRoundExhausted means the next proposal number would pass MaxRound.
+	// No retry on this proposer can succeed.
+	ErrRoundExhausted = errors.New("proposal rounds exhausted")
+	// ErrValueAdopted is returned by ProposeOwnAt when the slot was decided
+	// for a value other than ours. Retrying the same slot can't change
+	// that; propose into another.
+	ErrValueAdopted = errors.New("slot chose a value other than ours")
+)
+
+// isFatal reports errors no retry can fix, which end a proposal at once.
+func isFatal(err error) bool {
+	return errors.Is(err, ErrSafetyViolation) || errors.Is(err, ErrNoQuorum) || errors.Is(err, ErrNoPeers) ||
+		errors.Is(err, ErrRoundExhausted)
+}
+
+type RejectReason int
+
+const (
+	// LowerProposalNumber: Phase 1 hit an acceptor already promised to an
+	// equal or higher number.
+	LowerProposalNumber RejectReason = iota + 1
+	// QuorumNotReached: responses stopped before a quorum answered, e.g.
+	// the context was cancelled or the transport failed.
+	QuorumNotReached
+	// Timeout: the context deadline passed while waiting for a quorum.
+	Timeout
+	// Superseded: Phase 2 was rejected because a higher proposal got a
+	// promise after our Phase 1 succeeded.
+	Superseded
+)
+
+func (r RejectReason) String() string {
+	switch r {
+	case LowerProposalNumber:
+		return "lower proposal number"
+	case QuorumNotReached:
+		return "quorum not reached"
+	case Timeout:
+		return "timeout"
+	case Superseded:
+		return "superseded"
+	}
+	return "unknown"
+}
+
+// ProposeError says why a round failed. HighestSeen is set for
+// LowerProposalNumber and Superseded. Err is the underlying cause, so
+// errors.Is(err, ErrRejected) and errors.Is(err, context.DeadlineExceeded)
+// keep working.
+type ProposeError struct {
+	Reason      RejectReason
+	HighestSeen ProposalNumber
+	Err         error
+}
+
+func (e *ProposeError) Error() string {
+	if !e.HighestSeen.IsZero() {
+		return fmt.Sprintf("%s: %s (highest seen %s)", e.Err, e.Reason, e.HighestSeen)
 	}
+	return fmt.Sprintf("%s: %s", e.Err, e.Reason)
 }
-var ErrRejected = errors.New("proposal rejected")
 
+func (e *ProposeError) Unwrap() error {
+	return e.Err
+}
+
+func (p *Proposer) phaseContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.quorumTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.quorumTimeout)
+}
+
+// phaseFailure tells the quorum timeout apart from the caller's own ctx
+// expiring: only the former means too few acceptors are reachable.
+func (p *Proposer) phaseFailure(ctx context.Context, err error) error {
+	if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return &ProposeError{Reason: QuorumNotReached, Err: ErrNoQuorum}
+	}
+	return receiveFailure(err)
+}
+
+func receiveFailure(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &ProposeError{Reason: Timeout, Err: err}
+	}
+	return &ProposeError{Reason: QuorumNotReached, Err: err}
+}
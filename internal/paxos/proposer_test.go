@@ -0,0 +1,452 @@
+package paxos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProposeDetailedOwnValue(t *testing.T) {
+	net := newTestNet(t, 3)
+	p := newTestProposer(t, net, "p1")
+	result, err := p.ProposeDetailed([]byte("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.OwnValueChosen || string(result.Value) != "A" {
+		t.Fatalf("got %q own=%v, want A own=true", result.Value, result.OwnValueChosen)
+	}
+}
+
+func TestProposeDetailedAdoptedFromOther(t *testing.T) {
+	net := newTestNet(t, 3)
+	old := ProposalNumber{Round: 1, ProposerID: "old"}
+	if err := net.acceptor("a0").SetStateForTest(old, old, []byte("X")); err != nil {
+		t.Fatal(err)
+	}
+	result, err := newTestProposer(t, net, "p1").ProposeDetailed([]byte("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OwnValueChosen || string(result.Value) != "X" {
+		t.Fatalf("got %q own=%v, want X own=false", result.Value, result.OwnValueChosen)
+	}
+}
+
+func TestProposeDetailedAdoptedFromOwnEarlierCall(t *testing.T) {
+	net := newTestNet(t, 3)
+	p := newTestProposer(t, net, "p1")
+	if _, err := p.Propose([]byte("A")); err != nil {
+		t.Fatal(err)
+	}
+	result, err := p.ProposeDetailed([]byte("B"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OwnValueChosen || string(result.Value) != "A" {
+		t.Fatalf("got %q own=%v, want A own=false", result.Value, result.OwnValueChosen)
+	}
+}
+
+func TestProposeDetailedAtAgainCountsRecordedNumbers(t *testing.T) {
+	net := newTestNet(t, 3)
+	p := newTestProposer(t, net, "p1")
+	var sent []ProposalNumber
+	if _, err := p.ProposeDetailedAtAgain(context.Background(), 2, []byte("A"), &sent); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent = %v, want the one number A went out under", sent)
+	}
+	result, err := p.ProposeDetailedAtAgain(context.Background(), 2, []byte("A"), &sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.OwnValueChosen || result.AdoptedFrom != sent[0] {
+		t.Fatalf("got own=%v adopted=%v, want own=true adopted=%v", result.OwnValueChosen, result.AdoptedFrom, sent[0])
+	}
+	result, err = p.ProposeDetailedAt(context.Background(), 2, []byte("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OwnValueChosen {
+		t.Fatal("without the recorded numbers the earlier call's value isn't ours")
+	}
+}
+
+func TestProposeOwnAtAdopted(t *testing.T) {
+	net := newTestNet(t, 3)
+	other := newTestProposer(t, net, "p2")
+	if _, err := other.ProposeAt(context.Background(), 4, []byte("cmd")); err != nil {
+		t.Fatal(err)
+	}
+	p := newTestProposer(t, net, "p1")
+	chosen, err := p.ProposeOwnAt(context.Background(), 4, []byte("cmd"))
+	if err != ErrValueAdopted {
+		t.Fatalf("identical value from another proposer: err = %v, want ErrValueAdopted", err)
+	}
+	if !bytes.Equal(chosen, []byte("cmd")) {
+		t.Fatalf("chosen = %q", chosen)
+	}
+	if _, err := p.ProposeOwnAt(context.Background(), 5, []byte("cmd")); err != nil {
+		t.Fatalf("free slot: %v", err)
+	}
+}
+
+func TestDrainStaleEmptiesResponseInbox(t *testing.T) {
+	tr := drainingTransport{newTestNet(t, 3).transport()}
+	for i := 0; i < 5; i++ {
+		tr.inbox <- Promise{Slot: int64(i), From: "a0"}
+	}
+	p, err := NewProposer("p1", 2, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.drainStale()
+	if got := p.StaleResponses(); got != 5 {
+		t.Fatalf("StaleResponses = %d, want 5", got)
+	}
+	if len(tr.inbox) != 0 {
+		t.Fatalf("%d messages left after drain", len(tr.inbox))
+	}
+}
+
+func TestDrainStaleLeavesSharedInboxAlone(t *testing.T) {
+	tr := newTestNet(t, 3).transport()
+	tr.inbox <- Prepare{Slot: 0, ProposalNumber: ProposalNumber{Round: 1, ProposerID: "p2"}, From: "p2"}
+	p, err := NewProposer("p1", 2, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.drainStale()
+	if len(tr.inbox) != 1 || p.StaleResponses() != 0 {
+		t.Fatal("drain discarded a request from a shared inbox")
+	}
+}
+
+func TestStrictSafetyCatchesSkippedAdoption(t *testing.T) {
+	p := newTestProposer(t, newTestNet(t, 3), "p1")
+	earlier := ProposalNumber{Round: 4, ProposerID: "p0"}
+	p.originalValue = []byte("mine")
+	p.promise = []Promise{
+		{From: "a0", OK: true},
+		{From: "a1", OK: true, HasAccepted: true, AcceptedProposal: earlier, AcceptedValue: []byte("theirs")},
+	}
+	// What runPhase2 would see if runPhase1 stopped adopting.
+	p.valueToPropose = p.originalValue
+	if err := p.verifyAdoption(); !errors.Is(err, ErrSafetyViolation) {
+		t.Fatalf("verifyAdoption = %v, want ErrSafetyViolation", err)
+	}
+	p.valueToPropose = []byte("theirs")
+	if err := p.verifyAdoption(); err != nil {
+		t.Fatalf("verifyAdoption after adopting: %v", err)
+	}
+}
+
+func TestStrictSafetyProposeAdopts(t *testing.T) {
+	net := newTestNet(t, 3)
+	old := ProposalNumber{Round: 1, ProposerID: "old"}
+	for _, id := range []string{"a0", "a1"} {
+		if err := net.acceptor(id).SetStateForTest(old, old, []byte("theirs")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newTestProposer(t, net, "p1")
+	p.SetStrictSafety(true)
+	chosen, err := p.Propose([]byte("mine"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chosen) != "theirs" {
+		t.Fatalf("chosen %q, want the adopted value", chosen)
+	}
+}
+
+func TestProposeAdoptsAcceptedEmptyValue(t *testing.T) {
+	net := newTestNet(t, 3)
+	old := ProposalNumber{Round: 1, ProposerID: "old"}
+	for _, id := range []string{"a0", "a1"} {
+		if err := net.acceptor(id).SetStateForTest(old, old, []byte{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	promise := net.acceptor("a0").HandlePrepare(Prepare{ProposalNumber: ProposalNumber{Round: 2, ProposerID: "p0"}})
+	if !promise.OK || !promise.HasAccepted || len(promise.AcceptedValue) != 0 {
+		t.Fatalf("promise = %+v, want HasAccepted with an empty value", promise)
+	}
+
+	p := newTestProposer(t, net, "p1")
+	chosen, err := p.Propose([]byte("mine"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chosen) != 0 {
+		t.Fatalf("chosen %q, want the accepted empty value", chosen)
+	}
+	if _, _, v := net.acceptor("a2").GetState(); len(v) != 0 {
+		t.Fatalf("a2 accepted %q, want the empty value", v)
+	}
+}
+
+func TestHighestAcceptedConflictingValues(t *testing.T) {
+	n := ProposalNumber{Round: 2, ProposerID: "p0"}
+	_, _, _, err := highestAccepted([]Promise{
+		{HasAccepted: true, AcceptedProposal: n, AcceptedValue: []byte("x")},
+		{HasAccepted: true, AcceptedProposal: n, AcceptedValue: []byte("y")},
+	})
+	if !errors.Is(err, ErrSafetyViolation) {
+		t.Fatalf("err = %v, want ErrSafetyViolation", err)
+	}
+}
+
+func TestRejectionMovesPastHighestSeen(t *testing.T) {
+	seen := ProposalNumber{Round: 7, ProposerID: "node-z"}
+	for _, id := range []string{"node-a", "node-z", "zz"} {
+		p := newTestProposer(t, newTestNet(t, 3), id)
+		p.handleRejection(seen)
+		next, err := p.generateProposalNumber()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !next.GreaterThan(seen) {
+			t.Errorf("%s: next number %v after a rejection reporting %v", id, next, seen)
+		}
+	}
+}
+
+func TestProposeRecoversFromRejection(t *testing.T) {
+	net := newTestNet(t, 3)
+	seen := ProposalNumber{Round: 7, ProposerID: "node-z"}
+	for _, id := range []string{"a0", "a1", "a2"} {
+		if err := net.acceptor(id).SetStateForTest(seen, ProposalNumber{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newTestProposer(t, net, "node-a")
+	if _, err := p.Propose([]byte("A")); errors.Is(err, ErrRejected) {
+		// The first attempt could only learn (7, "node-z"); the retry
+		// must clear it.
+		_, err = p.Propose([]byte("A"))
+		if err != nil {
+			t.Fatalf("retry after rejection: %v", err)
+		}
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	promised, _, _ := net.acceptor("a0").GetState()
+	if !promised.GreaterThan(seen) {
+		t.Fatalf("a0 promised %v, want more than %v", promised, seen)
+	}
+}
+
+func rejectReason(t *testing.T, err error) *ProposeError {
+	t.Helper()
+	var pe *ProposeError
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %v, want a *ProposeError", err)
+	}
+	return pe
+}
+
+func TestRejectReasonLowerProposalNumber(t *testing.T) {
+	net := newTestNet(t, 3)
+	seen := NewProposalNumber(7, "node-z")
+	if err := net.acceptor("a0").SetStateForTest(seen, ProposalNumber{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	p := newTestProposer(t, net, "p1")
+	p.currentProposal = NewProposalNumber(1, "p1")
+	err := p.runPhase1(context.Background())
+	pe := rejectReason(t, err)
+	if pe.Reason != LowerProposalNumber || pe.HighestSeen != seen || !errors.Is(err, ErrRejected) {
+		t.Fatalf("got %v (reason %v, highest %v), want lower proposal number at %v", err, pe.Reason, pe.HighestSeen, seen)
+	}
+}
+
+func TestRejectReasonSuperseded(t *testing.T) {
+	net := newTestNet(t, 3)
+	seen := NewProposalNumber(7, "node-z")
+	if err := net.acceptor("a0").SetStateForTest(seen, ProposalNumber{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	p := newTestProposer(t, net, "p1")
+	p.currentProposal = NewProposalNumber(1, "p1")
+	p.valueToPropose = []byte("A")
+	pe := rejectReason(t, p.runPhase2(context.Background()))
+	if pe.Reason != Superseded || pe.HighestSeen != seen {
+		t.Fatalf("reason %v, highest %v, want superseded at %v", pe.Reason, pe.HighestSeen, seen)
+	}
+}
+
+func TestRejectReasonTimeout(t *testing.T) {
+	net := newTestNet(t, 3)
+	for _, id := range net.ids {
+		net.setDown(id, true)
+	}
+	p := newTestProposer(t, net, "p1")
+	p.currentProposal = NewProposalNumber(1, "p1")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := p.runPhase1(ctx)
+	if pe := rejectReason(t, err); pe.Reason != Timeout {
+		t.Fatalf("reason %v, want timeout", pe.Reason)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want it to wrap context.DeadlineExceeded", err)
+	}
+}
+
+func TestHighestAcceptedMixedPromises(t *testing.T) {
+	low := NewProposalNumber(2, "x")
+	tie := NewProposalNumber(4, "y")
+	high := NewProposalNumber(4, "z")
+	highest, value, found, err := highestAccepted([]Promise{
+		{},
+		{AcceptedValue: []byte("stale")},
+		{HasAccepted: true, AcceptedValue: []byte("zero")},
+		{HasAccepted: true, AcceptedProposal: low, AcceptedValue: []byte("B")},
+		{HasAccepted: true, AcceptedProposal: high, AcceptedValue: []byte("D")},
+		{HasAccepted: true, AcceptedProposal: tie, AcceptedValue: []byte("C")},
+		{HasAccepted: true, AcceptedProposal: high, AcceptedValue: []byte("D")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || highest != high || string(value) != "D" {
+		t.Fatalf("got %v %q found=%v, want %v D", highest, value, found, high)
+	}
+}
+
+func TestHighestAcceptedIgnoresZeroProposal(t *testing.T) {
+	_, value, found, err := highestAccepted([]Promise{
+		{HasAccepted: true, AcceptedValue: []byte("zero")},
+		{},
+	})
+	if err != nil || found {
+		t.Fatalf("adopted %q from a zero accepted proposal (err %v)", value, err)
+	}
+}
+
+func TestPhase1AdoptsStrictlyHighestAccepted(t *testing.T) {
+	net := newTestNet(t, 5)
+	states := map[string]struct {
+		accepted ProposalNumber
+		value    string
+	}{
+		"a1": {NewProposalNumber(2, "x"), "B"},
+		"a2": {NewProposalNumber(4, "z"), "D"},
+		"a3": {NewProposalNumber(4, "z"), "D"},
+		"a4": {NewProposalNumber(4, "y"), "C"},
+	}
+	for id, st := range states {
+		if err := net.acceptor(id).SetStateForTest(st.accepted, st.accepted, []byte(st.value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Every acceptor must answer, so all five promises are weighed.
+	p, err := NewProposer("p1", 5, net.transport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	chosen, err := p.Propose([]byte("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chosen) != "D" {
+		t.Fatalf("chose %q, want D from the strictly highest (4, z)", chosen)
+	}
+}
+
+// forgingTransport rewrites liar's promises to report an accept above the
+// number being promised.
+type forgingTransport struct {
+	*testTransport
+	liar string
+}
+
+func (f *forgingTransport) Send(to string, msg interface{}) error {
+	reply, ok := f.net.deliver(to, msg)
+	if !ok {
+		return nil
+	}
+	if promise, isPromise := reply.(Promise); isPromise && to == f.liar && promise.OK {
+		promise.HasAccepted = true
+		promise.AcceptedProposal = NewProposalNumber(promise.ProposalNumber.Round+100, "evil")
+		promise.AcceptedValue = []byte("bogus")
+		reply = promise
+	}
+	f.inbox <- reply
+	return nil
+}
+
+func (f *forgingTransport) Broadcast(msg interface{}) error {
+	for _, id := range f.net.ids {
+		f.Send(id, msg)
+	}
+	return nil
+}
+
+func TestPhase1IgnoresPromiseAboveItsNumber(t *testing.T) {
+	net := newTestNet(t, 3)
+	tr := &forgingTransport{testTransport: net.transport(), liar: "a0"}
+	p, err := NewProposer("p1", 2, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chosen, trace, err := p.ProposeWithTrace([]byte("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chosen) != "A" {
+		t.Fatalf("chose %q, want A: the forged value was adopted", chosen)
+	}
+	promised := trace.Attempts[len(trace.Attempts)-1].Promised
+	for _, from := range promised {
+		if from == "a0" {
+			t.Fatalf("a0's forged promise counted toward quorum: %v", promised)
+		}
+	}
+	if _, _, v := net.acceptor("a1").GetState(); string(v) != "A" {
+		t.Fatalf("a1 accepted %q, want the proposer's own A", v)
+	}
+
+	// With every acceptor needed, the forged promise leaves it short.
+	p, err = NewProposer("p2", 3, &forgingTransport{testTransport: newTestNet(t, 3).transport(), liar: "a0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if v, err := p.ProposeAt(ctx, 0, []byte("A")); err == nil {
+		t.Fatalf("reached a quorum of 3 counting the forged promise, chose %q", v)
+	}
+}
+
+func TestPhase2AbortsOnceQuorumIsOutOfReach(t *testing.T) {
+	net := newTestNet(t, 5)
+	higher := NewProposalNumber(9, "rival")
+	for _, id := range []string{"a0", "a1", "a2"} {
+		if err := net.acceptor(id).SetStateForTest(higher, ProposalNumber{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	net.setDown("a4", true)
+	p := newTestProposer(t, net, "p1", WithAcceptors(net.ids), WithQuorumTimeout(5*time.Second))
+	p.currentProposal = NewProposalNumber(1, "p1")
+	p.valueToPropose = []byte("x")
+
+	start := time.Now()
+	err := p.runPhase2(context.Background())
+	if !errors.Is(err, ErrCannotReachQuorum) {
+		t.Fatalf("runPhase2 = %v, want ErrCannotReachQuorum", err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("runPhase2 took %v; it waited for the timeout", took)
+	}
+	var perr *ProposeError
+	if !errors.As(err, &perr) || perr.HighestSeen != higher {
+		t.Fatalf("err = %#v, want HighestSeen %v", err, higher)
+	}
+}
@@ -0,0 +1,57 @@
+package paxos
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// missFirstAccept loses the first Accept sent to one acceptor and counts
+// every Accept sent to each.
+type missFirstAccept struct {
+	*testTransport
+	miss    string
+	mu      sync.Mutex
+	accepts map[string]int
+}
+
+func (m *missFirstAccept) Send(to string, msg interface{}) error {
+	if _, ok := msg.(Accept); ok {
+		m.mu.Lock()
+		m.accepts[to]++
+		lost := to == m.miss && m.accepts[to] == 1
+		m.mu.Unlock()
+		if lost {
+			return nil
+		}
+	}
+	return m.testTransport.Send(to, msg)
+}
+
+func TestRetransmitOnlyToSilentAcceptor(t *testing.T) {
+	net := newTestNet(t, 5)
+	tr := &missFirstAccept{testTransport: net.transport(), miss: "a3", accepts: make(map[string]int)}
+	// Every acceptor is needed, so the lost Accept has to be re-sent.
+	p, err := NewProposer("p1", 5, tr, WithAcceptors(net.ids), WithRetransmit(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Propose([]byte("A")); err != nil {
+		t.Fatal(err)
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for _, id := range net.ids {
+		want := 1
+		if id == "a3" {
+			want = 2
+		}
+		if got := tr.accepts[id]; got != want {
+			t.Errorf("%d Accepts sent to %s, want %d", got, id, want)
+		}
+	}
+	if _, accepted, _ := net.acceptor("a3").GetState(); accepted.IsZero() {
+		t.Fatal("a3 never got the Accept")
+	}
+}
@@ -0,0 +1,54 @@
+package paxos
+
+import (
+	"testing"
+
+	"quorum/internal/storage"
+	"quorum/internal/testutil"
+)
+
+func TestAcceptorSyncsBeforeEveryOK(t *testing.T) {
+	s := testutil.NewCountingStorage(storage.NewMemoryStorage())
+	a := NewAcceptor("a0", s)
+	oks := 0
+	check := func(what string, ok bool) {
+		t.Helper()
+		if ok {
+			oks++
+			if s.Unsynced() {
+				t.Fatalf("%s answered OK before Sync", what)
+			}
+		}
+	}
+	for round := int64(1); round <= 5; round++ {
+		n := NewProposalNumber(round, "p1")
+		for slot := int64(0); slot < 3; slot++ {
+			check("Prepare", a.HandlePrepare(Prepare{Slot: slot, ProposalNumber: n, From: "p1"}).OK)
+			check("Accept", a.HandleAccept(Accept{Slot: slot, ProposalNumber: n, Value: []byte("v"), From: "p1"}).OK)
+		}
+		next := NewProposalNumber(round, "p2")
+		check("MultiPrepare", a.HandleMultiPrepare(MultiPrepare{FromSlot: 3, ToSlot: 6, ProposalNumber: next, From: "p2"}).OK)
+		check("Accept after MultiPrepare", a.HandleAccept(Accept{Slot: 4, ProposalNumber: next, Value: []byte("w"), From: "p2"}).OK)
+	}
+	saves, syncs := s.Counts()
+	if oks == 0 || syncs < oks || saves == 0 {
+		t.Fatalf("%d OKs, %d saves, %d syncs: want a sync for every OK", oks, saves, syncs)
+	}
+}
+
+func TestAcceptorRefusesWhenSyncFails(t *testing.T) {
+	s := testutil.NewCountingStorage(storage.NewMemoryStorage())
+	a := NewAcceptor("a0", s)
+	s.FailSync(true)
+	n := NewProposalNumber(1, "p1")
+	if p := a.HandlePrepare(Prepare{ProposalNumber: n, From: "p1"}); p.OK {
+		t.Fatal("promised with a failing Sync")
+	}
+	if r := a.HandleAccept(Accept{ProposalNumber: n, Value: []byte("v"), From: "p1"}); r.OK {
+		t.Fatal("accepted with a failing Sync")
+	}
+	s.FailSync(false)
+	if r := a.HandleAccept(Accept{ProposalNumber: n, Value: []byte("v"), From: "p1"}); !r.OK {
+		t.Fatalf("refused once Sync works again: %+v", r)
+	}
+}
@@ -0,0 +1,58 @@
+// =============================================================================
+// TEST HOOKS - Setting Up Acceptor State Directly (TESTS ONLY)
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Many safety scenarios begin with "an acceptor has already accepted X".
+// Reaching that state through real Prepare/Accept messages means scripting
+// a whole earlier round. SetStateForTest jumps straight there:
+//
+//   a := NewAcceptor("a1", s)
+//   a.SetStateForTest(
+//       ProposalNumber{Round: 3, ProposerID: "old"},   // promised
+//       ProposalNumber{Round: 3, ProposerID: "old"},   // accepted
+//       []byte("X"),
+//   )
+//   // a new proposer's Phase 1 now sees (3/old, "X") and must adopt it
+//
+// The state is also written to the acceptor's storage, so a node built later
+// over the same storage starts from it. To seed a node before Start without
+// an Acceptor in hand, call SaveSlot(0, ...) on its storage directly; the
+// acceptor loads slot state lazily on first use.
+//
+// =============================================================================
+// DO NOT USE OUTSIDE TESTS
+// =============================================================================
+//
+// SetStateForTest bypasses every acceptor rule. It can lower a promise or
+// replace an accepted value, which on a live acceptor is exactly the bug
+// described under FAILURE SCENARIO in acceptor.go. It lives in a normal
+// file only because tests in other packages need it.
+//
+// =============================================================================
+
+package paxos
+
+// SetStateForTest overwrites slot 0's promise and accepted proposal, and
+// persists them. Tests only; see the banner above.
+func (a *Acceptor) SetStateForTest(promised, accepted ProposalNumber, value []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st, err := a.slot(0)
+	if err != nil {
+		return err
+	}
+	st.highestPromised = promised
+	st.acceptedProposal = accepted
+	st.acceptedValue = append([]byte(nil), value...)
+	if err := a.storage.SavePromised(toStorageProposal(promised)); err != nil {
+		return err
+	}
+	if err := a.storage.SaveAccepted(toStorageProposal(accepted), st.acceptedValue); err != nil {
+		return err
+	}
+	return a.persist(0, st)
+}
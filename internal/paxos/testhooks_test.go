@@ -0,0 +1,56 @@
+package paxos
+
+import (
+	"testing"
+
+	"quorum/internal/storage"
+)
+
+func TestSetStateForTestIsAdopted(t *testing.T) {
+	net := newTestNet(t, 3)
+	old := NewProposalNumber(3, "old")
+	for _, id := range []string{"a0", "a1"} {
+		if err := net.acceptor(id).SetStateForTest(old, old, []byte("X")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chosen, err := newTestProposer(t, net, "p1").Propose([]byte("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chosen) != "X" {
+		t.Fatalf("chose %q, want the injected X", chosen)
+	}
+}
+
+func TestSetStateForTestPersists(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	old := NewProposalNumber(3, "old")
+	if err := NewAcceptor("a0", s).SetStateForTest(old, old, []byte("X")); err != nil {
+		t.Fatal(err)
+	}
+	promised, accepted, value := NewAcceptor("a0", s).GetState()
+	if promised != old || accepted != old || string(value) != "X" {
+		t.Fatalf("acceptor over the same storage has %v %v %q", promised, accepted, value)
+	}
+}
+
+func TestSeededStorageIsAdopted(t *testing.T) {
+	net := newTestNet(t, 3)
+	// Seed a0's storage directly and rebuild it, as a node would on Start.
+	s := storage.NewMemoryStorage()
+	seeded := storage.ProposalNumber{Round: 2, ProposerID: "old"}
+	if err := s.SaveSlot(0, storage.SlotState{HighestPromised: seeded, AcceptedProposal: seeded, AcceptedValue: []byte("Y")}); err != nil {
+		t.Fatal(err)
+	}
+	net.acceptors["a0"] = NewAcceptor("a0", s)
+	net.setDown("a2", true)
+
+	chosen, err := newTestProposer(t, net, "p1").Propose([]byte("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chosen) != "Y" {
+		t.Fatalf("chose %q, want the seeded Y", chosen)
+	}
+}
@@ -0,0 +1,76 @@
+// =============================================================================
+// TIMINGS - How Long Each Phase Took
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Choosing WithQuorumTimeout or a fanout EscalateAfter means knowing how
+// long a phase normally takes. LastTimings reports it for the most recent
+// Propose, ProposeAt or Append, successful or not:
+//
+//   p.Propose(value)
+//   t := p.LastTimings()
+//   // t.Phase1, t.Phase2: summed over every attempt
+//   // t.Retries:          attempts after the first
+//   // t.Attempts[i]:      each attempt on its own
+//
+// A phase is timed from just before its message is sent until a quorum has
+// answered or the phase gives up, so a rejected attempt still shows how long
+// it waited. For Append, Phase1 is the MultiPrepare, when one was needed.
+//
+// =============================================================================
+
+package paxos
+
+import "time"
+
+type PhaseTimings struct {
+	Phase1 time.Duration
+	Phase2 time.Duration
+}
+
+type ProposeTimings struct {
+	Phase1   time.Duration
+	Phase2   time.Duration
+	Retries  int
+	Attempts []PhaseTimings
+}
+
+// LastTimings returns the timings of the most recent proposal. It waits for
+// one still running to finish.
+func (p *Proposer) LastTimings() ProposeTimings {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t := p.timings
+	t.Attempts = append([]PhaseTimings(nil), t.Attempts...)
+	return t
+}
+
+func (t *ProposeTimings) startAttempt() {
+	if len(t.Attempts) > 0 {
+		t.Retries++
+	}
+	t.Attempts = append(t.Attempts, PhaseTimings{})
+}
+
+// timePhase runs fn and adds its duration to phase 1 or 2 of the current
+// attempt.
+func (p *Proposer) timePhase(phase int, fn func() error) error {
+	if len(p.timings.Attempts) == 0 {
+		p.timings.startAttempt()
+	}
+	start := p.now()
+	err := fn()
+	d := p.now().Sub(start)
+	attempt := &p.timings.Attempts[len(p.timings.Attempts)-1]
+	if phase == 1 {
+		attempt.Phase1 += d
+		p.timings.Phase1 += d
+	} else {
+		attempt.Phase2 += d
+		p.timings.Phase2 += d
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package paxos
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// clockedTransport advances the clock by a fixed cost for each Prepare and
+// Accept it delivers, standing in for the time the round trip takes.
+type clockedTransport struct {
+	*testTransport
+	clock           *fakeClock
+	prepare, accept time.Duration
+}
+
+func (c *clockedTransport) Send(to string, msg interface{}) error {
+	switch msg.(type) {
+	case Prepare:
+		c.clock.Advance(c.prepare)
+	case Accept:
+		c.clock.Advance(c.accept)
+	}
+	return c.testTransport.Send(to, msg)
+}
+
+func (c *clockedTransport) Broadcast(msg interface{}) error {
+	for _, id := range c.net.ids {
+		c.Send(id, msg)
+	}
+	return nil
+}
+
+func newTimedProposer(t *testing.T, net *testNet) *Proposer {
+	t.Helper()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tr := &clockedTransport{testTransport: net.transport(), clock: clock, prepare: 10 * time.Millisecond, accept: 30 * time.Millisecond}
+	p, err := NewProposer("p1", 2, tr, WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLastTimingsPerPhase(t *testing.T) {
+	p := newTimedProposer(t, newTestNet(t, 3))
+	if _, err := p.Propose([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	got := p.LastTimings()
+	// Three acceptors each way: 3×10ms for Phase 1, 3×30ms for Phase 2.
+	if got.Phase1 != 30*time.Millisecond || got.Phase2 != 90*time.Millisecond || got.Retries != 0 || len(got.Attempts) != 1 {
+		t.Fatalf("timings = %+v, want Phase1 30ms, Phase2 90ms, one attempt", got)
+	}
+}
+
+func TestLastTimingsPerAttempt(t *testing.T) {
+	net := newTestNet(t, 3)
+	high := NewProposalNumber(50, "rival")
+	for _, id := range []string{"a0", "a1"} {
+		if err := net.acceptor(id).SetStateForTest(high, ProposalNumber{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newTimedProposer(t, net)
+	if _, err := p.Propose([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	got := p.LastTimings()
+	if got.Retries < 1 || len(got.Attempts) != got.Retries+1 {
+		t.Fatalf("timings = %+v, want a retry after the rejected Phase 1", got)
+	}
+	first, last := got.Attempts[0], got.Attempts[len(got.Attempts)-1]
+	if first.Phase1 != 30*time.Millisecond || first.Phase2 != 0 {
+		t.Fatalf("rejected attempt = %+v, want only its 30ms Phase 1", first)
+	}
+	if last.Phase1 != 30*time.Millisecond || last.Phase2 != 90*time.Millisecond {
+		t.Fatalf("winning attempt = %+v, want 30ms and 90ms", last)
+	}
+	var phase1 time.Duration
+	for _, a := range got.Attempts {
+		phase1 += a.Phase1
+	}
+	if got.Phase1 != phase1 || got.Phase2 != 90*time.Millisecond {
+		t.Fatalf("totals %v/%v don't sum the attempts %+v", got.Phase1, got.Phase2, got.Attempts)
+	}
+}
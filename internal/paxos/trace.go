@@ -0,0 +1,109 @@
+// =============================================================================
+// TRACE - What Each Attempt of a Proposal Saw
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// ProposeResult says how many rounds a proposal took; LastTimings says how
+// long they took. Neither says why a round failed. ProposeWithTrace returns
+// a record of every attempt alongside the result:
+//
+//   value, trace, err := p.ProposeWithTrace(value)
+//   for _, a := range trace.Attempts {
+//       // a.Proposal:          the number this attempt used
+//       // a.Promised:          acceptors that promised, in arrival order
+//       // a.Accepted:          acceptors that accepted
+//       // a.Rejected:          who rejected, in which phase, having seen what
+//       // a.Adopted:           Phase 1 found an accepted value and took it
+//       // a.Err:               why the attempt ended, nil for the winning one
+//   }
+//
+// Only responses to the attempt's own number are recorded; stale ones are
+// counted by StaleResponses instead. A phase that ends at the first
+// rejection records only responses that arrived before it.
+//
+// =============================================================================
+
+package paxos
+
+import "context"
+
+type TraceRejection struct {
+	From        string
+	Phase       int
+	HighestSeen ProposalNumber
+}
+
+type TraceAttempt struct {
+	Proposal    ProposalNumber
+	Promised    []string
+	Accepted    []string
+	Rejected    []TraceRejection
+	Adopted     bool
+	AdoptedFrom ProposalNumber
+	Err         error
+}
+
+type ProposeTrace struct {
+	Attempts []TraceAttempt
+}
+
+// ProposeWithTrace is Propose, also returning a trace of every attempt it
+// made. The trace is returned on failure too.
+func (p *Proposer) ProposeWithTrace(value []byte) ([]byte, ProposeTrace, error) {
+	return p.ProposeWithTraceContext(context.Background(), value)
+}
+
+// ProposeWithTraceContext is ProposeWithTrace, giving up when ctx is done.
+func (p *Proposer) ProposeWithTraceContext(ctx context.Context, value []byte) ([]byte, ProposeTrace, error) {
+	var trace ProposeTrace
+	result, err := p.proposeTraced(ctx, 0, value, &trace, nil)
+	return result.Value, trace, err
+}
+
+func (p *Proposer) traceAttempt(proposal ProposalNumber) {
+	if p.trace != nil {
+		p.trace.Attempts = append(p.trace.Attempts, TraceAttempt{Proposal: proposal})
+	}
+}
+
+// current is the attempt being recorded, or nil when nothing is traced.
+func (p *Proposer) current() *TraceAttempt {
+	if p.trace == nil || len(p.trace.Attempts) == 0 {
+		return nil
+	}
+	return &p.trace.Attempts[len(p.trace.Attempts)-1]
+}
+
+func (p *Proposer) tracePromise(from string) {
+	if a := p.current(); a != nil {
+		a.Promised = append(a.Promised, from)
+	}
+}
+
+func (p *Proposer) traceAccepted(from string) {
+	if a := p.current(); a != nil {
+		a.Accepted = append(a.Accepted, from)
+	}
+}
+
+func (p *Proposer) traceRejection(phase int, from string, highestSeen ProposalNumber) {
+	if a := p.current(); a != nil {
+		a.Rejected = append(a.Rejected, TraceRejection{From: from, Phase: phase, HighestSeen: highestSeen})
+	}
+}
+
+func (p *Proposer) traceAdopted(from ProposalNumber) {
+	if a := p.current(); a != nil && !from.IsZero() {
+		a.Adopted = true
+		a.AdoptedFrom = from
+	}
+}
+
+func (p *Proposer) traceEnd(err error) {
+	if a := p.current(); a != nil {
+		a.Err = err
+	}
+}
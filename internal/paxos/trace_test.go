@@ -0,0 +1,51 @@
+package paxos
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTraceRecordsRejectedFirstAttempt(t *testing.T) {
+	net := newTestNet(t, 3)
+	rival := NewProposalNumber(1, "rival")
+	for _, id := range []string{"a0", "a1"} {
+		if err := net.acceptor(id).SetStateForTest(rival, ProposalNumber{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newTestProposer(t, net, "p1")
+	chosen, trace, err := p.ProposeWithTrace([]byte("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chosen) != "A" {
+		t.Fatalf("chose %q, want A", chosen)
+	}
+	if len(trace.Attempts) != 2 {
+		t.Fatalf("trace has %d attempts, want 2: %+v", len(trace.Attempts), trace.Attempts)
+	}
+
+	first, second := trace.Attempts[0], trace.Attempts[1]
+	if !errors.Is(first.Err, ErrRejected) {
+		t.Fatalf("first attempt ended with %v, want a rejection", first.Err)
+	}
+	if len(first.Rejected) == 0 {
+		t.Fatal("first attempt records no rejection")
+	}
+	if r := first.Rejected[0]; r.From != "a0" || r.Phase != 1 || r.HighestSeen != rival {
+		t.Fatalf("rejection = %+v, want a0 in phase 1 having seen %v", r, rival)
+	}
+	if len(first.Accepted) != 0 {
+		t.Fatalf("first attempt reached phase 2: accepted by %v", first.Accepted)
+	}
+
+	if second.Err != nil || !second.Proposal.GreaterThan(rival) {
+		t.Fatalf("second attempt = %+v, want success above %v", second, rival)
+	}
+	if len(second.Rejected) != 0 || len(second.Promised) < 2 || len(second.Accepted) < 2 {
+		t.Fatalf("second attempt = %+v, want a clean quorum", second)
+	}
+	if second.Adopted {
+		t.Fatal("second attempt adopted a value nobody accepted")
+	}
+}
@@ -0,0 +1,54 @@
+package paxos
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// untouched fails the test if a proposal reached any acceptor.
+func untouched(t *testing.T, net *testNet) {
+	t.Helper()
+	for _, id := range net.ids {
+		if promised, _, _ := net.acceptor(id).GetState(); promised != (ProposalNumber{}) {
+			t.Fatalf("%s promised %v: the value was sent before it was checked", id, promised)
+		}
+	}
+}
+
+func TestProposeRejectsOversizedValue(t *testing.T) {
+	net := newTestNet(t, 3)
+	p := newTestProposer(t, net, "p1", WithMaxValueSize(4))
+	if _, err := p.Propose([]byte("12345")); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("5-byte value: err = %v, want ErrValueTooLarge", err)
+	}
+	untouched(t, net)
+	if _, err := p.Propose([]byte("1234")); err != nil {
+		t.Fatalf("4-byte value: %v", err)
+	}
+}
+
+func TestProposeDefaultMaxValueSize(t *testing.T) {
+	net := newTestNet(t, 3)
+	p := newTestProposer(t, net, "p1")
+	if _, err := p.Propose(make([]byte, DefaultMaxValueSize+1)); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("err = %v, want ErrValueTooLarge", err)
+	}
+	untouched(t, net)
+}
+
+func TestProposeNilValue(t *testing.T) {
+	net := newTestNet(t, 3)
+	if _, err := newTestProposer(t, net, "p1").Propose(nil); !errors.Is(err, ErrEmptyValue) {
+		t.Fatalf("nil value: err = %v, want ErrEmptyValue", err)
+	}
+	untouched(t, net)
+
+	chosen, err := newTestProposer(t, net, "p2", WithAllowEmptyValue()).Propose(nil)
+	if err != nil {
+		t.Fatalf("nil value with WithAllowEmptyValue: %v", err)
+	}
+	if !bytes.Equal(chosen, nil) {
+		t.Fatalf("chose %q, want the empty value", chosen)
+	}
+}
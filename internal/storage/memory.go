@@ -168,6 +168,16 @@
 //
 // Each test gets a fresh storage - no cleanup needed, no interference.
 //
+// SLOW DISKS: memory writes are instant, which hides how an acceptor
+// behaves when persistence is slow. SetWriteLatency(d) makes every Save*
+// and Sync sleep for d first, so a test can push an acceptor's reply past
+// the proposer's WithQuorumTimeout:
+//
+//   s := NewMemoryStorage()
+//   s.SetWriteLatency(50 * time.Millisecond)
+//
+// The sleep happens before the lock is taken; loads stay fast.
+//
 // =============================================================================
 // MULTI-PAXOS EXTENSION POINT
 // =============================================================================
@@ -184,20 +194,46 @@
 
 package storage
 
-import "sync"
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type MemoryStorage struct {
 	highestPromised  ProposalNumber
 	acceptedProposal ProposalNumber
 	acceptedValue    []byte
+	slots            map[int64]*SlotState
+	highestSlot      int64
+	round            int64
+	applied          int64
 	mu               sync.RWMutex
+	writeLatency     atomic.Int64
 }
 
 func NewMemoryStorage() *MemoryStorage {
-	return &MemoryStorage{}
+	return &MemoryStorage{
+		slots:       make(map[int64]*SlotState),
+		highestSlot: -1,
+		applied:     -1,
+	}
+}
+
+// SetWriteLatency makes every later Save* and Sync take d. 0 turns it off.
+func (m *MemoryStorage) SetWriteLatency(d time.Duration) {
+	m.writeLatency.Store(int64(d))
+}
+
+func (m *MemoryStorage) slowWrite() {
+	if d := time.Duration(m.writeLatency.Load()); d > 0 {
+		time.Sleep(d)
+	}
 }
 
 func (m *MemoryStorage) SavePromised(proposal ProposalNumber) error {
+	m.slowWrite()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.highestPromised = proposal
@@ -211,6 +247,7 @@ func (m *MemoryStorage) LoadPromised() (ProposalNumber, error) {
 }
 
 func (m *MemoryStorage) SaveAccepted(proposal ProposalNumber, value []byte) error {
+	m.slowWrite()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.acceptedProposal = proposal
@@ -227,12 +264,75 @@ func (m *MemoryStorage) LoadAccepted() (ProposalNumber, []byte, error) {
 	return m.acceptedProposal, result, nil
 }
 
-func (m *MemoryStorage) Close() error {
+func (m *MemoryStorage) SaveSlot(slot int64, state SlotState) error {
+	m.slowWrite()
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.highestPromised = ProposalNumber{}
-	m.acceptedProposal = ProposalNumber{}
-	m.acceptedValue = nil
+	stored := copySlotState(state)
+	m.slots[slot] = &stored
+	if slot > m.highestSlot {
+		m.highestSlot = slot
+	}
+	return nil
+}
+
+func (m *MemoryStorage) LoadSlot(slot int64) (SlotState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stored, ok := m.slots[slot]
+	if !ok {
+		return SlotState{}, nil
+	}
+	return copySlotState(*stored), nil
+}
+
+func (m *MemoryStorage) GetHighestSlot() (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.highestSlot, nil
+}
+
+func (m *MemoryStorage) Slots() ([]int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return sortedSlots(m.slots), nil
+}
+
+func (m *MemoryStorage) SaveRound(round int64) error {
+	m.slowWrite()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.round = round
+	return nil
+}
+
+func (m *MemoryStorage) LoadRound() (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.round, nil
+}
+
+func (m *MemoryStorage) SaveApplied(slot int64) error {
+	m.slowWrite()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applied = slot
+	return nil
+}
+
+func (m *MemoryStorage) LoadApplied() (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.applied, nil
+}
+
+func (m *MemoryStorage) Sync() error {
+	m.slowWrite()
+	return nil
+}
+
+func (m *MemoryStorage) Close() error {
+	m.Reset()
 	return nil
 }
 
@@ -242,4 +342,29 @@ func (m *MemoryStorage) Reset() {
 	m.highestPromised = ProposalNumber{}
 	m.acceptedProposal = ProposalNumber{}
 	m.acceptedValue = nil
+	m.slots = make(map[int64]*SlotState)
+	m.highestSlot = -1
+	m.round = 0
+	m.applied = -1
+}
+
+// sortedSlots returns the keys of a slot map in ascending order.
+func sortedSlots[V any](slots map[int64]V) []int64 {
+	out := make([]int64, 0, len(slots))
+	for slot := range slots {
+		out = append(out, slot)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func copySlotState(state SlotState) SlotState {
+	out := state
+	out.AcceptedValue = make([]byte, len(state.AcceptedValue))
+	copy(out.AcceptedValue, state.AcceptedValue)
+	if state.ChosenValue != nil {
+		out.ChosenValue = make([]byte, len(state.ChosenValue))
+		copy(out.ChosenValue, state.ChosenValue)
+	}
+	return out
 }
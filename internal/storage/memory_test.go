@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemoryStorageSlots(t *testing.T) {
+	s := NewMemoryStorage()
+	for _, slot := range []int64{0, 5, 10} {
+		state := SlotState{
+			AcceptedProposal: ProposalNumber{Round: slot + 1, ProposerID: "n0"},
+			AcceptedValue:    []byte{byte(slot)},
+		}
+		if err := s.SaveSlot(slot, state); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	highest, err := s.GetHighestSlot()
+	if err != nil || highest != 10 {
+		t.Fatalf("GetHighestSlot = %d, %v, want 10", highest, err)
+	}
+	if slots, err := s.Slots(); err != nil || !reflect.DeepEqual(slots, []int64{0, 5, 10}) {
+		t.Fatalf("Slots = %v, %v, want [0 5 10]", slots, err)
+	}
+	for _, slot := range []int64{0, 5, 10} {
+		got, err := s.LoadSlot(slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.AcceptedProposal.Round != slot+1 || !reflect.DeepEqual(got.AcceptedValue, []byte{byte(slot)}) {
+			t.Fatalf("slot %d loaded as %+v", slot, got)
+		}
+	}
+	for _, slot := range []int64{1, 4, 6, 9} {
+		got, err := s.LoadSlot(slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, SlotState{}) {
+			t.Fatalf("gap at slot %d loaded as %+v, want the zero value", slot, got)
+		}
+	}
+}
+
+func TestMemoryStorageSlotIsCopied(t *testing.T) {
+	s := NewMemoryStorage()
+	value := []byte("a")
+	if err := s.SaveSlot(0, SlotState{AcceptedValue: value}); err != nil {
+		t.Fatal(err)
+	}
+	value[0] = 'b'
+	got, _ := s.LoadSlot(0)
+	got.AcceptedValue[0] = 'c'
+	if again, _ := s.LoadSlot(0); string(again.AcceptedValue) != "a" {
+		t.Fatalf("stored value changed to %q through a caller's slice", again.AcceptedValue)
+	}
+}
+
+func TestMemoryStorageWriteLatency(t *testing.T) {
+	s := NewMemoryStorage()
+	s.SetWriteLatency(20 * time.Millisecond)
+	start := time.Now()
+	if err := s.SavePromised(ProposalNumber{Round: 1, ProposerID: "p1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took < 40*time.Millisecond {
+		t.Fatalf("a save and a sync took %v, want at least 40ms", took)
+	}
+	// Loads never sleep, and clearing the latency stops saves sleeping. A
+	// latency far above the bound keeps the check clear of scheduler noise.
+	s.SetWriteLatency(5 * time.Second)
+	start = time.Now()
+	if _, err := s.LoadPromised(); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("a load took %v under a 5s write latency", took)
+	}
+	s.SetWriteLatency(0)
+	start = time.Now()
+	if err := s.SaveRound(3); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("an unthrottled save took %v", took)
+	}
+}
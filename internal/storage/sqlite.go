@@ -0,0 +1,230 @@
+// =============================================================================
+// SQLITE STORAGE - Acceptor State You Can Query
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// FileStorage and StreamStorage are durable but opaque: reading them means
+// writing a decoder. SQLiteStorage keeps the same state in tables that
+// any SQLite client can inspect:
+//
+//   acceptor (id = 0,                     -- a single row
+//             promised_round, promised_proposer,
+//             accepted_round, accepted_proposer, value,
+//             round)
+//
+//   applied  (id = 0, slot)               -- a single row, -1 until set
+//
+//   slots    (slot PRIMARY KEY,
+//             promised_round, promised_proposer,
+//             accepted_round, accepted_proposer, value,
+//             chosen, chosen_value)
+//
+//   sqlite3 node1.db 'SELECT slot, accepted_round, chosen FROM slots'
+//
+// =============================================================================
+// DRIVER
+// =============================================================================
+//
+// The module has no dependencies, so this file only uses database/sql. The
+// caller opens the database with whichever SQLite driver their binary links
+// in and hands over the *sql.DB:
+//
+//   import _ "modernc.org/sqlite"
+//
+//   db, _ := sql.Open("sqlite", "node1.db")
+//   s, _ := storage.NewSQLiteStorage(db)
+//
+// The SQL is plain SQLite (upserts need 3.24 or later). Close closes db.
+//
+// =============================================================================
+// DURABILITY
+// =============================================================================
+//
+// NewSQLiteStorage sets PRAGMA synchronous=FULL, so SQLite fsyncs at every
+// commit, and limits the pool to one connection so the pragma applies to
+// every statement. Each Save* call is one transaction: when it returns the
+// row is on disk, which is what the acceptor needs before it replies.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"database/sql"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS acceptor (
+	id                INTEGER PRIMARY KEY CHECK (id = 0),
+	promised_round    INTEGER NOT NULL DEFAULT 0,
+	promised_proposer TEXT    NOT NULL DEFAULT '',
+	accepted_round    INTEGER NOT NULL DEFAULT 0,
+	accepted_proposer TEXT    NOT NULL DEFAULT '',
+	value             BLOB,
+	round             INTEGER NOT NULL DEFAULT 0
+);
+INSERT OR IGNORE INTO acceptor (id) VALUES (0);
+CREATE TABLE IF NOT EXISTS slots (
+	slot              INTEGER PRIMARY KEY,
+	promised_round    INTEGER NOT NULL,
+	promised_proposer TEXT    NOT NULL,
+	accepted_round    INTEGER NOT NULL,
+	accepted_proposer TEXT    NOT NULL,
+	value             BLOB,
+	chosen            INTEGER NOT NULL,
+	chosen_value      BLOB
+);
+CREATE TABLE IF NOT EXISTS applied (
+	id                INTEGER PRIMARY KEY CHECK (id = 0),
+	slot              INTEGER NOT NULL DEFAULT -1
+);
+INSERT OR IGNORE INTO applied (id) VALUES (0);
+`
+
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage creates the schema in db if it is missing and returns a
+// Storage backed by it. See the banner above for how to open db.
+func NewSQLiteStorage(db *sql.DB) (*SQLiteStorage, error) {
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA synchronous=FULL"); err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, err
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+func (s *SQLiteStorage) SavePromised(proposal ProposalNumber) error {
+	return s.exec(`UPDATE acceptor SET promised_round = ?, promised_proposer = ? WHERE id = 0`,
+		proposal.Round, proposal.ProposerID)
+}
+
+func (s *SQLiteStorage) LoadPromised() (ProposalNumber, error) {
+	var p ProposalNumber
+	err := s.db.QueryRow(`SELECT promised_round, promised_proposer FROM acceptor WHERE id = 0`).
+		Scan(&p.Round, &p.ProposerID)
+	return p, err
+}
+
+func (s *SQLiteStorage) SaveAccepted(proposal ProposalNumber, value []byte) error {
+	return s.exec(`UPDATE acceptor SET accepted_round = ?, accepted_proposer = ?, value = ? WHERE id = 0`,
+		proposal.Round, proposal.ProposerID, value)
+}
+
+func (s *SQLiteStorage) LoadAccepted() (ProposalNumber, []byte, error) {
+	var p ProposalNumber
+	var value []byte
+	err := s.db.QueryRow(`SELECT accepted_round, accepted_proposer, value FROM acceptor WHERE id = 0`).
+		Scan(&p.Round, &p.ProposerID, &value)
+	return p, value, err
+}
+
+func (s *SQLiteStorage) SaveSlot(slot int64, state SlotState) error {
+	return s.exec(`INSERT INTO slots
+		(slot, promised_round, promised_proposer, accepted_round, accepted_proposer, value, chosen, chosen_value)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (slot) DO UPDATE SET
+			promised_round = excluded.promised_round,
+			promised_proposer = excluded.promised_proposer,
+			accepted_round = excluded.accepted_round,
+			accepted_proposer = excluded.accepted_proposer,
+			value = excluded.value,
+			chosen = excluded.chosen,
+			chosen_value = excluded.chosen_value`,
+		slot,
+		state.HighestPromised.Round, state.HighestPromised.ProposerID,
+		state.AcceptedProposal.Round, state.AcceptedProposal.ProposerID,
+		state.AcceptedValue, state.Chosen, state.ChosenValue)
+}
+
+func (s *SQLiteStorage) LoadSlot(slot int64) (SlotState, error) {
+	var st SlotState
+	err := s.db.QueryRow(`SELECT promised_round, promised_proposer, accepted_round, accepted_proposer,
+		value, chosen, chosen_value FROM slots WHERE slot = ?`, slot).
+		Scan(&st.HighestPromised.Round, &st.HighestPromised.ProposerID,
+			&st.AcceptedProposal.Round, &st.AcceptedProposal.ProposerID,
+			&st.AcceptedValue, &st.Chosen, &st.ChosenValue)
+	if err == sql.ErrNoRows {
+		return SlotState{}, nil
+	}
+	return st, err
+}
+
+func (s *SQLiteStorage) GetHighestSlot() (int64, error) {
+	var highest sql.NullInt64
+	if err := s.db.QueryRow(`SELECT MAX(slot) FROM slots`).Scan(&highest); err != nil {
+		return -1, err
+	}
+	if !highest.Valid {
+		return -1, nil
+	}
+	return highest.Int64, nil
+}
+
+func (s *SQLiteStorage) Slots() ([]int64, error) {
+	rows, err := s.db.Query(`SELECT slot FROM slots ORDER BY slot`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var slots []int64
+	for rows.Next() {
+		var slot int64
+		if err := rows.Scan(&slot); err != nil {
+			return nil, err
+		}
+		slots = append(slots, slot)
+	}
+	return slots, rows.Err()
+}
+
+func (s *SQLiteStorage) SaveRound(round int64) error {
+	return s.exec(`UPDATE acceptor SET round = ? WHERE id = 0`, round)
+}
+
+func (s *SQLiteStorage) LoadRound() (int64, error) {
+	var round int64
+	err := s.db.QueryRow(`SELECT round FROM acceptor WHERE id = 0`).Scan(&round)
+	return round, err
+}
+
+func (s *SQLiteStorage) SaveApplied(slot int64) error {
+	return s.exec(`UPDATE applied SET slot = ? WHERE id = 0`, slot)
+}
+
+func (s *SQLiteStorage) LoadApplied() (int64, error) {
+	var slot int64
+	err := s.db.QueryRow(`SELECT slot FROM applied WHERE id = 0`).Scan(&slot)
+	return slot, err
+}
+
+// Sync has nothing to do: with synchronous=FULL every Save* commit has
+// already reached disk.
+func (s *SQLiteStorage) Sync() error {
+	return nil
+}
+
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// exec runs one statement in its own transaction, so it is committed and
+// synced before Save* returns.
+func (s *SQLiteStorage) exec(query string, args ...interface{}) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,324 @@
+package storage
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The module links no SQLite driver, so the test drives the sqlite3 shell
+// instead: cliDriver binds arguments into the SQL text, runs each statement
+// (or each transaction, at Commit) in one sqlite3 process, and parses the
+// rows from ".mode quote" output.
+func init() {
+	sql.Register("sqlite3cli", cliDriver{})
+}
+
+type cliDriver struct{}
+
+func (cliDriver) Open(path string) (driver.Conn, error) {
+	return &cliConn{path: path}, nil
+}
+
+type cliConn struct {
+	path string
+	tx   []string
+	inTx bool
+}
+
+func (c *cliConn) run(script string) ([]byte, error) {
+	cmd := exec.Command("sqlite3", "-bail", "-batch", "-cmd", ".mode quote", c.path)
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: %v: %s", err, stderr.String())
+	}
+	return out, nil
+}
+
+func (c *cliConn) Prepare(query string) (driver.Stmt, error) {
+	return &cliStmt{conn: c, query: query}, nil
+}
+
+func (c *cliConn) Close() error { return nil }
+
+func (c *cliConn) Begin() (driver.Tx, error) {
+	c.tx, c.inTx = nil, true
+	return c, nil
+}
+
+func (c *cliConn) Commit() error {
+	script := "BEGIN;\n" + strings.Join(c.tx, ";\n") + ";\nCOMMIT;\n"
+	c.tx, c.inTx = nil, false
+	_, err := c.run(script)
+	return err
+}
+
+func (c *cliConn) Rollback() error {
+	c.tx, c.inTx = nil, false
+	return nil
+}
+
+type cliStmt struct {
+	conn  *cliConn
+	query string
+}
+
+func (s *cliStmt) Close() error  { return nil }
+func (s *cliStmt) NumInput() int { return -1 }
+
+func (s *cliStmt) Exec(args []driver.Value) (driver.Result, error) {
+	script, err := bind(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if s.conn.inTx {
+		s.conn.tx = append(s.conn.tx, script)
+		return driver.RowsAffected(0), nil
+	}
+	_, err = s.conn.run(script + ";\n")
+	return driver.RowsAffected(0), err
+}
+
+func (s *cliStmt) Query(args []driver.Value) (driver.Rows, error) {
+	script, err := bind(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.conn.run(script + ";\n")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := parseQuoted(string(out))
+	if err != nil {
+		return nil, err
+	}
+	return &cliRows{rows: rows}, nil
+}
+
+func bind(query string, args []driver.Value) (string, error) {
+	var b strings.Builder
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		if len(args) == 0 {
+			return "", errors.New("too few arguments")
+		}
+		switch v := args[0].(type) {
+		case nil:
+			b.WriteString("NULL")
+		case int64:
+			b.WriteString(strconv.FormatInt(v, 10))
+		case bool:
+			if v {
+				b.WriteString("1")
+			} else {
+				b.WriteString("0")
+			}
+		case string:
+			b.WriteString("'" + strings.ReplaceAll(v, "'", "''") + "'")
+		case []byte:
+			if v == nil {
+				b.WriteString("NULL")
+			} else {
+				b.WriteString("X'" + hex.EncodeToString(v) + "'")
+			}
+		default:
+			return "", fmt.Errorf("cannot bind %T", v)
+		}
+		args = args[1:]
+	}
+	return b.String(), nil
+}
+
+// parseQuoted reads ".mode quote" output: SQL literals separated by commas,
+// one row per line. Strings may themselves span lines.
+func parseQuoted(out string) ([][]driver.Value, error) {
+	var rows [][]driver.Value
+	var row []driver.Value
+	for i := 0; i < len(out); {
+		switch {
+		case out[i] == '\n':
+			rows, row = append(rows, row), nil
+			i++
+		case out[i] == ',':
+			i++
+		case out[i] == '\'':
+			var s strings.Builder
+			for i++; ; i++ {
+				if i >= len(out) {
+					return nil, errors.New("unterminated string")
+				}
+				if out[i] == '\'' {
+					if i+1 < len(out) && out[i+1] == '\'' {
+						s.WriteByte('\'')
+						i++
+						continue
+					}
+					i++
+					break
+				}
+				s.WriteByte(out[i])
+			}
+			row = append(row, s.String())
+		case strings.HasPrefix(out[i:], "X'"):
+			end := strings.IndexByte(out[i+2:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated blob")
+			}
+			blob, err := hex.DecodeString(out[i+2 : i+2+end])
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, blob)
+			i += end + 3
+		case strings.HasPrefix(out[i:], "NULL"):
+			row = append(row, nil)
+			i += 4
+		default:
+			end := strings.IndexAny(out[i:], ",\n")
+			if end < 0 {
+				end = len(out) - i
+			}
+			n, err := strconv.ParseInt(out[i:i+end], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, n)
+			i += end
+		}
+	}
+	if row != nil {
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+type cliRows struct {
+	rows [][]driver.Value
+}
+
+// Columns only needs the right count; Scan goes by position.
+func (r *cliRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *cliRows) Close() error { return nil }
+
+func (r *cliRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func openSQLite(t *testing.T, path string) *SQLiteStorage {
+	t.Helper()
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("no sqlite3 shell to drive the database with")
+	}
+	db, err := sql.Open("sqlite3cli", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSQLiteStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSQLiteStorageSurvivesReopen(t *testing.T) {
+	path := t.TempDir() + "/node.db"
+	s := openSQLite(t, path)
+	promised := ProposalNumber{Round: 7, ProposerID: "n2"}
+	accepted := ProposalNumber{Round: 6, ProposerID: "n1"}
+	slots := map[int64]SlotState{
+		0: {HighestPromised: promised, AcceptedProposal: accepted, AcceptedValue: []byte("it's"), Chosen: true, ChosenValue: []byte("it's")},
+		3: {HighestPromised: promised, AcceptedProposal: accepted, AcceptedValue: []byte{0, 1, '\n', 0xff}},
+	}
+	for _, err := range []error{
+		s.SavePromised(promised),
+		s.SaveAccepted(accepted, []byte("v")),
+		s.SaveSlot(0, slots[0]),
+		s.SaveSlot(3, slots[3]),
+		s.SaveRound(9),
+		s.SaveApplied(0),
+		s.Close(),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s = openSQLite(t, path)
+	defer s.Close()
+	if got, err := s.LoadPromised(); err != nil || got != promised {
+		t.Fatalf("LoadPromised = %v, %v, want %v", got, err, promised)
+	}
+	if got, v, err := s.LoadAccepted(); err != nil || got != accepted || string(v) != "v" {
+		t.Fatalf("LoadAccepted = %v %q, %v", got, v, err)
+	}
+	for slot, want := range slots {
+		got, err := s.LoadSlot(slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.HighestPromised != want.HighestPromised || got.AcceptedProposal != want.AcceptedProposal ||
+			got.Chosen != want.Chosen || !bytes.Equal(got.AcceptedValue, want.AcceptedValue) || !bytes.Equal(got.ChosenValue, want.ChosenValue) {
+			t.Fatalf("slot %d = %+v, want %+v", slot, got, want)
+		}
+	}
+	if got, err := s.LoadSlot(1); err != nil || got.Chosen || got.HighestPromised != (ProposalNumber{}) {
+		t.Fatalf("unwritten slot 1 = %+v, %v", got, err)
+	}
+	if got, err := s.GetHighestSlot(); err != nil || got != 3 {
+		t.Fatalf("GetHighestSlot = %d, %v, want 3", got, err)
+	}
+	if got, err := s.Slots(); err != nil || len(got) != 2 || got[0] != 0 || got[1] != 3 {
+		t.Fatalf("Slots = %v, %v, want [0 3]", got, err)
+	}
+	if got, err := s.LoadRound(); err != nil || got != 9 {
+		t.Fatalf("LoadRound = %d, %v, want 9", got, err)
+	}
+	if got, err := s.LoadApplied(); err != nil || got != 0 {
+		t.Fatalf("LoadApplied = %d, %v, want 0", got, err)
+	}
+
+	// The rows read the same from outside the process.
+	out, err := exec.Command("sqlite3", path, "SELECT slot, promised_round, promised_proposer, accepted_round, chosen FROM slots ORDER BY slot").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0|7|n2|6|1\n3|7|n2|6|0\n"; string(out) != want {
+		t.Fatalf("slots table:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestSQLiteStorageEmpty(t *testing.T) {
+	s := openSQLite(t, t.TempDir()+"/node.db")
+	defer s.Close()
+	if got, err := s.GetHighestSlot(); err != nil || got != -1 {
+		t.Fatalf("GetHighestSlot = %d, %v, want -1", got, err)
+	}
+	if got, err := s.LoadApplied(); err != nil || got != -1 {
+		t.Fatalf("LoadApplied = %d, %v, want -1", got, err)
+	}
+}
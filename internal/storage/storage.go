@@ -54,8 +54,20 @@
 // 3. AcceptedValue ([]byte)
 //    - The value we accepted
 //
+// The proposer side has one durable value of its own:
+//
+// 4. Round (int64)
+//    - The highest round this node has handed out or reserved, so a
+//      restarted node never reuses a proposal number (see node/rounds.go)
+//
+// And the state machine side has one:
+//
+// 5. Applied (int64)
+//    - The last slot handed to a durable apply function, -1 before the
+//      first, so a restarted node doesn't apply it twice (see node/apply.go)
+//
 // These can be stored as:
-// - Three separate keys
+// - Separate keys
 // - One serialized struct
 // - Any format that survives crashes
 //
@@ -176,6 +188,25 @@
 // You might also want "SavePromised" to apply globally (leader lease),
 // not per slot.
 //
+// This is what the interface below does: SaveSlot/LoadSlot hold each slot's
+// independent acceptor state, GetHighestSlot tells a restarting node how far
+// the log reached (-1 if no slot was ever saved), and SavePromised/
+// SaveAccepted remain the global, leader-wide state. A slot that was never
+// saved loads as the zero SlotState, exactly like a fresh acceptor.
+//
+// Sync makes every Save* that has returned durable. The acceptor calls it
+// after saving and before it replies, so "persist, then respond" is one
+// visible call rather than a promise each backend keeps on its own.
+// Backends that already sync on every save can make it cheap; in-memory
+// ones make it a no-op.
+//
+// Chosen/ChosenValue are not acceptor state. An acceptor alone can never
+// know whether its value was chosen; the node records them once its learner
+// has seen a quorum, so that a restart can rebuild the log without waiting
+// for the slot to be re-learned. ChosenValue is kept separate from
+// AcceptedValue because this acceptor may have accepted an older value
+// than the one that won.
+//
 // =============================================================================
 
 package storage
@@ -185,11 +216,30 @@ type ProposalNumber struct {
 	ProposerID string
 }
 
+type SlotState struct {
+	HighestPromised  ProposalNumber
+	AcceptedProposal ProposalNumber
+	AcceptedValue    []byte
+	Chosen           bool
+	ChosenValue      []byte
+}
+
 type Storage interface {
 	SavePromised(proposal ProposalNumber) error
 	LoadPromised() (ProposalNumber, error)
 	SaveAccepted(proposal ProposalNumber, value []byte) error
 	LoadAccepted() (ProposalNumber, []byte, error)
+	SaveSlot(slot int64, state SlotState) error
+	LoadSlot(slot int64) (SlotState, error)
+	GetHighestSlot() (int64, error)
+	// Slots lists every slot with saved state, in ascending order, so
+	// callers walking the log needn't probe each number up to the highest.
+	Slots() ([]int64, error)
+	SaveRound(round int64) error
+	LoadRound() (int64, error)
+	SaveApplied(slot int64) error
+	LoadApplied() (int64, error)
+	Sync() error
 	Close() error
 }
 
@@ -197,10 +247,14 @@ type InMemoryStorage struct {
 	promised ProposalNumber
 	accepted ProposalNumber
 	value    []byte
+	slots    map[int64]SlotState
+	highest  int64
+	round    int64
+	applied  int64
 }
 
 func NewInMemoryStorage() Storage {
-	return &InMemoryStorage{}
+	return &InMemoryStorage{slots: make(map[int64]SlotState), highest: -1, applied: -1}
 }
 
 func (s *InMemoryStorage) SavePromised(proposal ProposalNumber) error {
@@ -222,6 +276,48 @@ func (s *InMemoryStorage) LoadAccepted() (ProposalNumber, []byte, error) {
 	return s.accepted, s.value, nil
 }
 
+func (s *InMemoryStorage) SaveSlot(slot int64, state SlotState) error {
+	s.slots[slot] = state
+	if slot > s.highest {
+		s.highest = slot
+	}
+	return nil
+}
+
+func (s *InMemoryStorage) LoadSlot(slot int64) (SlotState, error) {
+	return s.slots[slot], nil
+}
+
+func (s *InMemoryStorage) GetHighestSlot() (int64, error) {
+	return s.highest, nil
+}
+
+func (s *InMemoryStorage) Slots() ([]int64, error) {
+	return sortedSlots(s.slots), nil
+}
+
+func (s *InMemoryStorage) SaveRound(round int64) error {
+	s.round = round
+	return nil
+}
+
+func (s *InMemoryStorage) LoadRound() (int64, error) {
+	return s.round, nil
+}
+
+func (s *InMemoryStorage) SaveApplied(slot int64) error {
+	s.applied = slot
+	return nil
+}
+
+func (s *InMemoryStorage) LoadApplied() (int64, error) {
+	return s.applied, nil
+}
+
+func (s *InMemoryStorage) Sync() error {
+	return nil
+}
+
 func (s *InMemoryStorage) Close() error {
 	return nil
 }
@@ -0,0 +1,354 @@
+// =============================================================================
+// STREAM STORAGE - Storage Over Any io.ReadWriteSeeker
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// A Storage implementation that knows nothing about files or databases. It
+// writes acceptor state into whatever io.ReadWriteSeeker you hand it:
+//
+// - an *os.File
+// - an mmap'd region wrapped in a seeker
+// - a network-synced blob that exposes Seek/Read/Write
+//
+// This is the escape hatch for custom durable backends.
+//
+// =============================================================================
+// ON-DISK FORMAT
+// =============================================================================
+//
+// The whole state is one length-prefixed record at offset 0:
+//
+//   ┌──────────┬───────────────────────────────────────────────┐
+//   │ len (u32)│ payload                                       │
+//   └──────────┴───────────────────────────────────────────────┘
+//
+//   payload = promised.Round   (i64)
+//             promised.ID      (u32 len + bytes)
+//             accepted.Round   (i64)
+//             accepted.ID      (u32 len + bytes)
+//             value            (u32 len + bytes)
+//             slot count       (u32)
+//             per slot:        slot (i64), promised, accepted, value,
+//                              chosen (u8), chosen value
+//             round            (i64)
+//             applied          (i64)
+//
+// Records written before slots existed simply end after value and load with
+// no slots; records written before rounds existed end after the slots and
+// load with round 0; records written before applied existed end after the
+// round and load with applied -1.
+//
+// Every save seeks back to 0 and rewrites the record. The length prefix is
+// what makes this safe when the new record is shorter than the old one: any
+// stale bytes after the record are never read.
+//
+// =============================================================================
+// DURABILITY
+// =============================================================================
+//
+// If the underlying stream also implements Sync() error (os.File does), it
+// is called after every write. Otherwise durability is whatever the stream
+// provides - document that when you plug in your own.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+var ErrCorruptRecord = errors.New("corrupt storage record")
+
+type syncer interface {
+	Sync() error
+}
+
+type StreamStorage struct {
+	rw               io.ReadWriteSeeker
+	highestPromised  ProposalNumber
+	acceptedProposal ProposalNumber
+	acceptedValue    []byte
+	slots            map[int64]SlotState
+	highestSlot      int64
+	round            int64
+	applied          int64
+	mu               sync.RWMutex
+}
+
+func NewStreamStorage(rw io.ReadWriteSeeker) (*StreamStorage, error) {
+	s := &StreamStorage{
+		rw:          rw,
+		slots:       make(map[int64]SlotState),
+		highestSlot: -1,
+		applied:     -1,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *StreamStorage) SavePromised(proposal ProposalNumber) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.highestPromised = proposal
+	return s.flush()
+}
+
+func (s *StreamStorage) LoadPromised() (ProposalNumber, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.highestPromised, nil
+}
+
+func (s *StreamStorage) SaveAccepted(proposal ProposalNumber, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acceptedProposal = proposal
+	s.acceptedValue = make([]byte, len(value))
+	copy(s.acceptedValue, value)
+	return s.flush()
+}
+
+func (s *StreamStorage) LoadAccepted() (ProposalNumber, []byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]byte, len(s.acceptedValue))
+	copy(result, s.acceptedValue)
+	return s.acceptedProposal, result, nil
+}
+
+func (s *StreamStorage) SaveSlot(slot int64, state SlotState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := copySlotState(state)
+	s.slots[slot] = stored
+	if slot > s.highestSlot {
+		s.highestSlot = slot
+	}
+	return s.flush()
+}
+
+func (s *StreamStorage) LoadSlot(slot int64) (SlotState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stored, ok := s.slots[slot]
+	if !ok {
+		return SlotState{}, nil
+	}
+	return copySlotState(stored), nil
+}
+
+func (s *StreamStorage) GetHighestSlot() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.highestSlot, nil
+}
+
+func (s *StreamStorage) Slots() ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedSlots(s.slots), nil
+}
+
+func (s *StreamStorage) SaveRound(round int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.round = round
+	return s.flush()
+}
+
+func (s *StreamStorage) LoadRound() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.round, nil
+}
+
+func (s *StreamStorage) SaveApplied(slot int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applied = slot
+	return s.flush()
+}
+
+func (s *StreamStorage) LoadApplied() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.applied, nil
+}
+
+// Sync fsyncs the underlying stream if it supports it, e.g. an *os.File.
+func (s *StreamStorage) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.rw.(syncer); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+func (s *StreamStorage) Close() error {
+	if c, ok := s.rw.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *StreamStorage) flush() error {
+	var payload bytes.Buffer
+	writeProposal(&payload, s.highestPromised)
+	writeProposal(&payload, s.acceptedProposal)
+	writeBytes(&payload, s.acceptedValue)
+	binary.Write(&payload, binary.BigEndian, uint32(len(s.slots)))
+	for slot, state := range s.slots {
+		binary.Write(&payload, binary.BigEndian, slot)
+		writeProposal(&payload, state.HighestPromised)
+		writeProposal(&payload, state.AcceptedProposal)
+		writeBytes(&payload, state.AcceptedValue)
+		chosen := byte(0)
+		if state.Chosen {
+			chosen = 1
+		}
+		payload.WriteByte(chosen)
+		writeBytes(&payload, state.ChosenValue)
+	}
+	binary.Write(&payload, binary.BigEndian, s.round)
+	binary.Write(&payload, binary.BigEndian, s.applied)
+
+	record := make([]byte, 4+payload.Len())
+	binary.BigEndian.PutUint32(record, uint32(payload.Len()))
+	copy(record[4:], payload.Bytes())
+
+	if _, err := s.rw.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := s.rw.Write(record); err != nil {
+		return err
+	}
+	if f, ok := s.rw.(syncer); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+func (s *StreamStorage) load() error {
+	if _, err := s.rw.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	var header [4]byte
+	if _, err := io.ReadFull(s.rw, header[:]); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return ErrCorruptRecord
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(s.rw, payload); err != nil {
+		return ErrCorruptRecord
+	}
+	r := bytes.NewReader(payload)
+	var err error
+	if s.highestPromised, err = readProposal(r); err != nil {
+		return err
+	}
+	if s.acceptedProposal, err = readProposal(r); err != nil {
+		return err
+	}
+	if s.acceptedValue, err = readBytes(r); err != nil {
+		return err
+	}
+	if r.Len() == 0 {
+		return nil
+	}
+	var count uint32
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return ErrCorruptRecord
+	}
+	for i := uint32(0); i < count; i++ {
+		var slot int64
+		var state SlotState
+		if err := binary.Read(r, binary.BigEndian, &slot); err != nil {
+			return ErrCorruptRecord
+		}
+		if state.HighestPromised, err = readProposal(r); err != nil {
+			return err
+		}
+		if state.AcceptedProposal, err = readProposal(r); err != nil {
+			return err
+		}
+		if state.AcceptedValue, err = readBytes(r); err != nil {
+			return err
+		}
+		chosen, err := r.ReadByte()
+		if err != nil {
+			return ErrCorruptRecord
+		}
+		state.Chosen = chosen == 1
+		if state.ChosenValue, err = readBytes(r); err != nil {
+			return err
+		}
+		s.slots[slot] = state
+		if slot > s.highestSlot {
+			s.highestSlot = slot
+		}
+	}
+	if r.Len() == 0 {
+		return nil
+	}
+	if err := binary.Read(r, binary.BigEndian, &s.round); err != nil {
+		return ErrCorruptRecord
+	}
+	if r.Len() == 0 {
+		return nil
+	}
+	if err := binary.Read(r, binary.BigEndian, &s.applied); err != nil {
+		return ErrCorruptRecord
+	}
+	return nil
+}
+
+func writeProposal(buf *bytes.Buffer, p ProposalNumber) {
+	binary.Write(buf, binary.BigEndian, p.Round)
+	writeBytes(buf, []byte(p.ProposerID))
+}
+
+func writeBytes(buf *bytes.Buffer, b []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(b)))
+	buf.Write(b)
+}
+
+func readProposal(r *bytes.Reader) (ProposalNumber, error) {
+	var p ProposalNumber
+	if err := binary.Read(r, binary.BigEndian, &p.Round); err != nil {
+		return ProposalNumber{}, ErrCorruptRecord
+	}
+	id, err := readBytes(r)
+	if err != nil {
+		return ProposalNumber{}, err
+	}
+	p.ProposerID = string(id)
+	return p, nil
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, ErrCorruptRecord
+	}
+	if int64(n) > int64(r.Len()) {
+		return nil, ErrCorruptRecord
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, ErrCorruptRecord
+	}
+	return b, nil
+}
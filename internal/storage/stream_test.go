@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// memSeeker is an in-memory io.ReadWriteSeeker, standing in for a file.
+type memSeeker struct {
+	data []byte
+	pos  int64
+}
+
+func (m *memSeeker) Read(p []byte) (int, error) {
+	if m.pos >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[m.pos:])
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memSeeker) Write(p []byte) (int, error) {
+	if end := m.pos + int64(len(p)); end > int64(len(m.data)) {
+		m.data = append(m.data, make([]byte, end-int64(len(m.data)))...)
+	}
+	n := copy(m.data[m.pos:], p)
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		m.pos = offset
+	case io.SeekCurrent:
+		m.pos += offset
+	case io.SeekEnd:
+		m.pos = int64(len(m.data)) + offset
+	}
+	return m.pos, nil
+}
+
+func TestStreamStorageRoundTrip(t *testing.T) {
+	rw := &memSeeker{}
+	s, err := NewStreamStorage(rw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	promised := ProposalNumber{Round: 7, ProposerID: "p1"}
+	accepted := ProposalNumber{Round: 6, ProposerID: "p2"}
+	slot := SlotState{HighestPromised: promised, AcceptedProposal: accepted, AcceptedValue: []byte("v3"), Chosen: true, ChosenValue: []byte("v3")}
+	for _, err := range []error{
+		s.SavePromised(promised),
+		s.SaveAccepted(accepted, []byte("a much longer value than the one that replaces it")),
+		s.SaveAccepted(accepted, []byte("v")),
+		s.SaveSlot(3, slot),
+		s.SaveRound(9),
+		s.SaveApplied(2),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := NewStreamStorage(rw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := reopened.LoadPromised(); got != promised {
+		t.Fatalf("promised = %v, want %v", got, promised)
+	}
+	if got, value, _ := reopened.LoadAccepted(); got != accepted || string(value) != "v" {
+		t.Fatalf("accepted = %v %q, want %v %q", got, value, accepted, "v")
+	}
+	got, _ := reopened.LoadSlot(3)
+	if got.HighestPromised != promised || got.AcceptedProposal != accepted ||
+		!bytes.Equal(got.AcceptedValue, slot.AcceptedValue) || !got.Chosen || !bytes.Equal(got.ChosenValue, slot.ChosenValue) {
+		t.Fatalf("slot 3 = %+v, want %+v", got, slot)
+	}
+	if highest, _ := reopened.GetHighestSlot(); highest != 3 {
+		t.Fatalf("highest slot = %d, want 3", highest)
+	}
+	if round, _ := reopened.LoadRound(); round != 9 {
+		t.Fatalf("round = %d, want 9", round)
+	}
+	if applied, _ := reopened.LoadApplied(); applied != 2 {
+		t.Fatalf("applied = %d, want 2", applied)
+	}
+}
+
+func TestStreamStorageEmptyAndCorrupt(t *testing.T) {
+	s, err := NewStreamStorage(&memSeeker{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied, _ := s.LoadApplied(); applied != -1 {
+		t.Fatalf("fresh storage applied = %d, want -1", applied)
+	}
+	if _, err := NewStreamStorage(&memSeeker{data: []byte{0, 0, 0, 9, 1}}); err == nil {
+		t.Fatal("truncated record loaded without error")
+	}
+}
@@ -0,0 +1,432 @@
+// =============================================================================
+// FILE STORAGE - Durable Acceptor State as a Write-Ahead Log
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// StreamStorage rewrites the whole state on every save. That is simple, but
+// every promise costs a write proportional to the entire log, and a crash
+// in the middle of the rewrite can leave nothing valid behind.
+//
+// FileStorage only ever appends. Each Save* call adds one record describing
+// that change and fsyncs before returning. Opening the file replays the
+// records in order; the last record for a given key wins.
+//
+// =============================================================================
+// RECORD FORMAT
+// =============================================================================
+//
+//   ┌──────────┬──────────┬──────────────────────────────────┐
+//   │ len (u32)│ crc (u32)│ payload                          │
+//   └──────────┴──────────┴──────────────────────────────────┘
+//
+//   payload = kind (u8) followed by
+//     kind 1 (promised): proposal
+//     kind 2 (accepted): proposal, value
+//     kind 3 (slot):     slot (i64), promised, accepted, value,
+//                        chosen (u8), chosen value
+//     kind 4 (round):    round (i64)
+//     kind 5 (applied):  slot (i64)
+//
+// Proposals and byte strings use the same encoding as StreamStorage.
+//
+// =============================================================================
+// TORN WRITES
+// =============================================================================
+//
+// A crash can leave a half-written record at the end of the file. Replay
+// stops at the first record that is short or fails its CRC and truncates the
+// file there. That record's Save never returned, so no promise made to a
+// proposer is lost.
+//
+// =============================================================================
+// CHECKPOINTS
+// =============================================================================
+//
+// Replaying an ever-growing log gets slow. Every checkpointEvery appends the
+// current state is written as a fresh, minimal log to path+".tmp", fsynced,
+// and renamed over the old log. Rename is atomic, so a crash leaves either
+// the old log or the new one, never a mix.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const checkpointEvery = 1000
+
+const (
+	walPromised byte = 1
+	walAccepted byte = 2
+	walSlot     byte = 3
+	walRound    byte = 4
+	walApplied  byte = 5
+)
+
+type FileStorage struct {
+	path             string
+	f                *os.File
+	appends          int
+	highestPromised  ProposalNumber
+	acceptedProposal ProposalNumber
+	acceptedValue    []byte
+	slots            map[int64]SlotState
+	highestSlot      int64
+	round            int64
+	applied          int64
+	mu               sync.RWMutex
+}
+
+func NewFileStorage(path string) (*FileStorage, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileStorage{
+		path:        path,
+		f:           f,
+		slots:       make(map[int64]SlotState),
+		highestSlot: -1,
+		applied:     -1,
+	}
+	if err := s.replay(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStorage) SavePromised(proposal ProposalNumber) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var payload bytes.Buffer
+	payload.WriteByte(walPromised)
+	writeProposal(&payload, proposal)
+	if err := s.append(payload.Bytes()); err != nil {
+		return err
+	}
+	s.highestPromised = proposal
+	return s.maybeCheckpoint()
+}
+
+func (s *FileStorage) LoadPromised() (ProposalNumber, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.highestPromised, nil
+}
+
+func (s *FileStorage) SaveAccepted(proposal ProposalNumber, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var payload bytes.Buffer
+	payload.WriteByte(walAccepted)
+	writeProposal(&payload, proposal)
+	writeBytes(&payload, value)
+	if err := s.append(payload.Bytes()); err != nil {
+		return err
+	}
+	s.acceptedProposal = proposal
+	s.acceptedValue = make([]byte, len(value))
+	copy(s.acceptedValue, value)
+	return s.maybeCheckpoint()
+}
+
+func (s *FileStorage) LoadAccepted() (ProposalNumber, []byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]byte, len(s.acceptedValue))
+	copy(result, s.acceptedValue)
+	return s.acceptedProposal, result, nil
+}
+
+func (s *FileStorage) SaveSlot(slot int64, state SlotState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.append(encodeSlotRecord(slot, state)); err != nil {
+		return err
+	}
+	s.applySlot(slot, copySlotState(state))
+	return s.maybeCheckpoint()
+}
+
+func (s *FileStorage) LoadSlot(slot int64) (SlotState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stored, ok := s.slots[slot]
+	if !ok {
+		return SlotState{}, nil
+	}
+	return copySlotState(stored), nil
+}
+
+func (s *FileStorage) GetHighestSlot() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.highestSlot, nil
+}
+
+func (s *FileStorage) Slots() ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedSlots(s.slots), nil
+}
+
+func (s *FileStorage) SaveRound(round int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.append(encodeRoundRecord(round)); err != nil {
+		return err
+	}
+	s.round = round
+	return s.maybeCheckpoint()
+}
+
+func (s *FileStorage) LoadRound() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.round, nil
+}
+
+func (s *FileStorage) SaveApplied(slot int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.append(encodeAppliedRecord(slot)); err != nil {
+		return err
+	}
+	s.applied = slot
+	return s.maybeCheckpoint()
+}
+
+func (s *FileStorage) LoadApplied() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.applied, nil
+}
+
+func (s *FileStorage) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Sync()
+}
+
+func (s *FileStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+func (s *FileStorage) applySlot(slot int64, state SlotState) {
+	s.slots[slot] = state
+	if slot > s.highestSlot {
+		s.highestSlot = slot
+	}
+}
+
+func encodeSlotRecord(slot int64, state SlotState) []byte {
+	var payload bytes.Buffer
+	payload.WriteByte(walSlot)
+	binary.Write(&payload, binary.BigEndian, slot)
+	writeProposal(&payload, state.HighestPromised)
+	writeProposal(&payload, state.AcceptedProposal)
+	writeBytes(&payload, state.AcceptedValue)
+	chosen := byte(0)
+	if state.Chosen {
+		chosen = 1
+	}
+	payload.WriteByte(chosen)
+	writeBytes(&payload, state.ChosenValue)
+	return payload.Bytes()
+}
+
+func encodeRoundRecord(round int64) []byte {
+	var payload bytes.Buffer
+	payload.WriteByte(walRound)
+	binary.Write(&payload, binary.BigEndian, round)
+	return payload.Bytes()
+}
+
+func encodeAppliedRecord(slot int64) []byte {
+	var payload bytes.Buffer
+	payload.WriteByte(walApplied)
+	binary.Write(&payload, binary.BigEndian, slot)
+	return payload.Bytes()
+}
+
+func frameRecord(payload []byte) []byte {
+	record := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint32(record[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(payload))
+	copy(record[8:], payload)
+	return record
+}
+
+func (s *FileStorage) append(payload []byte) error {
+	if _, err := s.f.Write(frameRecord(payload)); err != nil {
+		return err
+	}
+	s.appends++
+	return s.f.Sync()
+}
+
+func (s *FileStorage) replay() error {
+	info, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	var offset int64
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(s.f, header[:]); err != nil {
+			break
+		}
+		size := int64(binary.BigEndian.Uint32(header[0:]))
+		if offset+int64(len(header))+size > info.Size() {
+			break
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(s.f, payload); err != nil {
+			break
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+			break
+		}
+		if err := s.applyRecord(payload); err != nil {
+			break
+		}
+		offset += int64(len(header) + len(payload))
+		s.appends++
+	}
+	if err := s.f.Truncate(offset); err != nil {
+		return err
+	}
+	_, err = s.f.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (s *FileStorage) applyRecord(payload []byte) error {
+	if len(payload) == 0 {
+		return ErrCorruptRecord
+	}
+	r := bytes.NewReader(payload[1:])
+	switch payload[0] {
+	case walPromised:
+		p, err := readProposal(r)
+		if err != nil {
+			return err
+		}
+		s.highestPromised = p
+	case walAccepted:
+		p, err := readProposal(r)
+		if err != nil {
+			return err
+		}
+		value, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		s.acceptedProposal = p
+		s.acceptedValue = value
+	case walSlot:
+		var slot int64
+		var state SlotState
+		var err error
+		if err := binary.Read(r, binary.BigEndian, &slot); err != nil {
+			return ErrCorruptRecord
+		}
+		if state.HighestPromised, err = readProposal(r); err != nil {
+			return err
+		}
+		if state.AcceptedProposal, err = readProposal(r); err != nil {
+			return err
+		}
+		if state.AcceptedValue, err = readBytes(r); err != nil {
+			return err
+		}
+		chosen, err := r.ReadByte()
+		if err != nil {
+			return ErrCorruptRecord
+		}
+		state.Chosen = chosen == 1
+		if state.ChosenValue, err = readBytes(r); err != nil {
+			return err
+		}
+		s.applySlot(slot, state)
+	case walRound:
+		if err := binary.Read(r, binary.BigEndian, &s.round); err != nil {
+			return ErrCorruptRecord
+		}
+	case walApplied:
+		if err := binary.Read(r, binary.BigEndian, &s.applied); err != nil {
+			return ErrCorruptRecord
+		}
+	default:
+		return ErrCorruptRecord
+	}
+	return nil
+}
+
+func (s *FileStorage) maybeCheckpoint() error {
+	if s.appends < checkpointEvery {
+		return nil
+	}
+	return s.checkpoint()
+}
+
+// checkpoint must be called with s.mu held.
+func (s *FileStorage) checkpoint() error {
+	var buf bytes.Buffer
+	var payload bytes.Buffer
+	payload.WriteByte(walPromised)
+	writeProposal(&payload, s.highestPromised)
+	buf.Write(frameRecord(payload.Bytes()))
+	payload.Reset()
+	payload.WriteByte(walAccepted)
+	writeProposal(&payload, s.acceptedProposal)
+	writeBytes(&payload, s.acceptedValue)
+	buf.Write(frameRecord(payload.Bytes()))
+	for slot, state := range s.slots {
+		buf.Write(frameRecord(encodeSlotRecord(slot, state)))
+	}
+	buf.Write(frameRecord(encodeRoundRecord(s.round)))
+	buf.Write(frameRecord(encodeAppliedRecord(s.applied)))
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		f.Close()
+		return err
+	}
+	if dir, err := os.Open(filepath.Dir(s.path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	s.f.Close()
+	s.f = f
+	s.appends = 0
+	return nil
+}
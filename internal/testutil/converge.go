@@ -0,0 +1,106 @@
+// =============================================================================
+// TEST UTILITIES - Shared Assertions for Cluster Tests
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Nearly every cluster test ends the same way: wait for each node to learn
+// a value, check they all learned the same one. AssertConverged does both
+// and fails the test with a per-node report if either doesn't hold:
+//
+//   c, _ := cluster.New(5)
+//   c.Propose([]byte("x"))
+//   agreed := testutil.AssertConverged(t, c.Nodes())
+//
+// A node that never learns anything is reported as a liveness failure, one
+// that learned something different as a safety failure.
+//
+// The helper accepts anything with GetChosenValue, so it works with
+// *node.Node and with hand-written fakes alike.
+//
+// This package imports "testing" and is meant only for _test.go files.
+//
+// =============================================================================
+
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	DefaultConvergeTimeout = 2 * time.Second
+	convergePollInterval   = 5 * time.Millisecond
+)
+
+type ChosenValuer interface {
+	GetChosenValue() ([]byte, bool)
+}
+
+// AssertConverged is AssertConvergedWithin using DefaultConvergeTimeout.
+func AssertConverged[N ChosenValuer](t testing.TB, nodes []N) []byte {
+	t.Helper()
+	return AssertConvergedWithin(t, nodes, DefaultConvergeTimeout)
+}
+
+// AssertConvergedWithin waits up to timeout for every node to report a
+// chosen value and returns it if they all agree. Otherwise it fails t.
+// Diverged values fail at once; waiting longer can't fix them.
+func AssertConvergedWithin[N ChosenValuer](t testing.TB, nodes []N, timeout time.Duration) []byte {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		value, missing, diverged := check(nodes)
+		if diverged {
+			t.Fatalf("nodes chose different values:\n%s", report(nodes))
+			return nil
+		}
+		if missing == 0 {
+			return value
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d nodes learned nothing within %v:\n%s", missing, len(nodes), timeout, report(nodes))
+			return nil
+		}
+		time.Sleep(convergePollInterval)
+	}
+}
+
+func check[N ChosenValuer](nodes []N) (value []byte, missing int, diverged bool) {
+	seen := false
+	for _, n := range nodes {
+		v, ok := n.GetChosenValue()
+		if !ok {
+			missing++
+			continue
+		}
+		if !seen {
+			value, seen = v, true
+		} else if !bytes.Equal(v, value) {
+			return nil, missing, true
+		}
+	}
+	return value, missing, false
+}
+
+func report[N ChosenValuer](nodes []N) string {
+	var b strings.Builder
+	for i, n := range nodes {
+		label := fmt.Sprintf("node %d", i)
+		if id, ok := any(n).(interface{ ID() string }); ok {
+			label = id.ID()
+		}
+		if v, ok := n.GetChosenValue(); ok {
+			fmt.Fprintf(&b, "  %s: %q\n", label, v)
+		} else {
+			fmt.Fprintf(&b, "  %s: (nothing)\n", label)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeNode struct {
+	id    string
+	value string
+	after time.Time
+}
+
+func (f fakeNode) ID() string { return f.id }
+
+func (f fakeNode) GetChosenValue() ([]byte, bool) {
+	if f.value == "" || time.Now().Before(f.after) {
+		return nil, false
+	}
+	return []byte(f.value), true
+}
+
+// recorder stands in for *testing.T, keeping the failure instead of
+// stopping the test. The helper returns right after Fatalf.
+type recorder struct {
+	testing.TB
+	failure string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.failure = fmt.Sprintf(format, args...)
+}
+
+func TestAssertConvergedAgreed(t *testing.T) {
+	late := time.Now().Add(20 * time.Millisecond)
+	nodes := []fakeNode{{"n0", "x", time.Time{}}, {"n1", "x", late}, {"n2", "x", late}}
+	if got := AssertConverged(t, nodes); string(got) != "x" {
+		t.Fatalf("agreed on %q, want x", got)
+	}
+}
+
+func TestAssertConvergedDiverged(t *testing.T) {
+	r := &recorder{}
+	nodes := []fakeNode{{id: "n0", value: "x"}, {id: "n1", value: "y"}, {id: "n2", value: "x"}}
+	start := time.Now()
+	if got := AssertConvergedWithin(r, nodes, time.Second); got != nil {
+		t.Fatalf("returned %q for diverged nodes", got)
+	}
+	if !strings.Contains(r.failure, "different values") || !strings.Contains(r.failure, `n1: "y"`) {
+		t.Fatalf("failure = %q, want a safety report naming n1", r.failure)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("waited out the timeout on values that can't converge")
+	}
+}
+
+func TestAssertConvergedMissing(t *testing.T) {
+	r := &recorder{}
+	nodes := []fakeNode{{id: "n0", value: "x"}, {id: "n1"}}
+	AssertConvergedWithin(r, nodes, 20*time.Millisecond)
+	if !strings.Contains(r.failure, "1 of 2 nodes learned nothing") || !strings.Contains(r.failure, "n1: (nothing)") {
+		t.Fatalf("failure = %q, want a liveness report naming n1", r.failure)
+	}
+}
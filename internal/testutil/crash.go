@@ -0,0 +1,185 @@
+// =============================================================================
+// CRASH STORAGE - Losing Everything That Wasn't Synced
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// The acceptor banner warns that replying before state is durable can
+// violate safety. CountingStorage checks the ordering; CrashStorage shows
+// what breaks without it. Saves take effect at once but only become
+// durable at Sync, and Crash throws away everything since the last one:
+//
+//   s := testutil.NewCrashStorage()
+//   a := paxos.NewAcceptor("a", s)
+//   a.HandlePrepare(prepare5)
+//   a.HandleAccept(acceptX5)    // synced before Accepted{OK: true}
+//   s.Crash()
+//   a = paxos.NewAcceptor("a", s) // restarted: still holds (5, X)
+//
+// =============================================================================
+// THE FORGOTTEN ACCEPT
+// =============================================================================
+//
+// LieOnSync(true) makes Sync report success without making anything
+// durable - a disk cache that acknowledges early. With it, three acceptors
+// A, B, C can choose two values:
+//
+//   1. P1 runs (5, X) on A and B. X is chosen.
+//   2. A crashes. Its accept of (5, X) was never really written.
+//   3. P2 prepares (6) on the restarted A and on C. Neither reports an
+//      accepted value, so P2 proposes its own Y.
+//   4. A and C accept (6, Y). Y is chosen too.
+//
+// With honest syncs A still reports (5, X) in step 3, P2 adopts X, and only
+// X is ever chosen. A test that runs the steps both ways guards the
+// sync-before-ack ordering in the acceptor.
+//
+// =============================================================================
+
+package testutil
+
+import (
+	"sync"
+
+	"quorum/internal/storage"
+)
+
+type crashOp func(storage.Storage) error
+
+type CrashStorage struct {
+	mu      sync.Mutex
+	live    *storage.MemoryStorage
+	synced  []crashOp
+	pending []crashOp
+	lie     bool
+	crashes int
+}
+
+func NewCrashStorage() *CrashStorage {
+	return &CrashStorage{live: storage.NewMemoryStorage()}
+}
+
+func (c *CrashStorage) save(op crashOp) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := op(c.live); err != nil {
+		return err
+	}
+	c.pending = append(c.pending, op)
+	return nil
+}
+
+func (c *CrashStorage) SavePromised(proposal storage.ProposalNumber) error {
+	return c.save(func(s storage.Storage) error { return s.SavePromised(proposal) })
+}
+
+func (c *CrashStorage) SaveAccepted(proposal storage.ProposalNumber, value []byte) error {
+	value = append([]byte(nil), value...)
+	return c.save(func(s storage.Storage) error { return s.SaveAccepted(proposal, value) })
+}
+
+func (c *CrashStorage) SaveSlot(slot int64, state storage.SlotState) error {
+	state.AcceptedValue = append([]byte(nil), state.AcceptedValue...)
+	state.ChosenValue = append([]byte(nil), state.ChosenValue...)
+	return c.save(func(s storage.Storage) error { return s.SaveSlot(slot, state) })
+}
+
+func (c *CrashStorage) SaveRound(round int64) error {
+	return c.save(func(s storage.Storage) error { return s.SaveRound(round) })
+}
+
+func (c *CrashStorage) SaveApplied(slot int64) error {
+	return c.save(func(s storage.Storage) error { return s.SaveApplied(slot) })
+}
+
+func (c *CrashStorage) LoadPromised() (storage.ProposalNumber, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.live.LoadPromised()
+}
+
+func (c *CrashStorage) LoadAccepted() (storage.ProposalNumber, []byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.live.LoadAccepted()
+}
+
+func (c *CrashStorage) LoadSlot(slot int64) (storage.SlotState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.live.LoadSlot(slot)
+}
+
+func (c *CrashStorage) GetHighestSlot() (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.live.GetHighestSlot()
+}
+
+func (c *CrashStorage) Slots() ([]int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.live.Slots()
+}
+
+func (c *CrashStorage) LoadRound() (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.live.LoadRound()
+}
+
+func (c *CrashStorage) LoadApplied() (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.live.LoadApplied()
+}
+
+// Sync makes every save so far durable, unless LieOnSync is on.
+func (c *CrashStorage) Sync() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.lie {
+		c.synced = append(c.synced, c.pending...)
+		c.pending = nil
+	}
+	return nil
+}
+
+func (c *CrashStorage) Close() error {
+	return nil
+}
+
+// LieOnSync makes Sync succeed without making anything durable.
+func (c *CrashStorage) LieOnSync(lie bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lie = lie
+}
+
+// Crash discards every save not yet synced. Build a new acceptor over the
+// storage afterwards; the old one still holds the lost state in memory.
+func (c *CrashStorage) Crash() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.live = storage.NewMemoryStorage()
+	for _, op := range c.synced {
+		op(c.live)
+	}
+	c.pending = nil
+	c.crashes++
+}
+
+// Unsynced is how many saves a Crash right now would lose.
+func (c *CrashStorage) Unsynced() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+func (c *CrashStorage) Crashes() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.crashes
+}
@@ -0,0 +1,108 @@
+// =============================================================================
+// COUNTING STORAGE - Checking That Saves Are Synced Before Replies
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// The acceptor must sync storage after saving and before replying OK. That
+// ordering is invisible from outside unless storage is watched. Wrap any
+// Storage in a CountingStorage and check Unsynced right after each reply:
+//
+//   s := testutil.NewCountingStorage(storage.NewInMemoryStorage())
+//   a := paxos.NewAcceptor("a1", s)
+//   if r := a.HandleAccept(accept); r.OK && s.Unsynced() {
+//       t.Fatal("Accepted{OK:true} before Sync")
+//   }
+//
+// FailSync makes Sync return an error, for checking the acceptor rejects
+// instead of acknowledging.
+//
+// =============================================================================
+
+package testutil
+
+import (
+	"errors"
+	"sync"
+
+	"quorum/internal/storage"
+)
+
+var ErrSyncFailed = errors.New("sync failed")
+
+type CountingStorage struct {
+	storage.Storage
+	mu       sync.Mutex
+	saves    int
+	syncs    int
+	unsynced bool
+	failSync bool
+}
+
+func NewCountingStorage(s storage.Storage) *CountingStorage {
+	return &CountingStorage{Storage: s}
+}
+
+func (c *CountingStorage) saved(err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.saves++
+	c.unsynced = true
+	return err
+}
+
+func (c *CountingStorage) SavePromised(proposal storage.ProposalNumber) error {
+	return c.saved(c.Storage.SavePromised(proposal))
+}
+
+func (c *CountingStorage) SaveAccepted(proposal storage.ProposalNumber, value []byte) error {
+	return c.saved(c.Storage.SaveAccepted(proposal, value))
+}
+
+func (c *CountingStorage) SaveSlot(slot int64, state storage.SlotState) error {
+	return c.saved(c.Storage.SaveSlot(slot, state))
+}
+
+func (c *CountingStorage) SaveRound(round int64) error {
+	return c.saved(c.Storage.SaveRound(round))
+}
+
+func (c *CountingStorage) SaveApplied(slot int64) error {
+	return c.saved(c.Storage.SaveApplied(slot))
+}
+
+func (c *CountingStorage) Sync() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failSync {
+		return ErrSyncFailed
+	}
+	if err := c.Storage.Sync(); err != nil {
+		return err
+	}
+	c.syncs++
+	c.unsynced = false
+	return nil
+}
+
+// Unsynced reports whether a save has happened since the last successful
+// Sync.
+func (c *CountingStorage) Unsynced() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.unsynced
+}
+
+func (c *CountingStorage) Counts() (saves, syncs int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.saves, c.syncs
+}
+
+func (c *CountingStorage) FailSync(fail bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failSync = fail
+}
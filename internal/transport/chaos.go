@@ -0,0 +1,170 @@
+// =============================================================================
+// CHAOS - Every Fault Injection at Once, Driven by a Seed
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// Partition, SetDelay and SetFIFOPerSender each let a test set up one fault
+// by hand. EnableChaos turns them all loose at random for the rest of a run:
+//
+//   net.EnableChaos(transport.ChaosConfig{
+//       LossProb:      0.05,
+//       DelayRange:    transport.DelayRange{Min: 0, Max: 5 * time.Millisecond},
+//       ReorderProb:   0.1,
+//       PartitionProb: 0.01,
+//       Seed:          seed,
+//   })
+//
+// Every send draws the same four numbers from a generator seeded with Seed:
+//
+//   1. PARTITION: with PartitionProb, cut or heal the link between one
+//      random pair of nodes. A cut lasts until a later draw heals it or
+//      DisableChaos runs.
+//   2. LOSS: with LossProb, drop the message.
+//   3. DELAY: hold the message for a time drawn from DelayRange.
+//   4. REORDER: with ReorderProb, hold it for an extra DelayRange.Max (or
+//      reorderHold if that is zero), so later messages overtake it.
+//
+// =============================================================================
+// HOW DETERMINISTIC IS IT?
+// =============================================================================
+//
+// The fault decisions are a pure function of the seed and the order of
+// sends. The order of sends is not: nodes run in their own goroutines, so
+// two runs with the same seed can interleave differently and see different
+// faults. A failing seed makes a failure likely to recur, not certain. For
+// a fully replayable schedule use DeterministicNetwork instead.
+//
+// Chaos only ever loses, delays and reorders messages - faults Paxos must
+// tolerate. Liveness may suffer under heavy settings; safety must not.
+// Checking it is the caller's job, e.g. by comparing every node's chosen
+// values at the end of the run.
+//
+// =============================================================================
+
+package transport
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const reorderHold = time.Millisecond
+
+type DelayRange struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+type ChaosConfig struct {
+	LossProb      float64
+	DelayRange    DelayRange
+	ReorderProb   float64
+	PartitionProb float64
+	Seed          int64
+}
+
+type chaosState struct {
+	config ChaosConfig
+	rng    *rand.Rand
+	cut    map[string][2]string
+}
+
+// EnableChaos starts injecting faults into every subsequent send. Calling
+// it again restarts the sequence from the new config's seed.
+func (n *Network) EnableChaos(config ChaosConfig) {
+	if config.DelayRange.Max < config.DelayRange.Min {
+		config.DelayRange.Max = config.DelayRange.Min
+	}
+	n.DisableChaos()
+	n.chaosMu.Lock()
+	defer n.chaosMu.Unlock()
+	n.chaos = &chaosState{
+		config: config,
+		rng:    rand.New(rand.NewSource(config.Seed)),
+		cut:    make(map[string][2]string),
+	}
+}
+
+// DisableChaos stops injecting faults and heals every partition chaos made.
+// Messages already held back are still delivered.
+func (n *Network) DisableChaos() {
+	n.chaosMu.Lock()
+	c := n.chaos
+	n.chaos = nil
+	n.chaosMu.Unlock()
+	if c == nil {
+		return
+	}
+	for _, pair := range c.cut {
+		n.Heal(pair[0], pair[1])
+	}
+}
+
+// chaosDecision reports whether chaos drops the next message and, if not,
+// how long to hold it. ok is false when chaos is off.
+func (n *Network) chaosDecision() (drop bool, delay time.Duration, ok bool) {
+	n.chaosMu.Lock()
+	defer n.chaosMu.Unlock()
+	c := n.chaos
+	if c == nil {
+		return false, 0, false
+	}
+	partitionRoll := c.rng.Float64()
+	pairRoll := c.rng.Int63()
+	lossRoll := c.rng.Float64()
+	delayRoll := c.rng.Int63()
+	reorderRoll := c.rng.Float64()
+
+	if partitionRoll < c.config.PartitionProb {
+		n.togglePartition(c, pairRoll)
+	}
+	if lossRoll < c.config.LossProb {
+		return true, 0, true
+	}
+	r := c.config.DelayRange
+	delay = r.Min
+	if spread := r.Max - r.Min; spread > 0 {
+		delay += time.Duration(delayRoll % (int64(spread) + 1))
+	}
+	if reorderRoll < c.config.ReorderProb {
+		if r.Max > 0 {
+			delay += r.Max
+		} else {
+			delay += reorderHold
+		}
+	}
+	return false, delay, true
+}
+
+// togglePartition must be called with n.chaosMu held. Nodes are sorted so
+// the pair picked depends only on the roll.
+func (n *Network) togglePartition(c *chaosState, roll int64) {
+	nodes := n.getAllNodes()
+	if len(nodes) < 2 {
+		return
+	}
+	sort.Strings(nodes)
+	pairs := len(nodes) * (len(nodes) - 1) / 2
+	k := int(roll % int64(pairs))
+	for i := 0; i < len(nodes); i++ {
+		for j := i + 1; j < len(nodes); j++ {
+			if k == 0 {
+				a, b := nodes[i], nodes[j]
+				key := linkKey(a, b)
+				if _, ok := c.cut[key]; ok {
+					delete(c.cut, key)
+					n.Heal(a, b)
+				} else {
+					c.cut[key] = [2]string{a, b}
+					n.Partition(a, b)
+				}
+				return
+			}
+			k--
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// =============================================================================
+// CODEC - Message Serialization for Network Transports
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// The in-memory transports pass Go values through channels and never need
+// to serialize anything. Real network transports (UDP, TCP) do. The Codec
+// is the one place that turns a Message into bytes and back.
+//
+// Paxos code keeps working with Go structs; only the transport touches bytes.
+//
+// =============================================================================
+// GOB AND REGISTRATION
+// =============================================================================
+//
+// GobCodec wraps each message in an envelope with an interface-typed field.
+// gob can only encode a concrete type behind an interface if that type was
+// registered first:
+//
+//   transport.RegisterMessage(paxos.Prepare{})
+//
+// The node package registers all Paxos message types in its init(), so
+// anything built through node.NewNode is covered. If you define your own
+// message types, register them before the first Send.
+//
+// =============================================================================
+// TYPED VALUES
+// =============================================================================
+//
+// Paxos values are bytes. To carry application commands of several types in
+// one log, EncodeValue wraps a command in the same kind of interface-typed
+// envelope, and DecodeValue gets it back:
+//
+//   transport.RegisterValueType(SetCmd{})      // once, at startup,
+//   transport.RegisterValueType(DeleteCmd{})   // on every node
+//
+//   value, err := transport.EncodeValue(SetCmd{Key: "x", Val: 1})
+//   chosen, err := n.Propose(value)
+//   cmd, err := transport.DecodeValue(chosen)  // cmd.(SetCmd)
+//
+// Every node must register the same types, or a value proposed by one node
+// can't be decoded on another. Encoding a type nobody registered fails with
+// ErrUnregisteredType naming the type, instead of gob's bare "type not
+// registered for interface".
+//
+// =============================================================================
+
+package transport
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnregisteredType = errors.New("type not registered with the codec")
+
+type Codec interface {
+	Encode(msg Message) ([]byte, error)
+	Decode(data []byte) (Message, error)
+}
+
+func RegisterMessage(sample Message) {
+	gob.Register(sample)
+}
+
+// RegisterValueType registers a command type for EncodeValue/DecodeValue.
+// See TYPED VALUES above.
+func RegisterValueType(sample interface{}) {
+	gob.Register(sample)
+}
+
+// explainGobError turns gob's "type not registered" failure into
+// ErrUnregisteredType, naming the value being encoded.
+func explainGobError(err error, v interface{}, register string) error {
+	if err != nil && strings.Contains(err.Error(), "type not registered") {
+		return fmt.Errorf("%w: encoding %T: call transport.%s first (%v)", ErrUnregisteredType, v, register, err)
+	}
+	return err
+}
+
+type valueEnvelope struct {
+	Value interface{}
+}
+
+// EncodeValue turns a registered command into bytes suitable as a Paxos
+// value.
+func EncodeValue(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(valueEnvelope{Value: v}); err != nil {
+		return nil, explainGobError(err, v, "RegisterValueType")
+	}
+	return buf.Bytes(), nil
+}
+
+func DecodeValue(data []byte) (interface{}, error) {
+	var env valueEnvelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&env); err != nil {
+		return nil, err
+	}
+	return env.Value, nil
+}
+
+type gobEnvelope struct {
+	Msg Message
+}
+
+type GobCodec struct{}
+
+func (GobCodec) Encode(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(gobEnvelope{Msg: msg}); err != nil {
+		return nil, explainGobError(err, msg, "RegisterMessage")
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Decode(data []byte) (Message, error) {
+	var env gobEnvelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&env); err != nil {
+		return nil, err
+	}
+	return env.Msg, nil
+}
@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"quorum/internal/paxos"
+)
+
+type setCommand struct {
+	Key   string
+	Value int
+}
+
+type unregisteredCommand struct {
+	Key string
+}
+
+type unregisteredMessage struct {
+	From string
+}
+
+func (m unregisteredMessage) GetFrom() string { return m.From }
+
+func init() {
+	RegisterValueType(setCommand{})
+	// The node package registers the paxos messages in real use.
+	RegisterMessage(paxos.Accept{})
+}
+
+func TestTypedValueRoundTripsThroughGob(t *testing.T) {
+	value, err := EncodeValue(setCommand{Key: "x", Value: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := GobCodec{}.Encode(paxos.Accept{ProposalNumber: paxos.NewProposalNumber(1, "n0"), Value: value, From: "n0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := GobCodec{}.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accept, ok := msg.(paxos.Accept)
+	if !ok {
+		t.Fatalf("decoded %T, want paxos.Accept", msg)
+	}
+	cmd, err := DecodeValue(accept.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd != (setCommand{Key: "x", Value: 42}) {
+		t.Fatalf("decoded command %#v", cmd)
+	}
+}
+
+func TestEncodeUnregisteredValue(t *testing.T) {
+	_, err := EncodeValue(unregisteredCommand{Key: "x"})
+	if !errors.Is(err, ErrUnregisteredType) {
+		t.Fatalf("err = %v, want ErrUnregisteredType", err)
+	}
+	if !strings.Contains(err.Error(), "unregisteredCommand") || !strings.Contains(err.Error(), "RegisterValueType") {
+		t.Fatalf("error %q should name the type and RegisterValueType", err)
+	}
+}
+
+func TestEncodeUnregisteredMessage(t *testing.T) {
+	_, err := GobCodec{}.Encode(unregisteredMessage{From: "a"})
+	if !errors.Is(err, ErrUnregisteredType) || !strings.Contains(err.Error(), "RegisterMessage") {
+		t.Fatalf("err = %v, want ErrUnregisteredType pointing at RegisterMessage", err)
+	}
+}
@@ -0,0 +1,257 @@
+// =============================================================================
+// DEDUP TRANSPORT - At-Most-Once Delivery via Sequence Numbers
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// The transport invariant (transport.go) promises messages are delivered at
+// most once, "or deduplicated at the receiver". UDP can duplicate datagrams,
+// and a retrying sender can too. DedupTransport is the receiver-side half of
+// that promise, as a decorator around any other Transport:
+//
+//   t := NewDedupTransport("node-1", udpTransport)
+//
+// Every outgoing message is wrapped in a SequencedMessage stamped with
+// (From, Epoch, Seq). The receiving DedupTransport unwraps it and discards
+// any (From, Epoch, Seq) it has already delivered.
+//
+// Both ends must use the decorator. Messages that arrive without a stamp are
+// passed through untouched.
+//
+// =============================================================================
+// SLIDING WINDOW
+// =============================================================================
+//
+// Remembering every sequence number forever is a memory leak. Instead each
+// sender gets a 64-entry window (the same trick as IPsec anti-replay):
+//
+//   highest = largest Seq delivered so far
+//   seen    = bitmap, bit i set ⇔ (highest - i) was delivered
+//
+//   seq > highest            → new, slide window forward, deliver
+//   highest-64 < seq ≤ high  → deliver iff its bit is not set
+//   seq ≤ highest-64         → too old to tell, drop
+//
+// Dropping very old messages is safe - Paxos treats them as lost.
+//
+// =============================================================================
+// EPOCHS
+// =============================================================================
+//
+// A restarted sender starts again at Seq 1. Without an epoch the receiver
+// would drop everything it sends until Seq climbed past the old highest.
+// Each DedupTransport picks a fresh epoch at construction, and a newer epoch
+// from a sender resets that sender's window. Messages from an older epoch
+// (a previous incarnation) are dropped.
+//
+// =============================================================================
+
+package transport
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const dedupWindowSize = 64
+
+func init() {
+	RegisterMessage(SequencedMessage{})
+}
+
+type SequencedMessage struct {
+	From  string
+	Epoch int64
+	Seq   uint64
+	Msg   Message
+}
+
+func (m SequencedMessage) GetFrom() string { return m.From }
+
+type dedupWindow struct {
+	epoch   int64
+	highest uint64
+	seen    uint64
+}
+
+func (w *dedupWindow) accept(seq uint64) bool {
+	if seq > w.highest {
+		shift := seq - w.highest
+		if shift >= dedupWindowSize {
+			w.seen = 0
+		} else {
+			w.seen <<= shift
+		}
+		w.seen |= 1
+		w.highest = seq
+		return true
+	}
+	offset := w.highest - seq
+	if offset >= dedupWindowSize {
+		return false
+	}
+	bit := uint64(1) << offset
+	if w.seen&bit != 0 {
+		return false
+	}
+	w.seen |= bit
+	return true
+}
+
+type DedupTransport struct {
+	nodeID     string
+	inner      Transport
+	epoch      int64
+	seq        atomic.Uint64
+	windows    map[string]*dedupWindow
+	duplicates atomic.Int64
+	mu         sync.Mutex
+}
+
+func NewDedupTransport(id string, inner Transport) *DedupTransport {
+	return &DedupTransport{
+		nodeID:  id,
+		inner:   inner,
+		epoch:   time.Now().UnixNano(),
+		windows: make(map[string]*dedupWindow),
+	}
+}
+
+func (t *DedupTransport) stamp(msg Message) SequencedMessage {
+	return SequencedMessage{
+		From:  t.nodeID,
+		Epoch: t.epoch,
+		Seq:   t.seq.Add(1),
+		Msg:   msg,
+	}
+}
+
+func (t *DedupTransport) Send(to string, msg Message) error {
+	return t.inner.Send(to, t.stamp(msg))
+}
+
+func (t *DedupTransport) Broadcast(msg Message) error {
+	return t.inner.Broadcast(t.stamp(msg))
+}
+
+// BroadcastDetailed passes the inner transport's result through. If the
+// inner transport can't report per destination, the result is empty.
+func (t *DedupTransport) BroadcastDetailed(msg Message) (BroadcastResult, error) {
+	if d, ok := t.inner.(DetailedBroadcaster); ok {
+		return d.BroadcastDetailed(t.stamp(msg))
+	}
+	return BroadcastResult{}, t.inner.Broadcast(t.stamp(msg))
+}
+
+func (t *DedupTransport) Receive() (Message, error) {
+	return t.receive(t.inner.Receive)
+}
+
+func (t *DedupTransport) ReceiveTimeout(timeout time.Duration) (Message, error) {
+	return t.receiveTimeout(t.inner.ReceiveTimeout, timeout)
+}
+
+// ReceiveResponse reads the inner transport's response inbox, or its only
+// inbox if it doesn't have one.
+func (t *DedupTransport) ReceiveResponse() (Message, error) {
+	if rr, ok := t.inner.(ResponseReceiver); ok {
+		return t.receive(rr.ReceiveResponse)
+	}
+	return t.Receive()
+}
+
+func (t *DedupTransport) ReceiveResponseTimeout(timeout time.Duration) (Message, error) {
+	if rr, ok := t.inner.(ResponseReceiver); ok {
+		return t.receiveTimeout(rr.ReceiveResponseTimeout, timeout)
+	}
+	return t.ReceiveTimeout(timeout)
+}
+
+// TryReceiveResponse finds nothing unless the inner transport has a
+// response inbox: a shared inbox may hold requests.
+func (t *DedupTransport) TryReceiveResponse() (Message, bool) {
+	rr, ok := t.inner.(ResponseReceiver)
+	if !ok {
+		return nil, false
+	}
+	for {
+		msg, ok := rr.TryReceiveResponse()
+		if !ok {
+			return nil, false
+		}
+		if out, ok := t.filter(msg); ok {
+			return out, true
+		}
+	}
+}
+
+func (t *DedupTransport) DuplicateID() bool {
+	r, ok := t.inner.(IDRegistry)
+	return ok && r.DuplicateID()
+}
+
+func (t *DedupTransport) receive(next func() (Message, error)) (Message, error) {
+	for {
+		msg, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if out, ok := t.filter(msg); ok {
+			return out, nil
+		}
+	}
+}
+
+func (t *DedupTransport) receiveTimeout(next func(time.Duration) (Message, error), timeout time.Duration) (Message, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, ErrTimeout
+		}
+		msg, err := next(remaining)
+		if err != nil {
+			return nil, err
+		}
+		if out, ok := t.filter(msg); ok {
+			return out, nil
+		}
+	}
+}
+
+func (t *DedupTransport) Close() error {
+	return t.inner.Close()
+}
+
+func (t *DedupTransport) NodeID() string {
+	return t.nodeID
+}
+
+func (t *DedupTransport) Duplicates() int64 {
+	return t.duplicates.Load()
+}
+
+func (t *DedupTransport) filter(msg Message) (Message, bool) {
+	sm, ok := msg.(SequencedMessage)
+	if !ok {
+		return msg, true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w, ok := t.windows[sm.From]
+	if ok && sm.Epoch < w.epoch {
+		return nil, false
+	}
+	if !ok || w.epoch != sm.Epoch {
+		w = &dedupWindow{epoch: sm.Epoch}
+		t.windows[sm.From] = w
+	}
+	if !w.accept(sm.Seq) {
+		t.duplicates.Add(1)
+		return nil, false
+	}
+	return sm.Msg, true
+}
@@ -0,0 +1,59 @@
+package transport
+
+import "testing"
+
+func TestDedupDeliversDuplicateDatagramOnce(t *testing.T) {
+	rawA, rawB := newUDPPair(t)
+	a := NewDedupTransport("a", rawA)
+	b := NewDedupTransport("b", rawB)
+
+	stamped := a.stamp(testRequest{From: "a", N: 1})
+	for i := 0; i < 2; i++ {
+		if err := rawA.Send("b", stamped); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Send("b", testRequest{From: "a", N: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := mustReceive(t, b.ReceiveTimeout); msg != (testRequest{From: "a", N: 1}) {
+		t.Fatalf("first delivery %#v", msg)
+	}
+	if msg := mustReceive(t, b.ReceiveTimeout); msg != (testRequest{From: "a", N: 2}) {
+		t.Fatalf("got %#v, want the duplicate skipped", msg)
+	}
+	if n := b.Duplicates(); n != 1 {
+		t.Fatalf("Duplicates = %d, want 1", n)
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	var w dedupWindow
+	for _, step := range []struct {
+		seq  uint64
+		want bool
+	}{
+		{1, true}, {3, true}, {2, true}, {3, false}, {1, false},
+		{100, true}, {40, true}, {36, false}, {40, false}, {101, true},
+	} {
+		if got := w.accept(step.seq); got != step.want {
+			t.Fatalf("accept(%d) = %v, want %v", step.seq, got, step.want)
+		}
+	}
+}
+
+func TestDedupNewEpochResetsWindow(t *testing.T) {
+	b := NewDedupTransport("b", nil)
+	old := SequencedMessage{From: "a", Epoch: 1, Seq: 50, Msg: testRequest{From: "a", N: 1}}
+	if _, ok := b.filter(old); !ok {
+		t.Fatal("first message dropped")
+	}
+	restarted := SequencedMessage{From: "a", Epoch: 2, Seq: 1, Msg: testRequest{From: "a", N: 2}}
+	if _, ok := b.filter(restarted); !ok {
+		t.Fatal("restarted sender's Seq 1 dropped")
+	}
+	if _, ok := b.filter(old); ok {
+		t.Fatal("message from the previous epoch delivered")
+	}
+}
@@ -0,0 +1,326 @@
+// =============================================================================
+// DETERMINISTIC TRANSPORT - Reproducible Message Scheduling
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// A variant of the in-memory transport where delivery is driven by the
+// caller instead of goroutine timing.
+//
+// With MemoryTransport, Send writes straight into the destination inbox,
+// so the order in which acceptors see messages depends on the Go scheduler.
+// That makes interleaving bugs (dueling proposers, stale promises) show up
+// once in a hundred runs and never under a debugger.
+//
+// DeterministicNetwork instead parks every sent message in a pending queue.
+// Nothing reaches an inbox until the test says so:
+//
+//   Send/Broadcast ──▶ pending queue ──Step()/DeliverNext()──▶ inbox
+//
+// =============================================================================
+// HOW TO DRIVE IT
+// =============================================================================
+//
+// - Step() delivers ONE pending message picked by the seeded RNG. The same
+//   seed and the same sends always give the same delivery order.
+//
+// - DeliverNext() delivers the oldest pending message (FIFO).
+//
+// - DeliverWhere(match) delivers every pending message that matches, in
+//   send order. This is how you build an exact interleaving:
+//
+//     // all of B's prepares before any of A's accepts
+//     net.DeliverWhere(func(e Envelope) bool {
+//         _, ok := e.Msg.(paxos.Prepare)
+//         return ok && e.From == "node-b"
+//     })
+//
+// - DropWhere(match) discards matching messages (simulated loss).
+//
+// =============================================================================
+// INVARIANT THIS FILE MUST UPHOLD
+// =============================================================================
+//
+// INVARIANT: For a fixed seed and a fixed sequence of sends, the sequence
+//            of deliveries produced by Step() is always identical.
+//
+// Broadcast therefore enqueues destinations in sorted order rather than
+// map iteration order.
+//
+// Delivery order alone isn't enough with a Node, where the message loop and
+// the proposer both read the transport. Like MemoryTransport, each
+// DeterministicTransport has a request inbox and a response inbox (see
+// REQUESTS AND RESPONSES in memory.go), so which reader takes a message is
+// fixed by its type, not by the scheduler.
+//
+// A message delivered to a full inbox is not lost silently: it is kept in
+// Refused with ErrInboxFull. A test that sees anything there has sent more
+// than the reader kept up with, and its schedule no longer means what it
+// says.
+//
+// =============================================================================
+
+package transport
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Envelope struct {
+	From string
+	To   string
+	Msg  Message
+}
+
+// RefusedEnvelope is a message Step or a Deliver call couldn't hand over.
+type RefusedEnvelope struct {
+	Envelope
+	Err error
+}
+
+type DeterministicNetwork struct {
+	inboxes   map[string]chan Message
+	responses map[string]chan Message
+	pending   []Envelope
+	refused   []RefusedEnvelope
+	rng       *rand.Rand
+	mu        sync.Mutex
+}
+
+func NewDeterministicNetwork(seed int64) *DeterministicNetwork {
+	return &DeterministicNetwork{
+		inboxes:   make(map[string]chan Message),
+		responses: make(map[string]chan Message),
+		rng:       rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (n *DeterministicNetwork) AddNode(id string) *DeterministicTransport {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	inbox := make(chan Message, 100)
+	responses := make(chan Message, 100)
+	n.inboxes[id] = inbox
+	n.responses[id] = responses
+	return &DeterministicTransport{
+		nodeID:    id,
+		inbox:     inbox,
+		responses: responses,
+		network:   n,
+	}
+}
+
+func (n *DeterministicNetwork) RemoveNode(id string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.inboxes, id)
+	delete(n.responses, id)
+}
+
+func (n *DeterministicNetwork) enqueue(from, to string, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.inboxes[to]; !ok {
+		return ErrUnknownNode
+	}
+	n.pending = append(n.pending, Envelope{From: from, To: to, Msg: msg})
+	return nil
+}
+
+func (n *DeterministicNetwork) peers(self string) []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ids := make([]string, 0, len(n.inboxes))
+	for id := range n.inboxes {
+		if id != self {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// deliverAt must be called with n.mu held. A message for a node that has
+// left is dropped, as on MemoryTransport; one for a full inbox goes to
+// Refused.
+func (n *DeterministicNetwork) deliverAt(i int) Envelope {
+	env := n.pending[i]
+	n.pending = append(n.pending[:i], n.pending[i+1:]...)
+	inbox, ok := n.inboxes[env.To]
+	if !ok {
+		return env
+	}
+	inbox = inboxFor(env.Msg, inbox, n.responses[env.To])
+	select {
+	case inbox <- env.Msg:
+	default:
+		n.refused = append(n.refused, RefusedEnvelope{Envelope: env, Err: ErrInboxFull})
+	}
+	return env
+}
+
+// Refused lists every message that found its inbox full when delivered.
+func (n *DeterministicNetwork) Refused() []RefusedEnvelope {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make([]RefusedEnvelope, len(n.refused))
+	copy(out, n.refused)
+	return out
+}
+
+func (n *DeterministicNetwork) Pending() []Envelope {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make([]Envelope, len(n.pending))
+	copy(out, n.pending)
+	return out
+}
+
+func (n *DeterministicNetwork) Step() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.pending) == 0 {
+		return false
+	}
+	n.deliverAt(n.rng.Intn(len(n.pending)))
+	return true
+}
+
+func (n *DeterministicNetwork) DeliverNext() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.pending) == 0 {
+		return false
+	}
+	n.deliverAt(0)
+	return true
+}
+
+func (n *DeterministicNetwork) DeliverWhere(match func(Envelope) bool) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delivered := 0
+	for i := 0; i < len(n.pending); {
+		if match(n.pending[i]) {
+			n.deliverAt(i)
+			delivered++
+			continue
+		}
+		i++
+	}
+	return delivered
+}
+
+func (n *DeterministicNetwork) DropWhere(match func(Envelope) bool) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	kept := n.pending[:0]
+	dropped := 0
+	for _, env := range n.pending {
+		if match(env) {
+			dropped++
+			continue
+		}
+		kept = append(kept, env)
+	}
+	n.pending = kept
+	return dropped
+}
+
+type DeterministicTransport struct {
+	nodeID    string
+	inbox     chan Message
+	responses chan Message
+	network   *DeterministicNetwork
+	closed    bool
+	mu        sync.Mutex
+}
+
+func (t *DeterministicTransport) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+func (t *DeterministicTransport) Send(to string, msg Message) error {
+	if t.isClosed() {
+		return ErrClosed
+	}
+	return t.network.enqueue(t.nodeID, to, msg)
+}
+
+func (t *DeterministicTransport) Broadcast(msg Message) error {
+	_, err := t.BroadcastDetailed(msg)
+	return err
+}
+
+func (t *DeterministicTransport) BroadcastDetailed(msg Message) (BroadcastResult, error) {
+	if t.isClosed() {
+		return nil, ErrClosed
+	}
+	peers := t.network.peers(t.nodeID)
+	if len(peers) == 0 {
+		return nil, ErrNoPeers
+	}
+	result := make(BroadcastResult, len(peers))
+	for _, id := range peers {
+		result[id] = t.network.enqueue(t.nodeID, id, msg)
+	}
+	return result, nil
+}
+
+// Receive returns the next request; responses wait in their own inbox for
+// ReceiveResponse.
+func (t *DeterministicTransport) Receive() (Message, error) {
+	if t.isClosed() {
+		return nil, ErrClosed
+	}
+	return receiveFrom(t.inbox, -1)
+}
+
+func (t *DeterministicTransport) ReceiveTimeout(timeout time.Duration) (Message, error) {
+	if t.isClosed() {
+		return nil, ErrClosed
+	}
+	return receiveFrom(t.inbox, timeout)
+}
+
+func (t *DeterministicTransport) ReceiveResponse() (Message, error) {
+	if t.isClosed() {
+		return nil, ErrClosed
+	}
+	return receiveFrom(t.responses, -1)
+}
+
+func (t *DeterministicTransport) ReceiveResponseTimeout(timeout time.Duration) (Message, error) {
+	if t.isClosed() {
+		return nil, ErrClosed
+	}
+	return receiveFrom(t.responses, timeout)
+}
+
+func (t *DeterministicTransport) TryReceiveResponse() (Message, bool) {
+	return tryReceive(t.responses)
+}
+
+func (t *DeterministicTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	t.network.RemoveNode(t.nodeID)
+	close(t.inbox)
+	close(t.responses)
+	return nil
+}
+
+func (t *DeterministicTransport) NodeID() string {
+	return t.nodeID
+}
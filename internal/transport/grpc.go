@@ -0,0 +1,457 @@
+// =============================================================================
+// GRPC TRANSPORT - Paxos over gRPC Streams
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// A Transport for deployments that standardise on gRPC. The wire schema is
+// quorum.proto: each node serves the Quorum service, and every peer keeps
+// one client stream open to it and pushes Envelopes down it. The five
+// single-decree messages travel as protobuf, so a peer written in another
+// language can take part; the rest travel GobCodec-encoded (see OTHER
+// MESSAGES in quorum.proto).
+//
+//   peers := map[string]string{"n0": "10.0.0.1:7000", "n1": "10.0.0.2:7000", ...}
+//   t, err := transport.NewGRPCTransport("n0", peers)
+//
+// The transport listens on its own entry in peers. As with UDPTransport,
+// tests listen on "127.0.0.1:0" and wire the transports together with
+// AddPeer and Addr afterwards.
+//
+// =============================================================================
+// SENDING
+// =============================================================================
+//
+// Send converts the message and queues it for the peer's worker goroutine,
+// then returns; it never waits for the network, so a slow or dead peer
+// costs the proposer nothing. The worker dials lazily, opens the Deliver
+// stream, and writes queued envelopes in order. A failed write closes the
+// stream and drops that envelope - Paxos already tolerates lost messages -
+// and the next one reopens it. A full queue makes Send return ErrInboxFull.
+// Dropped counts the envelopes lost either way.
+//
+// =============================================================================
+// RECEIVING
+// =============================================================================
+//
+// The Deliver handler decodes each envelope and pushes it into the same
+// request/response inboxes MemoryTransport uses, dropping it if the inbox
+// is full, exactly like TCPTransport's read loop. Close stops the server
+// and all workers before closing the inboxes.
+//
+// =============================================================================
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"quorum/internal/paxos"
+	"quorum/internal/transport/grpcpb"
+)
+
+const grpcSendQueue = 100
+
+type grpcPeer struct {
+	id    string
+	addr  string
+	queue chan *grpcpb.Envelope
+}
+
+type GRPCTransport struct {
+	nodeID    string
+	listener  net.Listener
+	server    *grpc.Server
+	codec     Codec
+	peers     map[string]*grpcPeer
+	inbox     chan Message
+	responses chan Message
+	dropped   atomic.Uint64
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closed    bool
+	mu        sync.RWMutex
+	wg        sync.WaitGroup
+}
+
+// NewGRPCTransport listens on peers[id] and sends to every other entry.
+func NewGRPCTransport(id string, peers map[string]string) (*GRPCTransport, error) {
+	listenAddr, ok := peers[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: no address for %s", ErrUnknownNode, id)
+	}
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	t := &GRPCTransport{
+		nodeID:    id,
+		listener:  listener,
+		server:    grpc.NewServer(),
+		codec:     GobCodec{},
+		peers:     make(map[string]*grpcPeer),
+		inbox:     make(chan Message, 100),
+		responses: make(chan Message, 100),
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	grpcpb.RegisterQuorumServer(t.server, grpcServer{t: t})
+	for peerID, addr := range peers {
+		if peerID != id {
+			t.addPeer(peerID, addr)
+		}
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.server.Serve(listener)
+	}()
+	return t, nil
+}
+
+// AddPeer adds or re-addresses a peer. Envelopes already queued for it
+// still go to the old address.
+func (t *GRPCTransport) AddPeer(id, addr string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.addPeer(id, addr)
+}
+
+// addPeer must be called with t.mu held.
+func (t *GRPCTransport) addPeer(id, addr string) {
+	if t.closed {
+		return
+	}
+	if old, ok := t.peers[id]; ok {
+		close(old.queue)
+	}
+	p := &grpcPeer{id: id, addr: addr, queue: make(chan *grpcpb.Envelope, grpcSendQueue)}
+	t.peers[id] = p
+	t.wg.Add(1)
+	go t.sendLoop(p)
+}
+
+func (t *GRPCTransport) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+func (t *GRPCTransport) Send(to string, msg Message) error {
+	env, err := t.envelope(to, msg)
+	if err != nil {
+		return err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return ErrClosed
+	}
+	p, ok := t.peers[to]
+	if !ok {
+		return ErrUnknownNode
+	}
+	select {
+	case p.queue <- env:
+		return nil
+	default:
+		t.dropped.Add(1)
+		return fmt.Errorf("%w: send queue for %s", ErrInboxFull, to)
+	}
+}
+
+// sendLoop is a peer's worker. It owns the peer's connection and stream.
+func (t *GRPCTransport) sendLoop(p *grpcPeer) {
+	defer t.wg.Done()
+	var (
+		conn   *grpc.ClientConn
+		stream grpcpb.Quorum_DeliverClient
+	)
+	defer func() {
+		if stream != nil {
+			stream.CloseAndRecv()
+		}
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+	for {
+		var env *grpcpb.Envelope
+		select {
+		case <-t.ctx.Done():
+			return
+		case e, ok := <-p.queue:
+			if !ok {
+				return
+			}
+			env = e
+		}
+		if stream == nil {
+			var err error
+			if conn == nil {
+				conn, err = grpc.NewClient(p.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+				if err != nil {
+					t.dropped.Add(1)
+					continue
+				}
+			}
+			stream, err = grpcpb.NewQuorumClient(conn).Deliver(t.ctx)
+			if err != nil {
+				t.dropped.Add(1)
+				continue
+			}
+		}
+		if err := stream.Send(env); err != nil {
+			stream = nil
+			t.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped is how many envelopes were lost to a full send queue or a
+// failed stream.
+func (t *GRPCTransport) Dropped() uint64 {
+	return t.dropped.Load()
+}
+
+// Broadcast sends to every peer and, after trying them all, returns every
+// failure joined into one error; see BroadcastResult.Err.
+func (t *GRPCTransport) Broadcast(msg Message) error {
+	result, err := t.BroadcastDetailed(msg)
+	if err != nil {
+		return err
+	}
+	return result.Err()
+}
+
+func (t *GRPCTransport) BroadcastDetailed(msg Message) (BroadcastResult, error) {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return nil, ErrClosed
+	}
+	ids := make([]string, 0, len(t.peers))
+	for id := range t.peers {
+		ids = append(ids, id)
+	}
+	t.mu.RUnlock()
+	if len(ids) == 0 {
+		return nil, ErrNoPeers
+	}
+	result := make(BroadcastResult, len(ids))
+	for _, id := range ids {
+		result[id] = t.Send(id, msg)
+	}
+	return result, nil
+}
+
+// Receive returns the next request. Messages of a type passed to
+// RegisterResponse go to a separate inbox, as in MemoryTransport.
+func (t *GRPCTransport) Receive() (Message, error) {
+	return receiveFrom(t.inbox, -1)
+}
+
+func (t *GRPCTransport) ReceiveTimeout(timeout time.Duration) (Message, error) {
+	return receiveFrom(t.inbox, timeout)
+}
+
+func (t *GRPCTransport) ReceiveResponse() (Message, error) {
+	return receiveFrom(t.responses, -1)
+}
+
+func (t *GRPCTransport) ReceiveResponseTimeout(timeout time.Duration) (Message, error) {
+	return receiveFrom(t.responses, timeout)
+}
+
+func (t *GRPCTransport) TryReceiveResponse() (Message, bool) {
+	return tryReceive(t.responses)
+}
+
+func (t *GRPCTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.cancel()
+	t.mu.Unlock()
+	t.server.Stop()
+	t.wg.Wait()
+	close(t.inbox)
+	close(t.responses)
+	return nil
+}
+
+func (t *GRPCTransport) NodeID() string {
+	return t.nodeID
+}
+
+// deliver runs on the server for every envelope a peer sends.
+func (t *GRPCTransport) deliver(env *grpcpb.Envelope) {
+	msg, err := t.message(env)
+	if err != nil {
+		return
+	}
+	select {
+	case inboxFor(msg, t.inbox, t.responses) <- msg:
+	default:
+	}
+}
+
+type grpcServer struct {
+	grpcpb.UnimplementedQuorumServer
+	t *GRPCTransport
+}
+
+func (s grpcServer) Deliver(stream grpcpb.Quorum_DeliverServer) error {
+	t := s.t
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return ErrClosed
+	}
+	t.wg.Add(1)
+	t.mu.RUnlock()
+	defer t.wg.Done()
+	for {
+		env, err := stream.Recv()
+		if err != nil {
+			return stream.SendAndClose(&grpcpb.DeliverAck{})
+		}
+		if env.GetTo() == t.nodeID {
+			t.deliver(env)
+		}
+	}
+}
+
+var errEmptyEnvelope = errors.New("envelope carries no message")
+
+func (t *GRPCTransport) envelope(to string, msg Message) (*grpcpb.Envelope, error) {
+	env := &grpcpb.Envelope{To: to}
+	switch m := msg.(type) {
+	case paxos.Prepare:
+		env.Message = &grpcpb.Envelope_Prepare{Prepare: &grpcpb.Prepare{
+			Slot:           m.Slot,
+			ProposalNumber: toProposalPB(m.ProposalNumber),
+			From:           m.From,
+		}}
+	case paxos.Promise:
+		var lease int64
+		if !m.LeaseUntil.IsZero() {
+			lease = m.LeaseUntil.UnixNano()
+		}
+		env.Message = &grpcpb.Envelope_Promise{Promise: &grpcpb.Promise{
+			Slot:             m.Slot,
+			ProposalNumber:   toProposalPB(m.ProposalNumber),
+			AcceptedProposal: toProposalPB(m.AcceptedProposal),
+			AcceptedValue:    m.AcceptedValue,
+			HasAccepted:      m.HasAccepted,
+			HighestSeen:      toProposalPB(m.HighestSeen),
+			From:             m.From,
+			Ok:               m.OK,
+			LeaseUntil:       lease,
+		}}
+	case paxos.Accept:
+		env.Message = &grpcpb.Envelope_Accept{Accept: &grpcpb.Accept{
+			Slot:           m.Slot,
+			ProposalNumber: toProposalPB(m.ProposalNumber),
+			Value:          m.Value,
+			From:           m.From,
+		}}
+	case paxos.Accepted:
+		env.Message = &grpcpb.Envelope_Accepted{Accepted: &grpcpb.Accepted{
+			Slot:           m.Slot,
+			ProposalNumber: toProposalPB(m.ProposalNumber),
+			Value:          m.Value,
+			HighestSeen:    toProposalPB(m.HighestSeen),
+			From:           m.From,
+			Ok:             m.OK,
+		}}
+	case paxos.Learn:
+		env.Message = &grpcpb.Envelope_Learn{Learn: &grpcpb.Learn{
+			Slot:           m.Slot,
+			ProposalNumber: toProposalPB(m.ProposalNumber),
+			Value:          m.Value,
+			From:           m.From,
+		}}
+	default:
+		encoded, err := t.codec.Encode(msg)
+		if err != nil {
+			return nil, err
+		}
+		env.Message = &grpcpb.Envelope_Encoded{Encoded: encoded}
+	}
+	return env, nil
+}
+
+func (t *GRPCTransport) message(env *grpcpb.Envelope) (Message, error) {
+	switch m := env.GetMessage().(type) {
+	case *grpcpb.Envelope_Prepare:
+		return paxos.Prepare{
+			Slot:           m.Prepare.GetSlot(),
+			ProposalNumber: fromProposalPB(m.Prepare.GetProposalNumber()),
+			From:           m.Prepare.GetFrom(),
+		}, nil
+	case *grpcpb.Envelope_Promise:
+		p := m.Promise
+		var lease time.Time
+		if p.GetLeaseUntil() != 0 {
+			lease = time.Unix(0, p.GetLeaseUntil())
+		}
+		return paxos.Promise{
+			Slot:             p.GetSlot(),
+			ProposalNumber:   fromProposalPB(p.GetProposalNumber()),
+			AcceptedProposal: fromProposalPB(p.GetAcceptedProposal()),
+			AcceptedValue:    p.GetAcceptedValue(),
+			HasAccepted:      p.GetHasAccepted(),
+			HighestSeen:      fromProposalPB(p.GetHighestSeen()),
+			From:             p.GetFrom(),
+			OK:               p.GetOk(),
+			LeaseUntil:       lease,
+		}, nil
+	case *grpcpb.Envelope_Accept:
+		return paxos.Accept{
+			Slot:           m.Accept.GetSlot(),
+			ProposalNumber: fromProposalPB(m.Accept.GetProposalNumber()),
+			Value:          m.Accept.GetValue(),
+			From:           m.Accept.GetFrom(),
+		}, nil
+	case *grpcpb.Envelope_Accepted:
+		a := m.Accepted
+		return paxos.Accepted{
+			Slot:           a.GetSlot(),
+			ProposalNumber: fromProposalPB(a.GetProposalNumber()),
+			Value:          a.GetValue(),
+			HighestSeen:    fromProposalPB(a.GetHighestSeen()),
+			From:           a.GetFrom(),
+			OK:             a.GetOk(),
+		}, nil
+	case *grpcpb.Envelope_Learn:
+		return paxos.Learn{
+			Slot:           m.Learn.GetSlot(),
+			ProposalNumber: fromProposalPB(m.Learn.GetProposalNumber()),
+			Value:          m.Learn.GetValue(),
+			From:           m.Learn.GetFrom(),
+		}, nil
+	case *grpcpb.Envelope_Encoded:
+		return t.codec.Decode(m.Encoded)
+	}
+	return nil, errEmptyEnvelope
+}
+
+func toProposalPB(n paxos.ProposalNumber) *grpcpb.ProposalNumber {
+	return &grpcpb.ProposalNumber{Round: n.Round, ProposerId: n.ProposerID}
+}
+
+func fromProposalPB(n *grpcpb.ProposalNumber) paxos.ProposalNumber {
+	return paxos.ProposalNumber{Round: n.GetRound(), ProposerID: n.GetProposerId()}
+}
@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+)
+
+func newGRPCPair(t *testing.T) (*GRPCTransport, *GRPCTransport) {
+	t.Helper()
+	a, err := NewGRPCTransport("a", map[string]string{"a": "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	b, err := NewGRPCTransport("b", map[string]string{"b": "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	a.AddPeer("b", b.Addr().String())
+	b.AddPeer("a", a.Addr().String())
+	return a, b
+}
+
+func TestGRPCCarriesEveryMessage(t *testing.T) {
+	a, b := newGRPCPair(t)
+	n := paxos.ProposalNumber{Round: 3, ProposerID: "a"}
+	lease := time.Unix(0, time.Now().UnixNano())
+	msgs := []Message{
+		paxos.Prepare{Slot: 1, ProposalNumber: n, From: "a"},
+		paxos.Promise{Slot: 1, ProposalNumber: n, AcceptedProposal: n, AcceptedValue: []byte("v"),
+			HasAccepted: true, HighestSeen: n, From: "a", OK: true, LeaseUntil: lease},
+		paxos.Accept{Slot: 1, ProposalNumber: n, Value: []byte("v"), From: "a"},
+		paxos.Accepted{Slot: 1, ProposalNumber: n, Value: []byte("v"), HighestSeen: n, From: "a", OK: true},
+		paxos.Learn{Slot: 1, ProposalNumber: n, Value: []byte("v"), From: "a"},
+		testRequest{From: "a", N: 7},
+	}
+	for _, msg := range msgs {
+		if err := a.Send("b", msg); err != nil {
+			t.Fatal(err)
+		}
+		if got := mustReceive(t, b.ReceiveTimeout); !reflect.DeepEqual(got, msg) {
+			t.Fatalf("sent %#v, received %#v", msg, got)
+		}
+	}
+}
+
+func TestGRPCSeparatesResponses(t *testing.T) {
+	a, b := newGRPCPair(t)
+	if err := a.Send("b", testResponse{From: "a", N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Send("b", testRequest{From: "a", N: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := mustReceive(t, b.ReceiveTimeout); msg != (testRequest{From: "a", N: 2}) {
+		t.Fatalf("request inbox got %#v", msg)
+	}
+	if msg := mustReceive(t, b.ReceiveResponseTimeout); msg != (testResponse{From: "a", N: 1}) {
+		t.Fatalf("response inbox got %#v", msg)
+	}
+}
+
+func TestGRPCSendDoesNotWaitForPeer(t *testing.T) {
+	a, err := NewGRPCTransport("a", map[string]string{"a": "127.0.0.1:0", "b": "10.255.255.1:7000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	start := time.Now()
+	for i := 0; i < grpcSendQueue; i++ {
+		a.Send("b", testRequest{From: "a", N: i})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("%d sends to an unreachable peer took %v", grpcSendQueue, elapsed)
+	}
+	if err := a.Send("nobody", testRequest{From: "a"}); !errors.Is(err, ErrUnknownNode) {
+		t.Fatalf("Send to an unknown peer: err = %v, want ErrUnknownNode", err)
+	}
+}
@@ -0,0 +1,946 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: quorum.proto
+
+package grpcpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ProposalNumber struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Round      int64  `protobuf:"varint,1,opt,name=round,proto3" json:"round,omitempty"`
+	ProposerId string `protobuf:"bytes,2,opt,name=proposer_id,json=proposerId,proto3" json:"proposer_id,omitempty"`
+}
+
+func (x *ProposalNumber) Reset() {
+	*x = ProposalNumber{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quorum_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProposalNumber) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProposalNumber) ProtoMessage() {}
+
+func (x *ProposalNumber) ProtoReflect() protoreflect.Message {
+	mi := &file_quorum_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProposalNumber.ProtoReflect.Descriptor instead.
+func (*ProposalNumber) Descriptor() ([]byte, []int) {
+	return file_quorum_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ProposalNumber) GetRound() int64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *ProposalNumber) GetProposerId() string {
+	if x != nil {
+		return x.ProposerId
+	}
+	return ""
+}
+
+type Prepare struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot           int64           `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	ProposalNumber *ProposalNumber `protobuf:"bytes,2,opt,name=proposal_number,json=proposalNumber,proto3" json:"proposal_number,omitempty"`
+	From           string          `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+}
+
+func (x *Prepare) Reset() {
+	*x = Prepare{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quorum_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Prepare) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Prepare) ProtoMessage() {}
+
+func (x *Prepare) ProtoReflect() protoreflect.Message {
+	mi := &file_quorum_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Prepare.ProtoReflect.Descriptor instead.
+func (*Prepare) Descriptor() ([]byte, []int) {
+	return file_quorum_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Prepare) GetSlot() int64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *Prepare) GetProposalNumber() *ProposalNumber {
+	if x != nil {
+		return x.ProposalNumber
+	}
+	return nil
+}
+
+func (x *Prepare) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+type Promise struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot             int64           `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	ProposalNumber   *ProposalNumber `protobuf:"bytes,2,opt,name=proposal_number,json=proposalNumber,proto3" json:"proposal_number,omitempty"`
+	AcceptedProposal *ProposalNumber `protobuf:"bytes,3,opt,name=accepted_proposal,json=acceptedProposal,proto3" json:"accepted_proposal,omitempty"`
+	AcceptedValue    []byte          `protobuf:"bytes,4,opt,name=accepted_value,json=acceptedValue,proto3" json:"accepted_value,omitempty"`
+	HasAccepted      bool            `protobuf:"varint,5,opt,name=has_accepted,json=hasAccepted,proto3" json:"has_accepted,omitempty"`
+	HighestSeen      *ProposalNumber `protobuf:"bytes,6,opt,name=highest_seen,json=highestSeen,proto3" json:"highest_seen,omitempty"`
+	From             string          `protobuf:"bytes,7,opt,name=from,proto3" json:"from,omitempty"`
+	Ok               bool            `protobuf:"varint,8,opt,name=ok,proto3" json:"ok,omitempty"`
+	LeaseUntil       int64           `protobuf:"varint,9,opt,name=lease_until,json=leaseUntil,proto3" json:"lease_until,omitempty"`
+}
+
+func (x *Promise) Reset() {
+	*x = Promise{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quorum_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Promise) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Promise) ProtoMessage() {}
+
+func (x *Promise) ProtoReflect() protoreflect.Message {
+	mi := &file_quorum_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Promise.ProtoReflect.Descriptor instead.
+func (*Promise) Descriptor() ([]byte, []int) {
+	return file_quorum_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Promise) GetSlot() int64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *Promise) GetProposalNumber() *ProposalNumber {
+	if x != nil {
+		return x.ProposalNumber
+	}
+	return nil
+}
+
+func (x *Promise) GetAcceptedProposal() *ProposalNumber {
+	if x != nil {
+		return x.AcceptedProposal
+	}
+	return nil
+}
+
+func (x *Promise) GetAcceptedValue() []byte {
+	if x != nil {
+		return x.AcceptedValue
+	}
+	return nil
+}
+
+func (x *Promise) GetHasAccepted() bool {
+	if x != nil {
+		return x.HasAccepted
+	}
+	return false
+}
+
+func (x *Promise) GetHighestSeen() *ProposalNumber {
+	if x != nil {
+		return x.HighestSeen
+	}
+	return nil
+}
+
+func (x *Promise) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Promise) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *Promise) GetLeaseUntil() int64 {
+	if x != nil {
+		return x.LeaseUntil
+	}
+	return 0
+}
+
+type Accept struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot           int64           `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	ProposalNumber *ProposalNumber `protobuf:"bytes,2,opt,name=proposal_number,json=proposalNumber,proto3" json:"proposal_number,omitempty"`
+	Value          []byte          `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	From           string          `protobuf:"bytes,4,opt,name=from,proto3" json:"from,omitempty"`
+}
+
+func (x *Accept) Reset() {
+	*x = Accept{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quorum_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Accept) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Accept) ProtoMessage() {}
+
+func (x *Accept) ProtoReflect() protoreflect.Message {
+	mi := &file_quorum_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Accept.ProtoReflect.Descriptor instead.
+func (*Accept) Descriptor() ([]byte, []int) {
+	return file_quorum_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Accept) GetSlot() int64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *Accept) GetProposalNumber() *ProposalNumber {
+	if x != nil {
+		return x.ProposalNumber
+	}
+	return nil
+}
+
+func (x *Accept) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Accept) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+type Accepted struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot           int64           `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	ProposalNumber *ProposalNumber `protobuf:"bytes,2,opt,name=proposal_number,json=proposalNumber,proto3" json:"proposal_number,omitempty"`
+	Value          []byte          `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	HighestSeen    *ProposalNumber `protobuf:"bytes,4,opt,name=highest_seen,json=highestSeen,proto3" json:"highest_seen,omitempty"`
+	From           string          `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	Ok             bool            `protobuf:"varint,6,opt,name=ok,proto3" json:"ok,omitempty"`
+}
+
+func (x *Accepted) Reset() {
+	*x = Accepted{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quorum_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Accepted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Accepted) ProtoMessage() {}
+
+func (x *Accepted) ProtoReflect() protoreflect.Message {
+	mi := &file_quorum_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Accepted.ProtoReflect.Descriptor instead.
+func (*Accepted) Descriptor() ([]byte, []int) {
+	return file_quorum_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Accepted) GetSlot() int64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *Accepted) GetProposalNumber() *ProposalNumber {
+	if x != nil {
+		return x.ProposalNumber
+	}
+	return nil
+}
+
+func (x *Accepted) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Accepted) GetHighestSeen() *ProposalNumber {
+	if x != nil {
+		return x.HighestSeen
+	}
+	return nil
+}
+
+func (x *Accepted) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Accepted) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+type Learn struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot           int64           `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	ProposalNumber *ProposalNumber `protobuf:"bytes,2,opt,name=proposal_number,json=proposalNumber,proto3" json:"proposal_number,omitempty"`
+	Value          []byte          `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	From           string          `protobuf:"bytes,4,opt,name=from,proto3" json:"from,omitempty"`
+}
+
+func (x *Learn) Reset() {
+	*x = Learn{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quorum_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Learn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Learn) ProtoMessage() {}
+
+func (x *Learn) ProtoReflect() protoreflect.Message {
+	mi := &file_quorum_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Learn.ProtoReflect.Descriptor instead.
+func (*Learn) Descriptor() ([]byte, []int) {
+	return file_quorum_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Learn) GetSlot() int64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *Learn) GetProposalNumber() *ProposalNumber {
+	if x != nil {
+		return x.ProposalNumber
+	}
+	return nil
+}
+
+func (x *Learn) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Learn) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+type Envelope struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	To string `protobuf:"bytes,1,opt,name=to,proto3" json:"to,omitempty"`
+	// Types that are assignable to Message:
+	//	*Envelope_Prepare
+	//	*Envelope_Promise
+	//	*Envelope_Accept
+	//	*Envelope_Accepted
+	//	*Envelope_Learn
+	//	*Envelope_Encoded
+	Message isEnvelope_Message `protobuf_oneof:"message"`
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quorum_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_quorum_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_quorum_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Envelope) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (m *Envelope) GetMessage() isEnvelope_Message {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (x *Envelope) GetPrepare() *Prepare {
+	if x, ok := x.GetMessage().(*Envelope_Prepare); ok {
+		return x.Prepare
+	}
+	return nil
+}
+
+func (x *Envelope) GetPromise() *Promise {
+	if x, ok := x.GetMessage().(*Envelope_Promise); ok {
+		return x.Promise
+	}
+	return nil
+}
+
+func (x *Envelope) GetAccept() *Accept {
+	if x, ok := x.GetMessage().(*Envelope_Accept); ok {
+		return x.Accept
+	}
+	return nil
+}
+
+func (x *Envelope) GetAccepted() *Accepted {
+	if x, ok := x.GetMessage().(*Envelope_Accepted); ok {
+		return x.Accepted
+	}
+	return nil
+}
+
+func (x *Envelope) GetLearn() *Learn {
+	if x, ok := x.GetMessage().(*Envelope_Learn); ok {
+		return x.Learn
+	}
+	return nil
+}
+
+func (x *Envelope) GetEncoded() []byte {
+	if x, ok := x.GetMessage().(*Envelope_Encoded); ok {
+		return x.Encoded
+	}
+	return nil
+}
+
+type isEnvelope_Message interface {
+	isEnvelope_Message()
+}
+
+type Envelope_Prepare struct {
+	Prepare *Prepare `protobuf:"bytes,2,opt,name=prepare,proto3,oneof"`
+}
+
+type Envelope_Promise struct {
+	Promise *Promise `protobuf:"bytes,3,opt,name=promise,proto3,oneof"`
+}
+
+type Envelope_Accept struct {
+	Accept *Accept `protobuf:"bytes,4,opt,name=accept,proto3,oneof"`
+}
+
+type Envelope_Accepted struct {
+	Accepted *Accepted `protobuf:"bytes,5,opt,name=accepted,proto3,oneof"`
+}
+
+type Envelope_Learn struct {
+	Learn *Learn `protobuf:"bytes,6,opt,name=learn,proto3,oneof"`
+}
+
+type Envelope_Encoded struct {
+	Encoded []byte `protobuf:"bytes,7,opt,name=encoded,proto3,oneof"`
+}
+
+func (*Envelope_Prepare) isEnvelope_Message() {}
+
+func (*Envelope_Promise) isEnvelope_Message() {}
+
+func (*Envelope_Accept) isEnvelope_Message() {}
+
+func (*Envelope_Accepted) isEnvelope_Message() {}
+
+func (*Envelope_Learn) isEnvelope_Message() {}
+
+func (*Envelope_Encoded) isEnvelope_Message() {}
+
+type DeliverAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeliverAck) Reset() {
+	*x = DeliverAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quorum_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeliverAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeliverAck) ProtoMessage() {}
+
+func (x *DeliverAck) ProtoReflect() protoreflect.Message {
+	mi := &file_quorum_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeliverAck.ProtoReflect.Descriptor instead.
+func (*DeliverAck) Descriptor() ([]byte, []int) {
+	return file_quorum_proto_rawDescGZIP(), []int{7}
+}
+
+var File_quorum_proto protoreflect.FileDescriptor
+
+var file_quorum_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x10,
+	0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74,
+	0x22, 0x47, 0x0a, 0x0e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62,
+	0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x70,
+	0x6f, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x70,
+	0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22, 0x7c, 0x0a, 0x07, 0x50, 0x72, 0x65,
+	0x70, 0x61, 0x72, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x12, 0x49, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x70,
+	0x6f, 0x73, 0x61, 0x6c, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x20, 0x2e, 0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x52, 0x0e, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x22, 0x8b, 0x03, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x6d,
+	0x69, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x12, 0x49, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f,
+	0x73, 0x61, 0x6c, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x20, 0x2e, 0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70,
+	0x6f, 0x72, 0x74, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62,
+	0x65, 0x72, 0x52, 0x0e, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62,
+	0x65, 0x72, 0x12, 0x4d, 0x0a, 0x11, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x5f, 0x70,
+	0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e,
+	0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74,
+	0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x52,
+	0x10, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61,
+	0x6c, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x5f, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x61, 0x63, 0x63, 0x65, 0x70,
+	0x74, 0x65, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x68, 0x61, 0x73, 0x5f,
+	0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b,
+	0x68, 0x61, 0x73, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x43, 0x0a, 0x0c, 0x68,
+	0x69, 0x67, 0x68, 0x65, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x20, 0x2e, 0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x52, 0x0b, 0x68, 0x69, 0x67, 0x68, 0x65, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e,
+	0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x6b, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x02, 0x6f, 0x6b, 0x12, 0x1f, 0x0a, 0x0b, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x5f, 0x75, 0x6e,
+	0x74, 0x69, 0x6c, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x6c, 0x65, 0x61, 0x73, 0x65,
+	0x55, 0x6e, 0x74, 0x69, 0x6c, 0x22, 0x91, 0x01, 0x0a, 0x06, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04,
+	0x73, 0x6c, 0x6f, 0x74, 0x12, 0x49, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c,
+	0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e,
+	0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74,
+	0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x52,
+	0x0e, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x22, 0xe8, 0x01, 0x0a, 0x08, 0x41, 0x63,
+	0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x12, 0x49, 0x0a, 0x0f, 0x70, 0x72,
+	0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x52, 0x0e, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x43, 0x0a, 0x0c, 0x68,
+	0x69, 0x67, 0x68, 0x65, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x20, 0x2e, 0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x52, 0x0b, 0x68, 0x69, 0x67, 0x68, 0x65, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e,
+	0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x6b, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x02, 0x6f, 0x6b, 0x22, 0x90, 0x01, 0x0a, 0x05, 0x4c, 0x65, 0x61, 0x72, 0x6e, 0x12, 0x12,
+	0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x6c,
+	0x6f, 0x74, 0x12, 0x49, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x5f, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x71, 0x75,
+	0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x50,
+	0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x52, 0x0e, 0x70,
+	0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x22, 0xce, 0x02, 0x0a, 0x08, 0x45, 0x6e, 0x76, 0x65,
+	0x6c, 0x6f, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x74, 0x6f, 0x12, 0x35, 0x0a, 0x07, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65,
+	0x48, 0x00, 0x52, 0x07, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x12, 0x35, 0x0a, 0x07, 0x70,
+	0x72, 0x6f, 0x6d, 0x69, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x71,
+	0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e,
+	0x50, 0x72, 0x6f, 0x6d, 0x69, 0x73, 0x65, 0x48, 0x00, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6d, 0x69,
+	0x73, 0x65, 0x12, 0x32, 0x0a, 0x06, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x18, 0x2e, 0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e,
+	0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x48, 0x00, 0x52, 0x06,
+	0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x12, 0x38, 0x0a, 0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74,
+	0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x71, 0x75, 0x6f, 0x72, 0x75,
+	0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x65, 0x64, 0x48, 0x00, 0x52, 0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64,
+	0x12, 0x2f, 0x0a, 0x05, 0x6c, 0x65, 0x61, 0x72, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f,
+	0x72, 0x74, 0x2e, 0x4c, 0x65, 0x61, 0x72, 0x6e, 0x48, 0x00, 0x52, 0x05, 0x6c, 0x65, 0x61, 0x72,
+	0x6e, 0x12, 0x1a, 0x0a, 0x07, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0c, 0x48, 0x00, 0x52, 0x07, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x64, 0x42, 0x09, 0x0a,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x0c, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x69,
+	0x76, 0x65, 0x72, 0x41, 0x63, 0x6b, 0x32, 0x4f, 0x0a, 0x06, 0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d,
+	0x12, 0x45, 0x0a, 0x07, 0x44, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72, 0x12, 0x1a, 0x2e, 0x71, 0x75,
+	0x6f, 0x72, 0x75, 0x6d, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x45,
+	0x6e, 0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65, 0x1a, 0x1c, 0x2e, 0x71, 0x75, 0x6f, 0x72, 0x75, 0x6d,
+	0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x44, 0x65, 0x6c, 0x69, 0x76,
+	0x65, 0x72, 0x41, 0x63, 0x6b, 0x28, 0x01, 0x42, 0x22, 0x5a, 0x20, 0x71, 0x75, 0x6f, 0x72, 0x75,
+	0x6d, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_quorum_proto_rawDescOnce sync.Once
+	file_quorum_proto_rawDescData = file_quorum_proto_rawDesc
+)
+
+func file_quorum_proto_rawDescGZIP() []byte {
+	file_quorum_proto_rawDescOnce.Do(func() {
+		file_quorum_proto_rawDescData = protoimpl.X.CompressGZIP(file_quorum_proto_rawDescData)
+	})
+	return file_quorum_proto_rawDescData
+}
+
+var file_quorum_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_quorum_proto_goTypes = []any{
+	(*ProposalNumber)(nil), // 0: quorum.transport.ProposalNumber
+	(*Prepare)(nil),        // 1: quorum.transport.Prepare
+	(*Promise)(nil),        // 2: quorum.transport.Promise
+	(*Accept)(nil),         // 3: quorum.transport.Accept
+	(*Accepted)(nil),       // 4: quorum.transport.Accepted
+	(*Learn)(nil),          // 5: quorum.transport.Learn
+	(*Envelope)(nil),       // 6: quorum.transport.Envelope
+	(*DeliverAck)(nil),     // 7: quorum.transport.DeliverAck
+}
+var file_quorum_proto_depIdxs = []int32{
+	0,  // 0: quorum.transport.Prepare.proposal_number:type_name -> quorum.transport.ProposalNumber
+	0,  // 1: quorum.transport.Promise.proposal_number:type_name -> quorum.transport.ProposalNumber
+	0,  // 2: quorum.transport.Promise.accepted_proposal:type_name -> quorum.transport.ProposalNumber
+	0,  // 3: quorum.transport.Promise.highest_seen:type_name -> quorum.transport.ProposalNumber
+	0,  // 4: quorum.transport.Accept.proposal_number:type_name -> quorum.transport.ProposalNumber
+	0,  // 5: quorum.transport.Accepted.proposal_number:type_name -> quorum.transport.ProposalNumber
+	0,  // 6: quorum.transport.Accepted.highest_seen:type_name -> quorum.transport.ProposalNumber
+	0,  // 7: quorum.transport.Learn.proposal_number:type_name -> quorum.transport.ProposalNumber
+	1,  // 8: quorum.transport.Envelope.prepare:type_name -> quorum.transport.Prepare
+	2,  // 9: quorum.transport.Envelope.promise:type_name -> quorum.transport.Promise
+	3,  // 10: quorum.transport.Envelope.accept:type_name -> quorum.transport.Accept
+	4,  // 11: quorum.transport.Envelope.accepted:type_name -> quorum.transport.Accepted
+	5,  // 12: quorum.transport.Envelope.learn:type_name -> quorum.transport.Learn
+	6,  // 13: quorum.transport.Quorum.Deliver:input_type -> quorum.transport.Envelope
+	7,  // 14: quorum.transport.Quorum.Deliver:output_type -> quorum.transport.DeliverAck
+	14, // [14:15] is the sub-list for method output_type
+	13, // [13:14] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_quorum_proto_init() }
+func file_quorum_proto_init() {
+	if File_quorum_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_quorum_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*ProposalNumber); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quorum_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Prepare); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quorum_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*Promise); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quorum_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Accept); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quorum_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*Accepted); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quorum_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*Learn); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quorum_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*Envelope); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quorum_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*DeliverAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_quorum_proto_msgTypes[6].OneofWrappers = []any{
+		(*Envelope_Prepare)(nil),
+		(*Envelope_Promise)(nil),
+		(*Envelope_Accept)(nil),
+		(*Envelope_Accepted)(nil),
+		(*Envelope_Learn)(nil),
+		(*Envelope_Encoded)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_quorum_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_quorum_proto_goTypes,
+		DependencyIndexes: file_quorum_proto_depIdxs,
+		MessageInfos:      file_quorum_proto_msgTypes,
+	}.Build()
+	File_quorum_proto = out.File
+	file_quorum_proto_rawDesc = nil
+	file_quorum_proto_goTypes = nil
+	file_quorum_proto_depIdxs = nil
+}
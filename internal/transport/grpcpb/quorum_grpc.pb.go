@@ -0,0 +1,114 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: quorum.proto
+
+package grpcpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Quorum_Deliver_FullMethodName = "/quorum.transport.Quorum/Deliver"
+)
+
+// QuorumClient is the client API for Quorum service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type QuorumClient interface {
+	Deliver(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Envelope, DeliverAck], error)
+}
+
+type quorumClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQuorumClient(cc grpc.ClientConnInterface) QuorumClient {
+	return &quorumClient{cc}
+}
+
+func (c *quorumClient) Deliver(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Envelope, DeliverAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Quorum_ServiceDesc.Streams[0], Quorum_Deliver_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Envelope, DeliverAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Quorum_DeliverClient = grpc.ClientStreamingClient[Envelope, DeliverAck]
+
+// QuorumServer is the server API for Quorum service.
+// All implementations must embed UnimplementedQuorumServer
+// for forward compatibility.
+type QuorumServer interface {
+	Deliver(grpc.ClientStreamingServer[Envelope, DeliverAck]) error
+	mustEmbedUnimplementedQuorumServer()
+}
+
+// UnimplementedQuorumServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedQuorumServer struct{}
+
+func (UnimplementedQuorumServer) Deliver(grpc.ClientStreamingServer[Envelope, DeliverAck]) error {
+	return status.Errorf(codes.Unimplemented, "method Deliver not implemented")
+}
+func (UnimplementedQuorumServer) mustEmbedUnimplementedQuorumServer() {}
+func (UnimplementedQuorumServer) testEmbeddedByValue()                {}
+
+// UnsafeQuorumServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QuorumServer will
+// result in compilation errors.
+type UnsafeQuorumServer interface {
+	mustEmbedUnimplementedQuorumServer()
+}
+
+func RegisterQuorumServer(s grpc.ServiceRegistrar, srv QuorumServer) {
+	// If the following call pancis, it indicates UnimplementedQuorumServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Quorum_ServiceDesc, srv)
+}
+
+func _Quorum_Deliver_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(QuorumServer).Deliver(&grpc.GenericServerStream[Envelope, DeliverAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Quorum_DeliverServer = grpc.ClientStreamingServer[Envelope, DeliverAck]
+
+// Quorum_ServiceDesc is the grpc.ServiceDesc for Quorum service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Quorum_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "quorum.transport.Quorum",
+	HandlerType: (*QuorumServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Deliver",
+			Handler:       _Quorum_Deliver_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "quorum.proto",
+}
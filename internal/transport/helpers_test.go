@@ -0,0 +1,35 @@
+package transport
+
+import (
+	"testing"
+	"time"
+)
+
+type testRequest struct {
+	From string
+	N    int
+}
+
+func (m testRequest) GetFrom() string { return m.From }
+
+type testResponse struct {
+	From string
+	N    int
+}
+
+func (m testResponse) GetFrom() string { return m.From }
+
+func init() {
+	RegisterMessage(testRequest{})
+	RegisterMessage(testResponse{})
+	RegisterResponse(testResponse{})
+}
+
+func mustReceive(t testing.TB, receive func(time.Duration) (Message, error)) Message {
+	t.Helper()
+	msg, err := receive(2 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
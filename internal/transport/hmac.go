@@ -0,0 +1,93 @@
+// =============================================================================
+// HMAC CODEC - Detecting Tampered or Forged Messages
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// The transport invariant promises messages arrive uncorrupted. TLS gives
+// that and more, but sometimes all you want is integrity on a trusted LAN.
+// HMACCodec wraps any other Codec and appends an HMAC-SHA256 tag, computed
+// with a secret shared by the whole cluster, to every encoded message:
+//
+//   codec := transport.NewHMACCodec(transport.GobCodec{}, clusterSecret)
+//   t, err := transport.NewUDPTransport(id, addr, peers, codec)
+//
+//   ┌──────────────────────────┬────────────────────┐
+//   │ inner codec payload      │ HMAC-SHA256 (32 B) │
+//   └──────────────────────────┴────────────────────┘
+//
+// Decode recomputes the tag and fails with ErrTampered on a mismatch. The
+// UDP and TCP transports drop messages that don't decode, so a flipped bit
+// or a forged Accept from a node without the secret never reaches Paxos.
+// TamperedCount reports how many were rejected.
+//
+// The in-memory transport never encodes messages, so this has no effect
+// there.
+//
+// =============================================================================
+// WHAT IT DOES NOT DO
+// =============================================================================
+//
+// - No confidentiality: payloads are still readable on the wire.
+// - No replay protection: a captured message can be resent verbatim. Stack
+//   DedupTransport on top if that matters.
+// - Every holder of the secret can sign as any node.
+//
+// =============================================================================
+
+package transport
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"sync/atomic"
+)
+
+var ErrTampered = errors.New("message failed HMAC verification")
+
+type HMACCodec struct {
+	inner    Codec
+	key      []byte
+	tampered atomic.Int64
+}
+
+func NewHMACCodec(inner Codec, key []byte) *HMACCodec {
+	return &HMACCodec{
+		inner: inner,
+		key:   append([]byte(nil), key...),
+	}
+}
+
+func (c *HMACCodec) Encode(msg Message) ([]byte, error) {
+	payload, err := c.inner.Encode(msg)
+	if err != nil {
+		return nil, err
+	}
+	return append(payload, c.tag(payload)...), nil
+}
+
+func (c *HMACCodec) Decode(data []byte) (Message, error) {
+	if len(data) < sha256.Size {
+		c.tampered.Add(1)
+		return nil, ErrTampered
+	}
+	payload := data[:len(data)-sha256.Size]
+	if !hmac.Equal(data[len(payload):], c.tag(payload)) {
+		c.tampered.Add(1)
+		return nil, ErrTampered
+	}
+	return c.inner.Decode(payload)
+}
+
+func (c *HMACCodec) TamperedCount() int64 {
+	return c.tampered.Load()
+}
+
+func (c *HMACCodec) tag(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,98 @@
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+)
+
+var testSecret = []byte("cluster secret")
+
+func TestHMACCodecRoundTrip(t *testing.T) {
+	c := NewHMACCodec(GobCodec{}, testSecret)
+	data, err := c.Encode(testRequest{From: "a", N: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := c.Decode(data)
+	if err != nil || msg != (testRequest{From: "a", N: 7}) {
+		t.Fatalf("Decode = %#v, %v", msg, err)
+	}
+	if c.TamperedCount() != 0 {
+		t.Fatalf("TamperedCount = %d after a clean message", c.TamperedCount())
+	}
+}
+
+func TestHMACCodecRejectsFlippedByte(t *testing.T) {
+	c := NewHMACCodec(GobCodec{}, testSecret)
+	data, err := c.Encode(testRequest{From: "a", N: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One byte in the payload, one in the tag.
+	for i, pos := range []int{0, len(data) - 1} {
+		tampered := append([]byte(nil), data...)
+		tampered[pos] ^= 0x01
+		if _, err := c.Decode(tampered); !errors.Is(err, ErrTampered) {
+			t.Fatalf("byte %d flipped: err = %v, want ErrTampered", pos, err)
+		}
+		if got := c.TamperedCount(); got != int64(i+1) {
+			t.Fatalf("TamperedCount = %d, want %d", got, i+1)
+		}
+	}
+	if _, err := c.Decode(data[:10]); !errors.Is(err, ErrTampered) {
+		t.Fatalf("truncated message: err = %v, want ErrTampered", err)
+	}
+}
+
+func TestHMACCodecRejectsOtherKey(t *testing.T) {
+	forged, err := NewHMACCodec(GobCodec{}, []byte("wrong secret")).Encode(testRequest{From: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewHMACCodec(GobCodec{}, testSecret).Decode(forged); !errors.Is(err, ErrTampered) {
+		t.Fatalf("err = %v, want ErrTampered", err)
+	}
+}
+
+func TestUDPDropsTamperedMessage(t *testing.T) {
+	codec := NewHMACCodec(GobCodec{}, testSecret)
+	a, err := NewUDPTransport("a", "127.0.0.1:0", nil, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewUDPTransport("b", "127.0.0.1:0", nil, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if err := a.AddPeer("b", b.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := codec.Encode(testRequest{From: "a", N: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload[len(payload)/2] ^= 0xff
+	frame := []byte{frameKindData, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	raw, err := net.DialUDP("udp", nil, b.conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	raw.Write(append(frame, payload...))
+
+	if err := a.Send("b", testRequest{From: "a", N: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := mustReceive(t, b.ReceiveTimeout); msg != (testRequest{From: "a", N: 2}) {
+		t.Fatalf("got %#v, want only the untampered message", msg)
+	}
+	if got := codec.TamperedCount(); got != 1 {
+		t.Fatalf("TamperedCount = %d, want 1", got)
+	}
+}
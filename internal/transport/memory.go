@@ -212,7 +212,7 @@
 // ADVANCED: SIMULATING FAILURES
 // =============================================================================
 //
-// For advanced testing, add methods like:
+// Network provides:
 //
 //   func (n *Network) Partition(nodeA, nodeB string)
 //     // Block messages between A and B
@@ -220,8 +220,25 @@
 //   func (n *Network) Heal(nodeA, nodeB string)
 //     // Restore communication
 //
-//   func (n *Network) SetMessageLoss(probability float64)
-//     // Randomly drop messages
+//   func (n *Network) SetDelay(min, max time.Duration)
+//     // Hold each message for a random time
+//
+//   func (n *Network) SetLinkLatency(from, to string, d time.Duration)
+//     // Add a fixed delay to one direction of one link, on top of SetDelay
+//     // or chaos: a slow follower, or a cross-region link
+//
+//   func (t *MemoryTransport) SetSelfDelivery(on bool)
+//     // Include the sender in its own Broadcasts: single-node clusters
+//
+//   func (n *Network) SetIdleTimeout(d time.Duration)
+//     // Close transports that go d without traffic
+//
+//   func (n *Network) Close() error
+//     // Close every transport on the network
+//
+//   func (n *Network) EnableChaos(config ChaosConfig)
+//     // Random loss, delay, reordering and partitions from a seed
+//     // (see chaos.go)
 //
 // These help test Paxos behavior under failure conditions.
 //
@@ -234,41 +251,422 @@
 // care about that.
 //
 // =============================================================================
+// REQUESTS AND RESPONSES
+// =============================================================================
+//
+// A node reads its inbox from two places: the message loop, which answers
+// Prepare and Accept through the acceptor, and the proposer, which waits
+// for Promise and Accepted. With one inbox each steals the other's
+// messages, and whatever it can't use is lost.
+//
+// So every MemoryTransport has two inboxes. A message whose type was
+// passed to RegisterResponse lands in the response inbox, read only by
+// ReceiveResponse and ReceiveResponseTimeout; everything else lands in the
+// request inbox that Receive reads. Each has its own buffer, so a burst of
+// one kind can't crowd out the other. The classification looks through
+// DedupTransport's SequencedMessage envelope.
+//
+// The node package registers the Paxos replies. With nothing registered
+// the response inbox stays empty and Receive sees every message.
+//
+// =============================================================================
+// INSPECTION (TESTS ONLY)
+// =============================================================================
+//
+// A channel can't be read without consuming it, so Inbox needs a mirror of
+// every inbox kept alongside the channels. That mirror costs a lock per
+// send, so it is off unless a test calls EnableInspection before any
+// traffic. Pending is just the number of queued messages and is always
+// available.
+//
+// =============================================================================
 
 package transport
 
 import (
+	"math/rand"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var responseTypes sync.Map
+
+// RegisterResponse routes messages of sample's type, or a pointer to it,
+// to the response inbox. See REQUESTS AND RESPONSES above.
+func RegisterResponse(sample Message) {
+	responseTypes.Store(reflect.TypeOf(sample), true)
+}
+
+func isResponse(msg Message) bool {
+	if sm, ok := msg.(SequencedMessage); ok {
+		msg = sm.Msg
+	}
+	t := reflect.TypeOf(msg)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	_, ok := responseTypes.Load(t)
+	return ok
+}
+
+// responseQueue is the inspection key for id's response inbox.
+func responseQueue(id string) string {
+	return id + "\x00responses"
+}
+
 type Network struct {
-	channels map[string]chan Message
-	mu       sync.RWMutex
+	channels    map[string]chan Message
+	responses   map[string]chan Message
+	mu          sync.RWMutex
+	inspect     atomic.Bool
+	queues      map[string][]Message
+	inspectMu   sync.Mutex
+	delayMin    time.Duration
+	delayMax    time.Duration
+	rng         *rand.Rand
+	delayMu     sync.Mutex
+	latency     map[string]time.Duration
+	stats       *networkStats
+	fifo        atomic.Bool
+	links       map[string]*fifoLink
+	linksMu     sync.Mutex
+	blocked     map[string]bool
+	blockMu     sync.RWMutex
+	chaos       *chaosState
+	chaosMu     sync.Mutex
+	transports  map[string]*MemoryTransport
+	idleTimeout time.Duration
 }
 
 func NewNetwork() *Network {
 	return &Network{
-		channels: make(map[string]chan Message),
+		channels:   make(map[string]chan Message),
+		responses:  make(map[string]chan Message),
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		stats:      newNetworkStats(),
+		links:      make(map[string]*fifoLink),
+		latency:    make(map[string]time.Duration),
+		blocked:    make(map[string]bool),
+		transports: make(map[string]*MemoryTransport),
 	}
 }
 
+const defaultInboxSize = 100
+
 func (n *Network) AddNode(id string) *MemoryTransport {
+	return n.AddNodeWithBuffer(id, defaultInboxSize)
+}
+
+// AddNodeWithBuffer is AddNode with an inbox of size messages instead of
+// defaultInboxSize, for each of its two inboxes. A send to a full inbox drops the new message and
+// returns ErrInboxFull, so a burst larger than the buffer loses its tail;
+// size it for the largest burst a test produces (roughly one Promise or
+// Accepted per peer per proposer per round). Sends never block on a full
+// inbox: a node's loop sends while it handles messages, and a blocking
+// send into a small buffer could wait on a node that is itself blocked
+// sending back.
+//
+// Adding an ID that is already attached takes its inboxes over and marks
+// both transports, so DuplicateID reports true on each. Remove or close
+// the old one first to replace a node.
+func (n *Network) AddNodeWithBuffer(id string, size int) *MemoryTransport {
 	n.mu.Lock()
 	defer n.mu.Unlock()
-	inbox := make(chan Message, 100) 
+	if size < 0 {
+		size = 0
+	}
+	inbox := make(chan Message, size)
+	responses := make(chan Message, size)
 	n.channels[id] = inbox
-	return &MemoryTransport{
-		nodeID:  id,
-		inbox:   inbox,
-		network: n,
+	n.responses[id] = responses
+	t := &MemoryTransport{
+		nodeID:    id,
+		inbox:     inbox,
+		responses: responses,
+		network:   n,
+	}
+	if old, ok := n.transports[id]; ok {
+		old.duplicate.Store(true)
+		t.duplicate.Store(true)
 	}
+	n.transports[id] = t
+	if n.idleTimeout > 0 {
+		t.startIdleTimer(n.idleTimeout)
+	}
+	return t
 }
 
 func (n *Network) RemoveNode(id string) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 	delete(n.channels, id)
+	delete(n.responses, id)
+	delete(n.transports, id)
+	n.inspectMu.Lock()
+	delete(n.queues, id)
+	delete(n.queues, responseQueue(id))
+	n.inspectMu.Unlock()
+}
+
+// SetIdleTimeout makes every transport added afterwards close itself once
+// it has gone d without sending or receiving a message. Its Receive then
+// returns ErrClosed. Zero, the default, never closes.
+func (n *Network) SetIdleTimeout(d time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.idleTimeout = d
+}
+
+// Close closes every transport still attached to the network and turns
+// chaos off. Messages still held back by a delay are dropped on arrival.
+func (n *Network) Close() error {
+	n.DisableChaos()
+	n.mu.RLock()
+	transports := make([]*MemoryTransport, 0, len(n.transports))
+	for _, t := range n.transports {
+		transports = append(transports, t)
+	}
+	n.mu.RUnlock()
+	for _, t := range transports {
+		t.Close()
+	}
+	return nil
+}
+
+// EnableInspection starts mirroring inboxes so Inbox can report them.
+// Messages already queued when it is called are not mirrored.
+func (n *Network) EnableInspection() {
+	n.inspectMu.Lock()
+	defer n.inspectMu.Unlock()
+	if n.queues == nil {
+		n.queues = make(map[string][]Message)
+	}
+	n.inspect.Store(true)
+}
+
+// Inbox returns a copy of the messages queued for id, oldest first, without
+// consuming them: the request inbox, then the response inbox. It returns
+// nil unless EnableInspection was called.
+func (n *Network) Inbox(id string) []Message {
+	n.inspectMu.Lock()
+	defer n.inspectMu.Unlock()
+	requests, responses := n.queues[id], n.queues[responseQueue(id)]
+	if len(requests)+len(responses) == 0 {
+		return nil
+	}
+	out := make([]Message, 0, len(requests)+len(responses))
+	out = append(out, requests...)
+	return append(out, responses...)
+}
+
+// SetDelay holds every message for a random duration in [min, max] before
+// it reaches the inbox. Delayed sends return nil immediately; a message
+// whose inbox is full or gone by the time it lands is dropped. SetDelay(0, 0)
+// turns delay off.
+func (n *Network) SetDelay(min, max time.Duration) {
+	n.delayMu.Lock()
+	defer n.delayMu.Unlock()
+	if max < min {
+		max = min
+	}
+	n.delayMin = min
+	n.delayMax = max
+}
+
+func (n *Network) nextDelay() time.Duration {
+	n.delayMu.Lock()
+	defer n.delayMu.Unlock()
+	if n.delayMax <= 0 {
+		return 0
+	}
+	spread := n.delayMax - n.delayMin
+	if spread == 0 {
+		return n.delayMin
+	}
+	return n.delayMin + time.Duration(n.rng.Int63n(int64(spread)+1))
+}
+
+// SetLinkLatency adds d to every message sent from from to to. Only that
+// direction is affected, so asymmetric links take two calls. d <= 0
+// removes the latency.
+func (n *Network) SetLinkLatency(from, to string, d time.Duration) {
+	n.delayMu.Lock()
+	defer n.delayMu.Unlock()
+	if d <= 0 {
+		delete(n.latency, linkKey(from, to))
+		return
+	}
+	n.latency[linkKey(from, to)] = d
+}
+
+func (n *Network) linkLatency(from, to string) time.Duration {
+	n.delayMu.Lock()
+	defer n.delayMu.Unlock()
+	return n.latency[linkKey(from, to)]
+}
+
+// SetFIFOPerSender makes messages on each (from, to) link arrive in the
+// order they were sent, even when SetDelay would reorder them. Messages from
+// different senders still interleave freely.
+func (n *Network) SetFIFOPerSender(fifo bool) {
+	n.fifo.Store(fifo)
+}
+
+type heldMessage struct {
+	msg    Message
+	sentAt time.Time
+}
+
+// fifoLink numbers the messages on one (from, to) link and holds back any
+// that land before their predecessors.
+type fifoLink struct {
+	sent      uint64
+	delivered uint64
+	held      map[uint64]heldMessage
+	mu        sync.Mutex
+}
+
+func (n *Network) link(from, to string) *fifoLink {
+	n.linksMu.Lock()
+	defer n.linksMu.Unlock()
+	key := linkKey(from, to)
+	l, ok := n.links[key]
+	if !ok {
+		l = &fifoLink{held: make(map[uint64]heldMessage)}
+		n.links[key] = l
+	}
+	return l
+}
+
+func (l *fifoLink) next() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sent++
+	return l.sent
+}
+
+// release delivers message seq once every earlier message on the link has
+// been delivered, along with any later ones it was holding up.
+func (l *fifoLink) release(n *Network, to string, seq uint64, h heldMessage) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.held[seq] = h
+	var err error
+	for {
+		next, ok := l.held[l.delivered+1]
+		if !ok {
+			return err
+		}
+		delete(l.held, l.delivered+1)
+		l.delivered++
+		if e := n.deliver(to, next.msg, next.sentAt); l.delivered == seq {
+			err = e
+		}
+	}
+}
+
+func linkKey(from, to string) string {
+	return from + "\x00" + to
+}
+
+// Partition cuts the link between nodeA and nodeB in both directions.
+// Messages sent across it are silently lost, as on a real network.
+func (n *Network) Partition(nodeA, nodeB string) {
+	n.blockMu.Lock()
+	defer n.blockMu.Unlock()
+	n.blocked[linkKey(nodeA, nodeB)] = true
+	n.blocked[linkKey(nodeB, nodeA)] = true
+}
+
+func (n *Network) Heal(nodeA, nodeB string) {
+	n.blockMu.Lock()
+	defer n.blockMu.Unlock()
+	delete(n.blocked, linkKey(nodeA, nodeB))
+	delete(n.blocked, linkKey(nodeB, nodeA))
+}
+
+func (n *Network) isBlocked(from, to string) bool {
+	n.blockMu.RLock()
+	defer n.blockMu.RUnlock()
+	return n.blocked[linkKey(from, to)]
+}
+
+func (n *Network) send(from, to string, msg Message) error {
+	if _, ok := n.getChannel(to); !ok {
+		return ErrUnknownNode
+	}
+	n.stats.recordSent(msg)
+	drop, chaosDelay, chaotic := n.chaosDecision()
+	if drop || n.isBlocked(from, to) {
+		return nil
+	}
+	h := heldMessage{msg: msg, sentAt: time.Now()}
+	deliver := func() error {
+		return n.deliver(to, h.msg, h.sentAt)
+	}
+	if n.fifo.Load() {
+		l := n.link(from, to)
+		seq := l.next()
+		deliver = func() error {
+			return l.release(n, to, seq, h)
+		}
+	}
+	d := n.nextDelay()
+	if chaotic {
+		d = chaosDelay
+	}
+	d += n.linkLatency(from, to)
+	if d > 0 {
+		time.AfterFunc(d, func() {
+			deliver()
+		})
+		return nil
+	}
+	return deliver()
+}
+
+// deliver holds the registry read lock across the channel send so that
+// RemoveNode, and therefore Close, can't close the inbox underneath it.
+func (n *Network) deliver(to string, msg Message, sentAt time.Time) error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	inbox, ok := n.channels[to]
+	if !ok {
+		return ErrUnknownNode
+	}
+	queue := to
+	if isResponse(msg) {
+		inbox, queue = n.responses[to], responseQueue(to)
+	}
+	if n.inspect.Load() {
+		n.inspectMu.Lock()
+		defer n.inspectMu.Unlock()
+	}
+	select {
+	case inbox <- msg:
+		if n.inspect.Load() {
+			n.queues[queue] = append(n.queues[queue], msg)
+		}
+		n.stats.recordDelivered(time.Since(sentAt))
+		return nil
+	default:
+		return ErrInboxFull
+	}
+}
+
+// consumed pops the front of the inspection queue named by key: a node ID
+// or its responseQueue.
+func (n *Network) consumed(key string) {
+	if !n.inspect.Load() {
+		return
+	}
+	n.inspectMu.Lock()
+	defer n.inspectMu.Unlock()
+	if queue := n.queues[key]; len(queue) > 0 {
+		n.queues[key] = queue[1:]
+	}
 }
 
 func (n *Network) getChannel(id string) (chan Message, bool) {
@@ -289,11 +687,47 @@ func (n *Network) getAllNodes() []string {
 }
 
 type MemoryTransport struct {
-	nodeID  string
-	inbox   chan Message
-	network *Network
-	closed  bool
-	mu      sync.Mutex
+	nodeID      string
+	inbox       chan Message
+	responses   chan Message
+	network     *Network
+	closed      bool
+	mu          sync.Mutex
+	idle        *time.Timer
+	idleTimeout time.Duration
+	lastActive  atomic.Int64
+	selfDeliver atomic.Bool
+	duplicate   atomic.Bool
+}
+
+// startIdleTimer arms the idle check. The timer re-arms itself for the
+// remaining time whenever there was activity since it was set.
+func (t *MemoryTransport) startIdleTimer(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.idleTimeout = d
+	t.touch()
+	t.idle = time.AfterFunc(d, t.checkIdle)
+}
+
+func (t *MemoryTransport) touch() {
+	t.lastActive.Store(time.Now().UnixNano())
+}
+
+func (t *MemoryTransport) checkIdle() {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+	elapsed := time.Since(time.Unix(0, t.lastActive.Load()))
+	if elapsed < t.idleTimeout {
+		t.idle.Reset(t.idleTimeout - elapsed)
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+	t.Close()
 }
 
 func (t *MemoryTransport) Send(to string, msg Message) error {
@@ -303,63 +737,101 @@ func (t *MemoryTransport) Send(to string, msg Message) error {
 		return ErrClosed
 	}
 	t.mu.Unlock()
-	destInbox, ok := t.network.getChannel(to)
-	if !ok {
-		return ErrUnknownNode
-	}
-	select {
-	case destInbox <- msg:
-		return nil
-	default:
-		return ErrInboxFull
-	}
+	t.touch()
+	return t.network.send(t.nodeID, to, msg)
 }
 
+// SetSelfDelivery makes Broadcast deliver to this transport's own inbox as
+// well as to its peers, so a one-node cluster with quorum 1 hears its own
+// acceptor and can choose a value with nobody else on the network.
+func (t *MemoryTransport) SetSelfDelivery(on bool) {
+	t.selfDeliver.Store(on)
+}
+
+// Broadcast reports only ErrClosed and ErrNoPeers; a message lost on the
+// way to one peer is like any other lost message. BroadcastDetailed says
+// which peers it was.
 func (t *MemoryTransport) Broadcast(msg Message) error {
+	_, err := t.BroadcastDetailed(msg)
+	return err
+}
+
+func (t *MemoryTransport) BroadcastDetailed(msg Message) (BroadcastResult, error) {
 	t.mu.Lock()
 	if t.closed {
 		t.mu.Unlock()
-		return ErrClosed
+		return nil, ErrClosed
 	}
 	t.mu.Unlock()
 	nodes := t.network.getAllNodes()
+	result := make(BroadcastResult, len(nodes))
 	for _, nodeID := range nodes {
-		if nodeID == t.nodeID {
+		if nodeID == t.nodeID && !t.selfDeliver.Load() {
 			continue 
 		}
-		t.Send(nodeID, msg)
+		result[nodeID] = t.Send(nodeID, msg)
 	}
-	return nil
+	if len(result) == 0 {
+		return nil, ErrNoPeers
+	}
+	return result, nil
 }
 
+// Receive returns the next request; see REQUESTS AND RESPONSES above.
 func (t *MemoryTransport) Receive() (Message, error) {
-	t.mu.Lock()
-	if t.closed {
-		t.mu.Unlock()
-		return nil, ErrClosed
-	}
-	t.mu.Unlock()
-	msg, ok := <-t.inbox
-	if !ok {
-		return nil, ErrClosed
-	}
-	return msg, nil
+	return t.receive(t.inbox, t.nodeID, -1)
 }
 
 func (t *MemoryTransport) ReceiveTimeout(timeout time.Duration) (Message, error) {
+	return t.receive(t.inbox, t.nodeID, timeout)
+}
+
+// ReceiveResponse returns the next message of a type passed to
+// RegisterResponse.
+func (t *MemoryTransport) ReceiveResponse() (Message, error) {
+	return t.receive(t.responses, responseQueue(t.nodeID), -1)
+}
+
+func (t *MemoryTransport) ReceiveResponseTimeout(timeout time.Duration) (Message, error) {
+	return t.receive(t.responses, responseQueue(t.nodeID), timeout)
+}
+
+func (t *MemoryTransport) TryReceiveResponse() (Message, bool) {
+	select {
+	case msg, ok := <-t.responses:
+		if !ok {
+			return nil, false
+		}
+		t.touch()
+		t.network.consumed(responseQueue(t.nodeID))
+		return msg, true
+	default:
+		return nil, false
+	}
+}
+
+// receive reads from one of the two inboxes. A negative timeout waits
+// forever.
+func (t *MemoryTransport) receive(inbox chan Message, queue string, timeout time.Duration) (Message, error) {
 	t.mu.Lock()
 	if t.closed {
 		t.mu.Unlock()
 		return nil, ErrClosed
 	}
 	t.mu.Unlock()
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		expired = time.After(timeout)
+	}
 	select {
-	case msg, ok := <-t.inbox:
+	case msg, ok := <-inbox:
 		if !ok {
 			return nil, ErrClosed
 		}
+		t.touch()
+		t.network.consumed(queue)
 		return msg, nil
-	case <-time.After(timeout):
+	case <-expired:
 		return nil, ErrTimeout
 	}
 }
@@ -371,11 +843,27 @@ func (t *MemoryTransport) Close() error {
 		return nil
 	}
 	t.closed = true
-	close(t.inbox)
+	if t.idle != nil {
+		t.idle.Stop()
+	}
 	t.network.RemoveNode(t.nodeID)
+	close(t.inbox)
+	close(t.responses)
 	return nil
 }
 
+// DuplicateID reports whether another transport was added to the network
+// under this one's ID while both were attached. It stays true once set.
+func (t *MemoryTransport) DuplicateID() bool {
+	return t.duplicate.Load()
+}
+
+// Pending reports how many messages are waiting in this transport's two
+// inboxes.
+func (t *MemoryTransport) Pending() int {
+	return len(t.inbox) + len(t.responses)
+}
+
 func (t *MemoryTransport) NodeID() string {
 	return t.nodeID
 }
@@ -0,0 +1,311 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"quorum/internal/paxos"
+)
+
+func TestBroadcastFansOutToEveryPeer(t *testing.T) {
+	net := NewNetwork()
+	defer net.Close()
+	net.EnableInspection()
+	ids := []string{"n0", "n1", "n2", "n3"}
+	trs := make(map[string]*MemoryTransport)
+	for _, id := range ids {
+		trs[id] = net.AddNode(id)
+	}
+
+	if err := trs["n0"].Broadcast(testRequest{From: "n0", N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := trs["n0"].Pending(); got != 0 {
+		t.Fatalf("sender has %d pending, want 0", got)
+	}
+	for _, id := range ids[1:] {
+		if got := trs[id].Pending(); got != 1 {
+			t.Fatalf("%s has %d pending, want 1", id, got)
+		}
+		inbox := net.Inbox(id)
+		if len(inbox) != 1 || inbox[0] != (testRequest{From: "n0", N: 1}) {
+			t.Fatalf("%s inbox = %v", id, inbox)
+		}
+	}
+
+	// Looking doesn't consume; receiving does.
+	if got := net.Inbox("n1"); len(got) != 1 {
+		t.Fatalf("second look at n1 = %v", got)
+	}
+	mustReceive(t, trs["n1"].ReceiveTimeout)
+	if got := net.Inbox("n1"); len(got) != 0 {
+		t.Fatalf("n1 inbox after Receive = %v, want empty", got)
+	}
+	if got := trs["n1"].Pending(); got != 0 {
+		t.Fatalf("n1 has %d pending after Receive", got)
+	}
+}
+
+func TestInboxNeedsInspection(t *testing.T) {
+	net := NewNetwork()
+	defer net.Close()
+	a, b := net.AddNode("a"), net.AddNode("b")
+	if err := a.Send("b", testRequest{From: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := net.Inbox("b"); got != nil {
+		t.Fatalf("Inbox without EnableInspection = %v, want nil", got)
+	}
+	if got := b.Pending(); got != 1 {
+		t.Fatalf("Pending = %d, want 1", got)
+	}
+}
+
+func TestInboxSeparatesResponses(t *testing.T) {
+	net := NewNetwork()
+	defer net.Close()
+	net.EnableInspection()
+	a, b := net.AddNode("a"), net.AddNode("b")
+	if err := a.Send("b", testResponse{From: "a", N: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Send("b", testRequest{From: "a", N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	inbox := net.Inbox("b")
+	if len(inbox) != 2 || inbox[0] != (testRequest{From: "a", N: 1}) || inbox[1] != (testResponse{From: "a", N: 2}) {
+		t.Fatalf("inbox = %v, want the request then the response", inbox)
+	}
+	mustReceive(t, b.ReceiveResponseTimeout)
+	if inbox := net.Inbox("b"); len(inbox) != 1 || inbox[0] != (testRequest{From: "a", N: 1}) {
+		t.Fatalf("inbox after taking the response = %v", inbox)
+	}
+}
+
+func TestRequestsAndResponsesDoNotStarveEachOther(t *testing.T) {
+	const count = 50
+	net := NewNetwork()
+	defer net.Close()
+	b := net.AddNode("b")
+	senders := []*MemoryTransport{net.AddNode("a0"), net.AddNode("a1")}
+
+	// A request inbox nobody reads fills up without blocking responses.
+	for i := 0; i < defaultInboxSize+10; i++ {
+		senders[0].Send("b", testRequest{From: "a0", N: -1})
+	}
+	if err := senders[1].Send("b", testResponse{From: "a1"}); err != nil {
+		t.Fatal(err)
+	}
+	mustReceive(t, b.ReceiveResponseTimeout)
+	for b.Pending() > 0 {
+		mustReceive(t, b.ReceiveTimeout)
+	}
+
+	// The acceptor loop and the proposer read at once while both kinds
+	// arrive interleaved; each sees every message of its kind and no other.
+	requests, responses := make(chan error, 1), make(chan error, 1)
+	loop := func(receive func(time.Duration) (Message, error), want func(Message) bool, done chan<- error) {
+		for i := 0; i < 2*count; i++ {
+			msg, err := receive(2 * time.Second)
+			if err != nil {
+				done <- fmt.Errorf("after %d messages: %v", i, err)
+				return
+			}
+			if !want(msg) {
+				done <- fmt.Errorf("got %#v", msg)
+				return
+			}
+		}
+		done <- nil
+	}
+	go loop(b.ReceiveTimeout, func(m Message) bool { _, ok := m.(testRequest); return ok }, requests)
+	go loop(b.ReceiveResponseTimeout, func(m Message) bool { _, ok := m.(testResponse); return ok }, responses)
+	for _, s := range senders {
+		s := s
+		go func() {
+			for i := 0; i < count; i++ {
+				s.Send("b", testRequest{From: s.NodeID(), N: i})
+				s.Send("b", testResponse{From: s.NodeID(), N: i})
+			}
+		}()
+	}
+	if err := <-requests; err != nil {
+		t.Fatalf("request loop: %v", err)
+	}
+	if err := <-responses; err != nil {
+		t.Fatalf("response loop: %v", err)
+	}
+}
+
+func TestFIFOPerSenderKeepsPrepareBeforeAccept(t *testing.T) {
+	net := NewNetwork()
+	defer net.Close()
+	net.SetDelay(0, 10*time.Millisecond)
+	net.SetFIFOPerSender(true)
+	a, b := net.AddNode("a"), net.AddNode("b")
+
+	const rounds = 20
+	for i := int64(1); i <= rounds; i++ {
+		n := paxos.NewProposalNumber(i, "a")
+		if err := a.Send("b", paxos.Prepare{ProposalNumber: n, From: "a"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Send("b", paxos.Accept{ProposalNumber: n, Value: []byte("v"), From: "a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := int64(1); i <= rounds; i++ {
+		for _, want := range []string{"paxos.Prepare", "paxos.Accept"} {
+			msg := mustReceive(t, b.ReceiveTimeout)
+			var round int64
+			switch m := msg.(type) {
+			case paxos.Prepare:
+				round = m.ProposalNumber.Round
+			case paxos.Accept:
+				round = m.ProposalNumber.Round
+			}
+			if got := fmt.Sprintf("%T", msg); got != want || round != i {
+				t.Fatalf("got %s for round %d, want %s for round %d", got, round, want, i)
+			}
+		}
+	}
+}
+
+func TestBroadcastWithoutPeers(t *testing.T) {
+	net := NewNetwork()
+	defer net.Close()
+	alone := net.AddNode("alone")
+	if err := alone.Broadcast(testRequest{From: "alone"}); !errors.Is(err, ErrNoPeers) {
+		t.Fatalf("err = %v, want ErrNoPeers", err)
+	}
+	net.AddNode("peer")
+	if err := alone.Broadcast(testRequest{From: "alone"}); err != nil {
+		t.Fatalf("Broadcast with a peer: %v", err)
+	}
+}
+
+func TestIdleTransportCloses(t *testing.T) {
+	net := NewNetwork()
+	defer net.Close()
+	net.SetIdleTimeout(30 * time.Millisecond)
+	busy, idle := net.AddNode("busy"), net.AddNode("idle")
+	peer := net.AddNode("peer")
+
+	// Traffic every 10ms keeps busy open well past the timeout.
+	for i := 0; i < 8; i++ {
+		if err := peer.Send("busy", testRequest{From: "peer", N: i}); err != nil {
+			t.Fatalf("send %d to a busy transport: %v", i, err)
+		}
+		mustReceive(t, busy.ReceiveTimeout)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := idle.ReceiveTimeout(time.Second); !errors.Is(err, ErrClosed) {
+		t.Fatalf("idle transport: err = %v, want ErrClosed", err)
+	}
+}
+
+func TestClosedNetworksLeaveNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		net := NewNetwork()
+		net.SetIdleTimeout(time.Minute)
+		net.SetDelay(0, time.Millisecond)
+		a := net.AddNode("a")
+		net.AddNode("b")
+		net.AddNode("c")
+		a.Broadcast(testRequest{From: "a", N: i})
+		net.Close()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines before, %d after closing 50 networks", before, runtime.NumGoroutine())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// burst broadcasts count requests from n0 to n1 and n2 and returns how many
+// sends came back ErrInboxFull.
+func burst(t *testing.T, size, count int) (full int) {
+	t.Helper()
+	net := NewNetwork()
+	defer net.Close()
+	n0 := net.AddNodeWithBuffer("n0", size)
+	peers := []*MemoryTransport{net.AddNodeWithBuffer("n1", size), net.AddNodeWithBuffer("n2", size)}
+	for i := 0; i < count; i++ {
+		result, err := n0.BroadcastDetailed(testRequest{From: "n0", N: i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, err := range result {
+			if errors.Is(err, ErrInboxFull) {
+				full++
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// A full inbox drops the newest message, so each peer holds the
+	// first messages of the burst, up to its buffer.
+	want := count
+	if size < count {
+		want = size
+	}
+	for _, p := range peers {
+		if got := p.Pending(); got != want {
+			t.Fatalf("%s has %d pending after a burst of %d, want %d", p.nodeID, got, count, want)
+		}
+		if msg, err := p.Receive(); err != nil || msg != (testRequest{From: "n0", N: 0}) {
+			t.Fatalf("%s received %v, %v first, want the head of the burst", p.nodeID, msg, err)
+		}
+	}
+	return full
+}
+
+func TestLargeInboxTakesBurstWithoutDropping(t *testing.T) {
+	const count = 500
+	if full := burst(t, defaultInboxSize, count); full == 0 {
+		t.Fatalf("a burst of %d fit the default inbox of %d; the test proves nothing", count, defaultInboxSize)
+	}
+	if full := burst(t, count, count); full != 0 {
+		t.Fatalf("%d sends dropped into an inbox sized for the burst", full)
+	}
+}
+
+func TestLinkLatencyIsOneWay(t *testing.T) {
+	net := NewNetwork()
+	defer net.Close()
+	a, b := net.AddNode("a"), net.AddNode("b")
+	net.SetLinkLatency("a", "b", 100*time.Millisecond)
+
+	if err := b.Send("a", testRequest{From: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ReceiveTimeout(20 * time.Millisecond); err != nil {
+		t.Fatalf("b to a is not slowed: %v", err)
+	}
+
+	start := time.Now()
+	if err := a.Send("b", testRequest{From: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.ReceiveTimeout(20 * time.Millisecond); err == nil {
+		t.Fatal("a to b arrived before its latency")
+	}
+	if _, err := b.ReceiveTimeout(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took < 100*time.Millisecond {
+		t.Fatalf("a to b took %v, want at least 100ms", took)
+	}
+
+	net.SetLinkLatency("a", "b", 0)
+	a.Send("b", testRequest{From: "a"})
+	if _, err := b.ReceiveTimeout(20 * time.Millisecond); err != nil {
+		t.Fatalf("latency still applied after clearing it: %v", err)
+	}
+}
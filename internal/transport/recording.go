@@ -0,0 +1,229 @@
+// =============================================================================
+// RECORDING TRANSPORT - A Journal of Every Message for Post-Mortems
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// When a safety check fails after thousands of messages, the logs rarely
+// say enough. RecordingTransport is a decorator, like DedupTransport, that
+// writes every Send, Broadcast, Receive and failed send into a Journal:
+//
+//   j := transport.NewJournal()
+//   t0 := transport.NewRecordingTransport("node-0", net.AddNode("node-0"), j)
+//   t1 := transport.NewRecordingTransport("node-1", net.AddNode("node-1"), j)
+//   ...
+//   j.WriteJSON(os.Stdout)
+//
+// One Journal is normally shared by every node, giving a single ordered
+// history of the whole cluster. Events are appended under a lock, so the
+// order is the order the journal saw them, which for a single process is
+// the real interleaving.
+//
+// =============================================================================
+// EVENTS
+// =============================================================================
+//
+//   send      - Send(to, msg) returned nil
+//   broadcast - Broadcast(msg) was called
+//   receive   - Receive/ReceiveTimeout returned msg
+//   drop      - Send or Broadcast failed; Err says why
+//
+// A message that the inner transport silently loses shows up as a send with
+// no matching receive.
+//
+// =============================================================================
+
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type EventKind string
+
+const (
+	EventSend      EventKind = "send"
+	EventBroadcast EventKind = "broadcast"
+	EventReceive   EventKind = "receive"
+	EventDrop      EventKind = "drop"
+)
+
+type RecordedEvent struct {
+	Time    time.Time `json:"time"`
+	Kind    EventKind `json:"kind"`
+	Node    string    `json:"node"`
+	To      string    `json:"to,omitempty"`
+	Type    string    `json:"type"`
+	Message Message   `json:"message"`
+	Err     string    `json:"err,omitempty"`
+}
+
+type Journal struct {
+	events []RecordedEvent
+	mu     sync.Mutex
+}
+
+func NewJournal() *Journal {
+	return &Journal{}
+}
+
+func (j *Journal) record(kind EventKind, node, to string, msg Message, err error) {
+	e := RecordedEvent{
+		Time:    time.Now(),
+		Kind:    kind,
+		Node:    node,
+		To:      to,
+		Type:    fmt.Sprintf("%T", msg),
+		Message: msg,
+	}
+	if err != nil {
+		e.Err = err.Error()
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events = append(j.events, e)
+}
+
+// Events returns a copy of the journal in recording order.
+func (j *Journal) Events() []RecordedEvent {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := make([]RecordedEvent, len(j.events))
+	copy(out, j.events)
+	return out
+}
+
+// WriteJSON writes the journal as a JSON array.
+func (j *Journal) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j.Events())
+}
+
+type RecordingTransport struct {
+	nodeID  string
+	inner   Transport
+	journal *Journal
+}
+
+func NewRecordingTransport(id string, inner Transport, journal *Journal) *RecordingTransport {
+	return &RecordingTransport{
+		nodeID:  id,
+		inner:   inner,
+		journal: journal,
+	}
+}
+
+func (t *RecordingTransport) Send(to string, msg Message) error {
+	err := t.inner.Send(to, msg)
+	if err != nil {
+		t.journal.record(EventDrop, t.nodeID, to, msg, err)
+		return err
+	}
+	t.journal.record(EventSend, t.nodeID, to, msg, nil)
+	return nil
+}
+
+func (t *RecordingTransport) Broadcast(msg Message) error {
+	t.journal.record(EventBroadcast, t.nodeID, "", msg, nil)
+	err := t.inner.Broadcast(msg)
+	if err != nil {
+		t.journal.record(EventDrop, t.nodeID, "", msg, err)
+	}
+	return err
+}
+
+// BroadcastDetailed records the broadcast and a drop for each destination
+// that failed. If the inner transport can't report per destination, the
+// result is empty.
+func (t *RecordingTransport) BroadcastDetailed(msg Message) (BroadcastResult, error) {
+	d, ok := t.inner.(DetailedBroadcaster)
+	if !ok {
+		return BroadcastResult{}, t.Broadcast(msg)
+	}
+	t.journal.record(EventBroadcast, t.nodeID, "", msg, nil)
+	result, err := d.BroadcastDetailed(msg)
+	if err != nil {
+		t.journal.record(EventDrop, t.nodeID, "", msg, err)
+		return nil, err
+	}
+	for _, id := range result.Failed() {
+		t.journal.record(EventDrop, t.nodeID, id, msg, result[id])
+	}
+	return result, nil
+}
+
+func (t *RecordingTransport) Receive() (Message, error) {
+	msg, err := t.inner.Receive()
+	if err == nil {
+		t.journal.record(EventReceive, t.nodeID, "", msg, nil)
+	}
+	return msg, err
+}
+
+func (t *RecordingTransport) ReceiveTimeout(timeout time.Duration) (Message, error) {
+	msg, err := t.inner.ReceiveTimeout(timeout)
+	if err == nil {
+		t.journal.record(EventReceive, t.nodeID, "", msg, nil)
+	}
+	return msg, err
+}
+
+// ReceiveResponse reads the inner transport's response inbox, or its only
+// inbox if it doesn't have one.
+func (t *RecordingTransport) ReceiveResponse() (Message, error) {
+	rr, ok := t.inner.(ResponseReceiver)
+	if !ok {
+		return t.Receive()
+	}
+	msg, err := rr.ReceiveResponse()
+	if err == nil {
+		t.journal.record(EventReceive, t.nodeID, "", msg, nil)
+	}
+	return msg, err
+}
+
+func (t *RecordingTransport) ReceiveResponseTimeout(timeout time.Duration) (Message, error) {
+	rr, ok := t.inner.(ResponseReceiver)
+	if !ok {
+		return t.ReceiveTimeout(timeout)
+	}
+	msg, err := rr.ReceiveResponseTimeout(timeout)
+	if err == nil {
+		t.journal.record(EventReceive, t.nodeID, "", msg, nil)
+	}
+	return msg, err
+}
+
+// TryReceiveResponse finds nothing unless the inner transport has a
+// response inbox.
+func (t *RecordingTransport) TryReceiveResponse() (Message, bool) {
+	rr, ok := t.inner.(ResponseReceiver)
+	if !ok {
+		return nil, false
+	}
+	msg, ok := rr.TryReceiveResponse()
+	if ok {
+		t.journal.record(EventReceive, t.nodeID, "", msg, nil)
+	}
+	return msg, ok
+}
+
+func (t *RecordingTransport) DuplicateID() bool {
+	r, ok := t.inner.(IDRegistry)
+	return ok && r.DuplicateID()
+}
+
+func (t *RecordingTransport) Close() error {
+	return t.inner.Close()
+}
+
+func (t *RecordingTransport) NodeID() string {
+	return t.nodeID
+}
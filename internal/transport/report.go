@@ -0,0 +1,154 @@
+// =============================================================================
+// NETWORK REPORT - Measuring What a Round of Consensus Costs
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// The demo's PERFORMANCE exercise asks how long consensus takes and how many
+// messages it needs. The in-memory Network counts every message it carries
+// and times how long each one took to reach its inbox:
+//
+//   net.SetDelay(time.Millisecond, 5*time.Millisecond)
+//   net.ResetReport()
+//   n.Propose([]byte("x"))
+//   r := net.Report()
+//   fmt.Println(r.Messages, r.ByType["paxos.Prepare"], r.Latency.Mean())
+//
+// A single uncontested proposal on N nodes needs at least one Prepare, one
+// Accept and one Learn to each of the N-1 peers, plus their replies.
+//
+// =============================================================================
+// COUNTING RULES
+// =============================================================================
+//
+// - A Broadcast to N-1 peers counts as N-1 messages.
+// - Messages is counted at send time, Delivered when the message lands in
+//   an inbox. The gap is what was dropped (full inbox, node removed).
+// - Latency is measured from Send to enqueue. Without SetDelay it is just
+//   the cost of a channel send.
+//
+// =============================================================================
+
+package transport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var latencyBounds = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// LatencyHistogram buckets delivery latencies. Counts[i] holds latencies up
+// to Bounds[i]; the final entry of Counts holds everything above the last
+// bound.
+type LatencyHistogram struct {
+	Bounds []time.Duration
+	Counts []int
+	Count  int
+	Total  time.Duration
+	Max    time.Duration
+}
+
+func newLatencyHistogram() LatencyHistogram {
+	return LatencyHistogram{
+		Bounds: latencyBounds,
+		Counts: make([]int, len(latencyBounds)+1),
+	}
+}
+
+func (h *LatencyHistogram) observe(d time.Duration) {
+	i := 0
+	for i < len(h.Bounds) && d > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Total += d
+	if d > h.Max {
+		h.Max = d
+	}
+}
+
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Total / time.Duration(h.Count)
+}
+
+type NetworkReport struct {
+	Messages  int
+	Delivered int
+	ByType    map[string]int
+	Latency   LatencyHistogram
+}
+
+type networkStats struct {
+	messages  int
+	delivered int
+	byType    map[string]int
+	latency   LatencyHistogram
+	mu        sync.Mutex
+}
+
+func newNetworkStats() *networkStats {
+	return &networkStats{
+		byType:  make(map[string]int),
+		latency: newLatencyHistogram(),
+	}
+}
+
+func (s *networkStats) recordSent(msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages++
+	s.byType[fmt.Sprintf("%T", msg)]++
+}
+
+func (s *networkStats) recordDelivered(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delivered++
+	s.latency.observe(d)
+}
+
+// Report returns a snapshot of everything counted since the network was
+// created or ResetReport was last called.
+func (n *Network) Report() NetworkReport {
+	s := n.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byType := make(map[string]int, len(s.byType))
+	for k, v := range s.byType {
+		byType[k] = v
+	}
+	latency := s.latency
+	latency.Counts = append([]int(nil), s.latency.Counts...)
+	return NetworkReport{
+		Messages:  s.messages,
+		Delivered: s.delivered,
+		ByType:    byType,
+		Latency:   latency,
+	}
+}
+
+func (n *Network) ResetReport() {
+	s := n.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = 0
+	s.delivered = 0
+	s.byType = make(map[string]int)
+	s.latency = newLatencyHistogram()
+}
@@ -0,0 +1,38 @@
+package transport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogramBuckets(t *testing.T) {
+	h := newLatencyHistogram()
+	for _, d := range []time.Duration{0, time.Millisecond, 3 * time.Millisecond, 2 * time.Second} {
+		h.observe(d)
+	}
+	if h.Counts[0] != 2 || h.Counts[1] != 1 || h.Counts[len(h.Counts)-1] != 1 {
+		t.Fatalf("counts = %v", h.Counts)
+	}
+	if h.Count != 4 || h.Max != 2*time.Second || h.Mean() != (2*time.Second+4*time.Millisecond)/4 {
+		t.Fatalf("count %d, max %v, mean %v", h.Count, h.Max, h.Mean())
+	}
+}
+
+func TestReportCountsBroadcastPerPeer(t *testing.T) {
+	net := NewNetwork()
+	defer net.Close()
+	a := net.AddNode("a")
+	net.AddNode("b")
+	net.AddNode("c")
+	if err := a.Broadcast(testRequest{From: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	r := net.Report()
+	if r.Messages != 2 || r.Delivered != 2 || r.ByType["transport.testRequest"] != 2 {
+		t.Fatalf("report = %+v, want 2 testRequests sent and delivered", r)
+	}
+	net.ResetReport()
+	if r := net.Report(); r.Messages != 0 || len(r.ByType) != 0 || r.Latency.Count != 0 {
+		t.Fatalf("report after reset = %+v", r)
+	}
+}
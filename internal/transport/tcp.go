@@ -0,0 +1,525 @@
+// =============================================================================
+// TCP TRANSPORT - Stream Transport for WAN Clusters, Optionally over TLS
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// A Transport that carries Paxos messages over TCP connections. Compared to
+// UDPTransport it has no datagram size ceiling and survives networks that
+// drop or mangle UDP, at the cost of connection management.
+//
+// Each transport listens on one address and keeps at most one outbound
+// connection per peer, dialed lazily on the first Send. Replies travel on
+// the replier's own outbound connection, so a pair of nodes normally has
+// two connections between them, one in each direction.
+//
+// =============================================================================
+// FRAMING
+// =============================================================================
+//
+// Frames use the same layout as UDPTransport:
+//
+//   ┌───────────┬──────────────┬─────────────────────────┐
+//   │ kind (u8) │ length (u32) │ payload (Codec-encoded) │
+//   └───────────┴──────────────┴─────────────────────────┘
+//
+// On a stream there is no datagram boundary to resynchronise on, so a frame
+// with an unknown kind or a length above maxFrameSize closes the connection.
+//
+// =============================================================================
+// FAILURES
+// =============================================================================
+//
+// A failed write closes the connection and returns the error; the next Send
+// dials again. Every frame is written under a deadline (tcpWriteTimeout, or
+// WithWriteTimeout), so a peer that stops reading fails the write instead
+// of blocking the sender, and every Send queued behind it, forever; the
+// timed-out connection is dropped and redialed like any other. By default
+// nothing is retransmitted here - a lost message is just a lost message,
+// which Paxos already tolerates.
+//
+// WithSendRetry(attempts, base) covers the common case of a blip that a
+// quick reconnect would have survived. A Send whose dial or write fails
+// returns nil at once and hands the frame to a background goroutine, which
+// redials and writes it up to attempts times, waiting base, 2*base, 4*base
+// ... plus up to as much again of jitter between tries. After the last
+// failure the frame is dropped and counted in Dropped. The caller never
+// waits, so a proposer is no slower with a peer down than without retries.
+// Unknown peers, TLS handshake failures and a closed transport aren't
+// transient and are returned as before.
+//
+// WithIdleTimeout(d) closes connections that carry no frame for d: outbound
+// ones from a timer, inbound ones through a read deadline. The transport
+// itself stays open and redials on the next Send.
+//
+// =============================================================================
+// TLS
+// =============================================================================
+//
+// WithTLS(config) wraps both the listener and every dialed connection:
+//
+//   cfg := &tls.Config{
+//       Certificates: []tls.Certificate{nodeCert},
+//       RootCAs:      clusterCA,                      // verify servers
+//       ClientCAs:    clusterCA,                      // verify clients
+//       ClientAuth:   tls.RequireAndVerifyClientCert, // mutual auth
+//   }
+//   t, err := NewTCPTransport(id, addr, peers, GobCodec{}, WithTLS(cfg))
+//
+// The handshake runs when a connection is dialed, before any frame is
+// written. If it fails - untrusted certificate, wrong name, no client cert
+// - Send returns an error wrapping ErrTLSHandshake, so certificate problems
+// can be told apart from a peer that is simply down. If config.ServerName is
+// empty it is set to the host part of the peer address.
+//
+// =============================================================================
+
+package transport
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	maxFrameSize     = 16 << 20
+	tcpDialTimeout   = 5 * time.Second
+	handshakeTimeout = 5 * time.Second
+	tcpWriteTimeout  = 5 * time.Second
+)
+
+var ErrTLSHandshake = errors.New("tls handshake failed")
+
+type TCPOption func(*TCPTransport)
+
+// WithTLS secures every connection with config. See the TLS section above.
+func WithTLS(config *tls.Config) TCPOption {
+	return func(t *TCPTransport) {
+		t.tlsConfig = config
+	}
+}
+
+// WithSendRetry retries failed sends in the background; see FAILURES above.
+func WithSendRetry(attempts int, base time.Duration) TCPOption {
+	return func(t *TCPTransport) {
+		t.retries = attempts
+		t.retryBase = base
+	}
+}
+
+// WithWriteTimeout bounds how long writing one frame may take.
+func WithWriteTimeout(d time.Duration) TCPOption {
+	return func(t *TCPTransport) {
+		t.writeTimeout = d
+	}
+}
+
+// WithIdleTimeout closes any connection that goes d without a frame.
+func WithIdleTimeout(d time.Duration) TCPOption {
+	return func(t *TCPTransport) {
+		t.idleTimeout = d
+	}
+}
+
+type tcpConn struct {
+	conn     net.Conn
+	mu       sync.Mutex
+	lastUsed time.Time
+	idle     *time.Timer
+}
+
+type TCPTransport struct {
+	nodeID       string
+	listener     net.Listener
+	codec        Codec
+	tlsConfig    *tls.Config
+	idleTimeout  time.Duration
+	writeTimeout time.Duration
+	retries      int
+	retryBase    time.Duration
+	dropped      atomic.Uint64
+	done         chan struct{}
+	peers        map[string]string
+	outbound     map[string]*tcpConn
+	inbound      map[net.Conn]bool
+	inbox        chan Message
+	responses    chan Message
+	closed       bool
+	mu           sync.RWMutex
+	wg           sync.WaitGroup
+}
+
+func NewTCPTransport(id, listenAddr string, peers map[string]string, codec Codec, opts ...TCPOption) (*TCPTransport, error) {
+	t := &TCPTransport{
+		nodeID:       id,
+		codec:        codec,
+		peers:        make(map[string]string),
+		outbound:     make(map[string]*tcpConn),
+		inbound:      make(map[net.Conn]bool),
+		inbox:        make(chan Message, 100),
+		responses:    make(chan Message, 100),
+		done:         make(chan struct{}),
+		writeTimeout: tcpWriteTimeout,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	if t.tlsConfig != nil {
+		listener = tls.NewListener(listener, t.tlsConfig)
+	}
+	t.listener = listener
+	for peerID, addr := range peers {
+		t.peers[peerID] = addr
+	}
+	t.wg.Add(1)
+	go t.acceptLoop()
+	return t, nil
+}
+
+func (t *TCPTransport) AddPeer(id, addr string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.peers[id] = addr
+}
+
+func (t *TCPTransport) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+func (t *TCPTransport) Send(to string, msg Message) error {
+	payload, err := t.codec.Encode(msg)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxFrameSize {
+		return ErrMessageTooLarge
+	}
+	frame := make([]byte, frameHeaderSize+len(payload))
+	frame[0] = frameKindData
+	binary.BigEndian.PutUint32(frame[1:frameHeaderSize], uint32(len(payload)))
+	copy(frame[frameHeaderSize:], payload)
+
+	err = t.write(to, frame)
+	if err != nil && t.retryable(err) && t.retryLater(to, frame) {
+		return nil
+	}
+	return err
+}
+
+func (t *TCPTransport) write(to string, frame []byte) error {
+	c, err := t.connection(to)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.conn.SetWriteDeadline(time.Now().Add(t.writeTimeout))
+	_, err = c.conn.Write(frame)
+	c.lastUsed = time.Now()
+	c.mu.Unlock()
+	if err != nil {
+		t.dropConnection(to, c)
+		return err
+	}
+	return nil
+}
+
+func (t *TCPTransport) retryable(err error) bool {
+	return t.retries > 0 &&
+		!errors.Is(err, ErrClosed) &&
+		!errors.Is(err, ErrUnknownNode) &&
+		!errors.Is(err, ErrTLSHandshake)
+}
+
+// retryLater starts the background redelivery of frame, unless the
+// transport is closing.
+func (t *TCPTransport) retryLater(to string, frame []byte) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return false
+	}
+	t.wg.Add(1)
+	go t.retry(to, frame)
+	return true
+}
+
+func (t *TCPTransport) retry(to string, frame []byte) {
+	defer t.wg.Done()
+	for attempt := 0; attempt < t.retries; attempt++ {
+		wait := t.retryBase << attempt
+		if wait > 0 {
+			wait += time.Duration(rand.Int63n(int64(wait) + 1))
+		}
+		select {
+		case <-t.done:
+			return
+		case <-time.After(wait):
+		}
+		err := t.write(to, frame)
+		if err == nil {
+			return
+		}
+		if !t.retryable(err) {
+			break
+		}
+	}
+	t.dropped.Add(1)
+}
+
+// Dropped is how many frames WithSendRetry gave up on.
+func (t *TCPTransport) Dropped() uint64 {
+	return t.dropped.Load()
+}
+
+// Broadcast sends to every peer and, after trying them all, returns every
+// failure joined into one error; see BroadcastResult.Err.
+func (t *TCPTransport) Broadcast(msg Message) error {
+	result, err := t.BroadcastDetailed(msg)
+	if err != nil {
+		return err
+	}
+	return result.Err()
+}
+
+func (t *TCPTransport) BroadcastDetailed(msg Message) (BroadcastResult, error) {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return nil, ErrClosed
+	}
+	ids := make([]string, 0, len(t.peers))
+	for id := range t.peers {
+		if id != t.nodeID {
+			ids = append(ids, id)
+		}
+	}
+	t.mu.RUnlock()
+	if len(ids) == 0 {
+		return nil, ErrNoPeers
+	}
+	result := make(BroadcastResult, len(ids))
+	for _, id := range ids {
+		result[id] = t.Send(id, msg)
+	}
+	return result, nil
+}
+
+// Receive returns the next request. Messages of a type passed to
+// RegisterResponse go to a separate inbox, as in MemoryTransport.
+func (t *TCPTransport) Receive() (Message, error) {
+	return receiveFrom(t.inbox, -1)
+}
+
+func (t *TCPTransport) ReceiveTimeout(timeout time.Duration) (Message, error) {
+	return receiveFrom(t.inbox, timeout)
+}
+
+func (t *TCPTransport) ReceiveResponse() (Message, error) {
+	return receiveFrom(t.responses, -1)
+}
+
+func (t *TCPTransport) ReceiveResponseTimeout(timeout time.Duration) (Message, error) {
+	return receiveFrom(t.responses, timeout)
+}
+
+func (t *TCPTransport) TryReceiveResponse() (Message, bool) {
+	return tryReceive(t.responses)
+}
+
+func (t *TCPTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	close(t.done)
+	err := t.listener.Close()
+	for _, c := range t.outbound {
+		if c.idle != nil {
+			c.idle.Stop()
+		}
+		c.conn.Close()
+	}
+	for conn := range t.inbound {
+		conn.Close()
+	}
+	t.mu.Unlock()
+	t.wg.Wait()
+	close(t.inbox)
+	close(t.responses)
+	return err
+}
+
+func (t *TCPTransport) NodeID() string {
+	return t.nodeID
+}
+
+func (t *TCPTransport) connection(to string) (*tcpConn, error) {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return nil, ErrClosed
+	}
+	c, ok := t.outbound[to]
+	addr, known := t.peers[to]
+	t.mu.RUnlock()
+	if ok {
+		return c, nil
+	}
+	if !known {
+		return nil, ErrUnknownNode
+	}
+
+	conn, err := t.dial(addr)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		conn.Close()
+		return nil, ErrClosed
+	}
+	if existing, ok := t.outbound[to]; ok {
+		conn.Close()
+		return existing, nil
+	}
+	c = &tcpConn{conn: conn, lastUsed: time.Now()}
+	if t.idleTimeout > 0 {
+		c.mu.Lock()
+		c.idle = time.AfterFunc(t.idleTimeout, func() { t.expire(to, c) })
+		c.mu.Unlock()
+	}
+	t.outbound[to] = c
+	return c, nil
+}
+
+// expire drops an outbound connection once it has been idle for the full
+// timeout, or re-arms for the time remaining.
+func (t *TCPTransport) expire(to string, c *tcpConn) {
+	c.mu.Lock()
+	elapsed := time.Since(c.lastUsed)
+	if elapsed < t.idleTimeout {
+		c.idle.Reset(t.idleTimeout - elapsed)
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+	t.dropConnection(to, c)
+}
+
+func (t *TCPTransport) dial(addr string) (net.Conn, error) {
+	raw, err := net.DialTimeout("tcp", addr, tcpDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if t.tlsConfig == nil {
+		return raw, nil
+	}
+	cfg := t.tlsConfig.Clone()
+	if cfg.ServerName == "" {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			cfg.ServerName = host
+		}
+	}
+	conn := tls.Client(raw, cfg)
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := conn.Handshake(); err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("%w: %v", ErrTLSHandshake, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func (t *TCPTransport) dropConnection(to string, c *tcpConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.outbound[to] == c {
+		delete(t.outbound, to)
+	}
+	if c.idle != nil {
+		c.idle.Stop()
+	}
+	c.conn.Close()
+}
+
+func (t *TCPTransport) acceptLoop() {
+	defer t.wg.Done()
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			conn.Close()
+			return
+		}
+		t.inbound[conn] = true
+		t.wg.Add(1)
+		t.mu.Unlock()
+		go t.readLoop(conn)
+	}
+}
+
+func (t *TCPTransport) readLoop(conn net.Conn) {
+	defer t.wg.Done()
+	defer func() {
+		t.mu.Lock()
+		delete(t.inbound, conn)
+		t.mu.Unlock()
+		conn.Close()
+	}()
+	if tc, ok := conn.(*tls.Conn); ok {
+		tc.SetDeadline(time.Now().Add(handshakeTimeout))
+		if err := tc.Handshake(); err != nil {
+			return
+		}
+		tc.SetDeadline(time.Time{})
+	}
+	var header [frameHeaderSize]byte
+	for {
+		if t.idleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(t.idleTimeout))
+		}
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		length := binary.BigEndian.Uint32(header[1:])
+		if header[0] != frameKindData || length > maxFrameSize {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		msg, err := t.codec.Decode(payload)
+		if err != nil {
+			continue
+		}
+		select {
+		case inboxFor(msg, t.inbox, t.responses) <- msg:
+		default:
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package transport
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"quorum/internal/testutil"
+)
+
+func newTCPPair(t *testing.T, opts ...TCPOption) (*TCPTransport, *TCPTransport) {
+	t.Helper()
+	a, err := NewTCPTransport("a", "127.0.0.1:0", nil, GobCodec{}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	b, err := NewTCPTransport("b", "127.0.0.1:0", nil, GobCodec{}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	a.AddPeer("b", b.Addr().String())
+	b.AddPeer("a", a.Addr().String())
+	return a, b
+}
+
+func TestTCPSeparatesResponses(t *testing.T) {
+	a, b := newTCPPair(t)
+	if err := a.Send("b", testResponse{From: "a", N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Send("b", testRequest{From: "a", N: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := mustReceive(t, b.ReceiveTimeout); msg != (testRequest{From: "a", N: 2}) {
+		t.Fatalf("request inbox got %#v", msg)
+	}
+	if msg := mustReceive(t, b.ReceiveResponseTimeout); msg != (testResponse{From: "a", N: 1}) {
+		t.Fatalf("response inbox got %#v", msg)
+	}
+	if msg, ok := b.TryReceiveResponse(); ok {
+		t.Fatalf("TryReceiveResponse on an empty inbox returned %#v", msg)
+	}
+}
+
+type bulkMessage struct {
+	From string
+	Data []byte
+}
+
+func (m bulkMessage) GetFrom() string { return m.From }
+
+func init() {
+	RegisterMessage(bulkMessage{})
+}
+
+func TestTCPTLS(t *testing.T) {
+	ca := testutil.NewTestCA(t)
+	a, err := NewTCPTransport("a", "127.0.0.1:0", nil, GobCodec{}, WithTLS(ca.TLSConfig(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewTCPTransport("b", "127.0.0.1:0", nil, GobCodec{}, WithTLS(ca.TLSConfig(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	a.AddPeer("b", b.Addr().String())
+
+	if err := a.Send("b", testRequest{From: "a", N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := mustReceive(t, b.ReceiveTimeout); msg != (testRequest{From: "a", N: 1}) {
+		t.Fatalf("got %#v", msg)
+	}
+	a.mu.RLock()
+	_, secure := a.outbound["b"].conn.(*tls.Conn)
+	a.mu.RUnlock()
+	if !secure {
+		t.Fatal("outbound connection is not TLS")
+	}
+}
+
+func TestTCPTLSUntrustedCert(t *testing.T) {
+	a, err := NewTCPTransport("a", "127.0.0.1:0", nil, GobCodec{}, WithTLS(testutil.NewTestCA(t).TLSConfig(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewTCPTransport("b", "127.0.0.1:0", nil, GobCodec{}, WithTLS(testutil.NewTestCA(t).TLSConfig(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	a.AddPeer("b", b.Addr().String())
+
+	if err := a.Send("b", testRequest{From: "a", N: 1}); !errors.Is(err, ErrTLSHandshake) {
+		t.Fatalf("Send to a peer with an untrusted cert: err = %v, want ErrTLSHandshake", err)
+	}
+	if msg, err := b.ReceiveTimeout(50 * time.Millisecond); err == nil {
+		t.Fatalf("untrusted peer delivered %#v", msg)
+	}
+}
+
+func TestTCPWriteTimeoutDropsConnection(t *testing.T) {
+	// A peer that accepts connections and never reads from them.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			accepted.Add(1)
+		}
+	}()
+
+	a, err := NewTCPTransport("a", "127.0.0.1:0", map[string]string{"b": ln.Addr().String()}, GobCodec{},
+		WithWriteTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	big := bulkMessage{From: "a", Data: make([]byte, 8<<20)}
+	var sendErr error
+	start := time.Now()
+	for i := 0; i < 8 && sendErr == nil; i++ {
+		sendErr = a.Send("b", big)
+	}
+	if sendErr == nil {
+		t.Fatal("writes to a peer that never reads kept succeeding")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("the blocked write took %v to fail", elapsed)
+	}
+	a.mu.RLock()
+	_, kept := a.outbound["b"]
+	a.mu.RUnlock()
+	if kept {
+		t.Fatal("timed-out connection was kept")
+	}
+
+	if err := a.Send("b", testRequest{From: "a", N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for accepted.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("peer saw %d connections, want a redial after the timeout", accepted.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTCPIdleConnectionCloses(t *testing.T) {
+	a, b := newTCPPair(t, WithIdleTimeout(30*time.Millisecond))
+	if err := a.Send("b", testRequest{From: "a", N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	mustReceive(t, b.ReceiveTimeout)
+	outbound := func() bool {
+		a.mu.RLock()
+		defer a.mu.RUnlock()
+		_, ok := a.outbound["b"]
+		return ok
+	}
+	if !outbound() {
+		t.Fatal("no outbound connection after a send")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for outbound() {
+		if time.Now().After(deadline) {
+			t.Fatal("idle connection was never closed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The next send dials again.
+	if err := a.Send("b", testRequest{From: "a", N: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := mustReceive(t, b.ReceiveTimeout); msg != (testRequest{From: "a", N: 2}) {
+		t.Fatalf("got %#v after redialling", msg)
+	}
+}
+
+func TestTCPSendRetryRidesOutRestart(t *testing.T) {
+	a, b := newTCPPair(t, WithSendRetry(6, 20*time.Millisecond))
+	if err := a.Send("b", testRequest{From: "a", N: 0}); err != nil {
+		t.Fatal(err)
+	}
+	mustReceive(t, b.ReceiveTimeout)
+
+	addr := b.Addr().String()
+	b.Close()
+	// Sends while b is down must neither fail nor wait for the redials.
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		if err := a.Send("b", testRequest{From: "a", N: i}); err != nil {
+			t.Fatalf("send %d while b is down: %v", i, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if took := time.Since(start); took > 100*time.Millisecond {
+		t.Fatalf("three sends took %v with b down", took)
+	}
+
+	b, err := NewTCPTransport("b", addr, map[string]string{"a": a.Addr().String()}, GobCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	// The first send may vanish into the dead connection before a notices;
+	// the ones after it are redialled and delivered.
+	for want := 2; want <= 3; {
+		msg := mustReceive(t, b.ReceiveTimeout).(testRequest)
+		if msg.N >= want {
+			want = msg.N + 1
+		}
+	}
+	if got := a.Dropped(); got != 0 {
+		t.Fatalf("Dropped = %d, want every retried frame delivered", got)
+	}
+}
+
+func TestTCPSendRetryGivesUp(t *testing.T) {
+	a, b := newTCPPair(t, WithSendRetry(3, 5*time.Millisecond))
+	b.Close()
+	for i := 0; i < 3; i++ {
+		if err := a.Send("b", testRequest{From: "a", N: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for a.Dropped() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("retries to a peer that never returns were never given up")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTCPBroadcastDetailedPinpointsFailedPeer(t *testing.T) {
+	a, b := newTCPPair(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := ln.Addr().String()
+	ln.Close()
+	a.AddPeer("c", dead)
+
+	result, err := a.BroadcastDetailed(testRequest{From: "a", N: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 || result["b"] != nil || result["c"] == nil {
+		t.Fatalf("result = %v, want b to succeed and c to fail", result)
+	}
+	if failed := result.Failed(); len(failed) != 1 || failed[0] != "c" {
+		t.Fatalf("Failed = %v, want [c]", failed)
+	}
+	if err := result.Err(); err == nil || !errors.Is(err, result["c"]) || !strings.HasPrefix(err.Error(), "c: ") {
+		t.Fatalf("Err = %v, want c's error named", err)
+	}
+	if msg := mustReceive(t, b.ReceiveTimeout); msg != (testRequest{From: "a", N: 1}) {
+		t.Fatalf("b received %#v", msg)
+	}
+	if err := a.Broadcast(testRequest{From: "a", N: 2}); err == nil {
+		t.Fatal("Broadcast hid the failed peer")
+	}
+
+	// The same holds for a destination the sender has no address for.
+	r := BroadcastResult{"b": nil, "nobody": ErrUnknownNode}
+	if failed := r.Failed(); len(failed) != 1 || failed[0] != "nobody" || !errors.Is(r.Err(), ErrUnknownNode) {
+		t.Fatalf("Failed = %v, Err = %v", failed, r.Err())
+	}
+	if (BroadcastResult{"b": nil}).Err() != nil {
+		t.Fatal("Err is non-nil with every send succeeding")
+	}
+}
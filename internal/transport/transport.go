@@ -231,6 +231,8 @@ package transport
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 )
 
@@ -246,9 +248,101 @@ type Transport interface {
 	Close() error
 }
 
+// ResponseReceiver is implemented by transports that keep replies meant
+// for the local proposer apart from requests for the local acceptor, so
+// the two readers never take each other's messages. See REQUESTS AND
+// RESPONSES in memory.go. TryReceiveResponse takes a queued response
+// without waiting, and reports false if there is none.
+type ResponseReceiver interface {
+	ReceiveResponse() (Message, error)
+	ReceiveResponseTimeout(timeout time.Duration) (Message, error)
+	TryReceiveResponse() (Message, bool)
+}
+
+// inboxFor picks the inbox a received message belongs in; see REQUESTS AND
+// RESPONSES in memory.go.
+func inboxFor(msg Message, requests, responses chan Message) chan Message {
+	if isResponse(msg) {
+		return responses
+	}
+	return requests
+}
+
+// receiveFrom reads one message from inbox. A negative timeout waits
+// forever.
+func receiveFrom(inbox chan Message, timeout time.Duration) (Message, error) {
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case msg, ok := <-inbox:
+		if !ok {
+			return nil, ErrClosed
+		}
+		return msg, nil
+	case <-expired:
+		return nil, ErrTimeout
+	}
+}
+
+func tryReceive(inbox chan Message) (Message, bool) {
+	select {
+	case msg, ok := <-inbox:
+		return msg, ok
+	default:
+		return nil, false
+	}
+}
+
+// DetailedBroadcaster is implemented by transports that can say which
+// destinations of a broadcast failed. The error is for the broadcast as a
+// whole - ErrClosed, ErrNoPeers - and the result is nil when it is set.
+type DetailedBroadcaster interface {
+	BroadcastDetailed(msg Message) (BroadcastResult, error)
+}
+
+// BroadcastResult maps every destination of a broadcast to its send error,
+// nil for those that succeeded.
+type BroadcastResult map[string]error
+
+// Failed lists the destinations whose send failed, sorted.
+func (r BroadcastResult) Failed() []string {
+	var failed []string
+	for id, err := range r {
+		if err != nil {
+			failed = append(failed, id)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+// Err joins every failed send into one error naming its destination, or
+// returns nil if all succeeded. errors.Is sees each underlying error.
+func (r BroadcastResult) Err() error {
+	var errs []error
+	for _, id := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", id, r[id]))
+	}
+	return errors.Join(errs...)
+}
+
+// IDRegistry is implemented by transports that register their ID on a
+// shared network and can tell when another transport registered the same
+// one. Two nodes with one ID produce colliding proposal numbers.
+type IDRegistry interface {
+	DuplicateID() bool
+}
+
 var (
 	ErrTimeout    = errors.New("receive timeout")
 	ErrClosed     = errors.New("transport closed")
 	ErrUnknownNode = errors.New("unknown node")
 	ErrInboxFull  = errors.New("inbox full")
+	// ErrNoPeers is returned by Broadcast when no node other than the
+	// sender is registered, which is almost always a wiring mistake.
+	ErrNoPeers = errors.New("no peers to broadcast to")
 )
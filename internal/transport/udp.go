@@ -0,0 +1,257 @@
+// =============================================================================
+// UDP TRANSPORT - Datagram Transport for LAN Clusters
+// =============================================================================
+//
+// =============================================================================
+// WHAT THIS FILE REPRESENTS
+// =============================================================================
+//
+// A Transport that sends each Paxos message as exactly one UDP datagram.
+//
+// UDP lines up with what Paxos already assumes about the network:
+//
+// - Send is fire-and-forget: no connection, no ack, no blocking
+// - Datagrams can be lost, delayed, reordered, or duplicated
+//
+// Paxos tolerates all of these, so there is nothing to add on top for
+// correctness - only framing so a receiver can reject garbage.
+//
+// =============================================================================
+// FRAMING
+// =============================================================================
+//
+//   ┌───────────┬──────────────┬─────────────────────────┐
+//   │ kind (u8) │ length (u32) │ payload (Codec-encoded) │
+//   └───────────┴──────────────┴─────────────────────────┘
+//
+// A datagram whose length field doesn't match its actual size, or whose
+// kind is unknown, is dropped on receive. Messages whose frame would not fit
+// in a single datagram are refused by Send with ErrMessageTooLarge - we do
+// not fragment.
+//
+// =============================================================================
+// DUPLICATES
+// =============================================================================
+//
+// The network may deliver the same datagram twice. The protocol has to
+// tolerate this: the learner already counts acceptors as a set, and the
+// proposer counts distinct responders per phase, so a duplicated Promise or
+// Accepted never counts twice toward a quorum.
+//
+// =============================================================================
+// PEER ADDRESSES
+// =============================================================================
+//
+// Peers are a static map of node ID → "host:port". When listening on ":0"
+// (ephemeral port for tests), create every transport first, then wire them
+// together with AddPeer using each transport's Addr().
+//
+// =============================================================================
+
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	maxDatagramSize = 65507
+	frameHeaderSize = 5
+	frameKindData   = 1
+)
+
+var ErrMessageTooLarge = errors.New("message too large for a single datagram")
+
+type UDPTransport struct {
+	nodeID    string
+	conn      *net.UDPConn
+	codec     Codec
+	peers     map[string]*net.UDPAddr
+	inbox     chan Message
+	responses chan Message
+	closed    bool
+	mu        sync.RWMutex
+	wg        sync.WaitGroup
+}
+
+func NewUDPTransport(id, listenAddr string, peers map[string]string, codec Codec) (*UDPTransport, error) {
+	laddr, err := net.ResolveUDPAddr("udp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	t := &UDPTransport{
+		nodeID:    id,
+		conn:      conn,
+		codec:     codec,
+		peers:     make(map[string]*net.UDPAddr),
+		inbox:     make(chan Message, 100),
+		responses: make(chan Message, 100),
+	}
+	for peerID, addr := range peers {
+		if err := t.AddPeer(peerID, addr); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	t.wg.Add(1)
+	go t.readLoop()
+	return t, nil
+}
+
+func (t *UDPTransport) AddPeer(id, addr string) error {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.peers[id] = raddr
+	return nil
+}
+
+func (t *UDPTransport) Addr() net.Addr {
+	return t.conn.LocalAddr()
+}
+
+func (t *UDPTransport) Send(to string, msg Message) error {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return ErrClosed
+	}
+	raddr, ok := t.peers[to]
+	t.mu.RUnlock()
+	if !ok {
+		return ErrUnknownNode
+	}
+	payload, err := t.codec.Encode(msg)
+	if err != nil {
+		return err
+	}
+	if frameHeaderSize+len(payload) > maxDatagramSize {
+		return ErrMessageTooLarge
+	}
+	frame := make([]byte, frameHeaderSize+len(payload))
+	frame[0] = frameKindData
+	binary.BigEndian.PutUint32(frame[1:frameHeaderSize], uint32(len(payload)))
+	copy(frame[frameHeaderSize:], payload)
+	_, err = t.conn.WriteToUDP(frame, raddr)
+	return err
+}
+
+// Broadcast reports ErrMessageTooLarge, which every peer would hit, but not
+// other per-peer failures; a datagram may be lost anyway. BroadcastDetailed
+// reports them all.
+func (t *UDPTransport) Broadcast(msg Message) error {
+	result, err := t.BroadcastDetailed(msg)
+	if err != nil {
+		return err
+	}
+	for _, err := range result {
+		if err == ErrMessageTooLarge {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *UDPTransport) BroadcastDetailed(msg Message) (BroadcastResult, error) {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return nil, ErrClosed
+	}
+	ids := make([]string, 0, len(t.peers))
+	for id := range t.peers {
+		if id != t.nodeID {
+			ids = append(ids, id)
+		}
+	}
+	t.mu.RUnlock()
+	if len(ids) == 0 {
+		return nil, ErrNoPeers
+	}
+	result := make(BroadcastResult, len(ids))
+	for _, id := range ids {
+		result[id] = t.Send(id, msg)
+	}
+	return result, nil
+}
+
+// Receive returns the next request. Messages of a type passed to
+// RegisterResponse go to a separate inbox, as in MemoryTransport.
+func (t *UDPTransport) Receive() (Message, error) {
+	return receiveFrom(t.inbox, -1)
+}
+
+func (t *UDPTransport) ReceiveTimeout(timeout time.Duration) (Message, error) {
+	return receiveFrom(t.inbox, timeout)
+}
+
+func (t *UDPTransport) ReceiveResponse() (Message, error) {
+	return receiveFrom(t.responses, -1)
+}
+
+func (t *UDPTransport) ReceiveResponseTimeout(timeout time.Duration) (Message, error) {
+	return receiveFrom(t.responses, timeout)
+}
+
+func (t *UDPTransport) TryReceiveResponse() (Message, bool) {
+	return tryReceive(t.responses)
+}
+
+func (t *UDPTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.mu.Unlock()
+	err := t.conn.Close()
+	t.wg.Wait()
+	close(t.inbox)
+	close(t.responses)
+	return err
+}
+
+func (t *UDPTransport) NodeID() string {
+	return t.nodeID
+}
+
+func (t *UDPTransport) readLoop() {
+	defer t.wg.Done()
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, _, err := t.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		if n < frameHeaderSize || buf[0] != frameKindData {
+			continue
+		}
+		length := binary.BigEndian.Uint32(buf[1:frameHeaderSize])
+		if int(length) != n-frameHeaderSize {
+			continue
+		}
+		msg, err := t.codec.Decode(buf[frameHeaderSize:n])
+		if err != nil {
+			continue
+		}
+		select {
+		case inboxFor(msg, t.inbox, t.responses) <- msg:
+		default:
+		}
+	}
+}
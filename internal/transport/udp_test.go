@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func newUDPPair(t *testing.T) (*UDPTransport, *UDPTransport) {
+	t.Helper()
+	a, err := NewUDPTransport("a", "127.0.0.1:0", nil, GobCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	b, err := NewUDPTransport("b", "127.0.0.1:0", nil, GobCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	if err := a.AddPeer("b", b.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddPeer("a", a.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	return a, b
+}
+
+func TestUDPSeparatesResponses(t *testing.T) {
+	a, b := newUDPPair(t)
+	if err := a.Send("b", testResponse{From: "a", N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Send("b", testRequest{From: "a", N: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := mustReceive(t, b.ReceiveTimeout); msg != (testRequest{From: "a", N: 2}) {
+		t.Fatalf("request inbox got %#v", msg)
+	}
+	if msg := mustReceive(t, b.ReceiveResponseTimeout); msg != (testResponse{From: "a", N: 1}) {
+		t.Fatalf("response inbox got %#v", msg)
+	}
+	if msg, ok := b.TryReceiveResponse(); ok {
+		t.Fatalf("TryReceiveResponse on an empty inbox returned %#v", msg)
+	}
+}
+
+func TestUDPRejectsOversizedMessage(t *testing.T) {
+	a, _ := newUDPPair(t)
+	big := bulkMessage{From: "a", Data: make([]byte, maxDatagramSize)}
+	if err := a.Send("b", big); !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("oversized Send: err = %v, want ErrMessageTooLarge", err)
+	}
+}
+
+func TestUDPDropsBadFrames(t *testing.T) {
+	a, b := newUDPPair(t)
+	raw, err := net.DialUDP("udp", nil, b.conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	raw.Write([]byte{frameKindData, 0, 0, 0, 99, 1, 2})
+	raw.Write([]byte{42, 0, 0, 0, 0})
+	if err := a.Send("b", testRequest{From: "a", N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := mustReceive(t, b.ReceiveTimeout); msg != (testRequest{From: "a", N: 1}) {
+		t.Fatalf("got %#v, want the valid frame after the bad ones", msg)
+	}
+}